@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"memberserver/api/models"
+	"memberserver/database"
+	"memberserver/mail"
+	"memberserver/resourcemanager"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// accessRequestEmail is the model for the access request templates
+type accessRequestEmail struct {
+	Name      string
+	Resource  string
+	DecidedBy string
+	Note      string
+}
+
+func (a API) getCurrentUserAccessRequests(w http.ResponseWriter, req *http.Request) {
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	member, err := a.db.GetActiveMemberByEmail(user.GetUserName())
+	if err != nil {
+		log.Errorf("error getting member by email: %s", err)
+		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+		return
+	}
+
+	requests, err := a.db.GetMemberAccessRequests(member.ID)
+	if err != nil {
+		log.Errorf("error getting access requests: %s", err)
+		http.Error(w, errors.New("error getting access requests").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(requests)
+	w.Write(j)
+}
+
+// addCurrentUserAccessRequest lets the logged in member ask to be added to a resource
+func (a API) addCurrentUserAccessRequest(w http.ResponseWriter, req *http.Request) {
+	var accessRequest models.AccessRequestRequest
+
+	err := json.NewDecoder(req.Body).Decode(&accessRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	member, err := a.db.GetActiveMemberByEmail(user.GetUserName())
+	if err != nil {
+		log.Errorf("error getting member by email: %s", err)
+		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = a.db.GetResourceByID(accessRequest.ResourceID)
+	if err != nil {
+		http.Error(w, errors.New("resource not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	ar, err := a.db.AddAccessRequest(member, accessRequest.ResourceID, accessRequest.Note)
+	if err != nil {
+		log.Errorf("error adding access request: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(ar)
+	w.Write(j)
+}
+
+// getAccessRequests returns the queue of pending access requests
+//   admins get every request, trainers only get the requests for the resources they train on
+func (a API) getAccessRequests(w http.ResponseWriter, req *http.Request) {
+	var requests []database.AccessRequest
+	var err error
+
+	if hasRole(req, []UserRole{admin}) {
+		requests, err = a.db.GetPendingAccessRequests()
+	} else {
+		_, user, _ := strategy.AuthenticateRequest(req)
+		requests, err = a.db.GetPendingAccessRequestsForTrainer(user.GetUserName())
+	}
+
+	if err != nil {
+		log.Errorf("error getting access requests: %s", err)
+		http.Error(w, errors.New("error getting access requests").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(requests)
+	w.Write(j)
+}
+
+// decideAccessRequest approves or denies an access request and emails the member the outcome
+//   trainers can only decide requests for the resources they train on
+func (a API) decideAccessRequest(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	id, err := strconv.ParseInt(routeVars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid access request id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	var decision models.DecideAccessRequestRequest
+
+	err = json.NewDecoder(req.Body).Decode(&decision)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ar, err := a.db.GetAccessRequestByID(id)
+	if err != nil {
+		http.Error(w, errors.New("access request not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	if !isTrainerFor(a.db, req, ar.ResourceID) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	ar, err = a.db.DecideAccessRequest(id, decision.Approve, user.GetUserName(), decision.Note)
+	if errors.Is(err, database.ErrAccessRequestDecided) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Errorf("error deciding access request: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(ar)
+	w.Write(j)
+
+	go a.notifyAccessRequestDecided(ar)
+}
+
+// notifyAccessRequestDecided pushes an approved member to the resource and emails the member the outcome
+func (a API) notifyAccessRequestDecided(ar database.AccessRequest) {
+	communication := mail.AccessRequestDenied
+
+	if ar.Status == database.AccessRequestApproved {
+		communication = mail.AccessRequestApproved
+
+		member, err := a.db.GetMemberByID(ar.MemberID)
+		if err != nil {
+			log.Errorf("error getting member to push access request: %s", err)
+		} else {
+			resourcemanager.PushOne(member)
+		}
+	}
+
+	model := accessRequestEmail{
+		Name:     ar.MemberName,
+		Resource: ar.ResourceName,
+		Note:     ar.DecisionNote,
+	}
+	if ar.DecidedBy != nil {
+		model.DecidedBy = *ar.DecidedBy
+	}
+
+	mailApi, _ := mail.Setup()
+	mailer := mail.NewMailer(a.db, mailApi, a.config)
+
+	_, err := mailer.SendCommunication(communication, ar.Email, model)
+	if err != nil {
+		log.Errorf("error emailing access request decision: %s", err)
+	}
+}
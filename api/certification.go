@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"memberserver/api/models"
+	"memberserver/database"
+	"memberserver/resourcemanager"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+func (a API) getCertifications(w http.ResponseWriter, req *http.Request) {
+	certifications, err := a.db.GetCertifications()
+	if err != nil {
+		log.Errorf("error getting certifications: %s", err)
+		http.Error(w, errors.New("error getting certifications").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(certifications)
+	w.Write(j)
+}
+
+func (a API) addCertification(w http.ResponseWriter, req *http.Request) {
+	var certificationRequest models.CertificationRequest
+
+	err := json.NewDecoder(req.Body).Decode(&certificationRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	certification, err := a.db.AddCertification(certificationRequest.Name, certificationRequest.Description)
+	if err != nil {
+		log.Errorf("error adding certification: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(certification)
+	w.Write(j)
+}
+
+func (rs resourceAPI) getCertifications(w http.ResponseWriter, req *http.Request) {
+	certifications, err := rs.db.GetResourceCertifications(req.URL.Query().Get("resource"))
+	if err != nil {
+		log.Errorf("error getting resource certifications: %s", err)
+		http.Error(w, errors.New("error getting resource certifications").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(certifications)
+	w.Write(j)
+}
+
+// setCertifications replaces the certifications a resource requires
+//   and pushes the access list so that members without them are taken off
+func (rs resourceAPI) setCertifications(w http.ResponseWriter, req *http.Request) {
+	var certificationsRequest models.ResourceCertificationsRequest
+
+	err := json.NewDecoder(req.Body).Decode(&certificationsRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r, err := rs.db.GetResourceByID(certificationsRequest.ResourceID)
+	if err != nil {
+		http.Error(w, errors.New("resource not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	certifications, err := rs.db.SetResourceCertifications(r.ID, certificationsRequest.CertificationIDs)
+	if err != nil {
+		log.Errorf("error setting resource certifications: %s", err)
+		http.Error(w, errors.New("error setting resource certifications").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(certifications)
+	w.Write(j)
+
+	go resourcemanager.UpdateResourceACL(r)
+}
+
+func (a API) getMemberCertifications(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	certifications, err := a.db.GetMemberCertifications(routeVars["id"])
+	if err != nil {
+		log.Errorf("error getting member certifications: %s", err)
+		http.Error(w, errors.New("error getting member certifications").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(certifications)
+	w.Write(j)
+}
+
+// addMemberCertification records that a member was trained
+//   and pushes the member to the resources they can now use.
+//   Trainers can only certify members for what their resources require.
+func (a API) addMemberCertification(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	var certificationRequest models.MemberCertificationRequest
+
+	err := json.NewDecoder(req.Body).Decode(&certificationRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	if !hasRole(req, []UserRole{admin}) && !a.db.IsCertificationTrainer(user.GetUserName(), certificationRequest.CertificationID) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	member, err := a.db.GetMemberByID(routeVars["id"])
+	if err != nil {
+		log.Errorf("error getting member by id: %s", err)
+		http.Error(w, errors.New("error getting member by id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	mc := database.MemberCertification{
+		MemberID:        member.ID,
+		CertificationID: certificationRequest.CertificationID,
+		Trainer:         certificationRequest.Trainer,
+		RecordedBy:      user.GetUserName(),
+		ExpiresAt:       certificationRequest.ExpiresAt,
+	}
+
+	if mc.Trainer == "" {
+		mc.Trainer = user.GetUserName()
+	}
+
+	if certificationRequest.CertifiedAt != nil {
+		mc.CertifiedAt = *certificationRequest.CertifiedAt
+	}
+
+	mc, err = a.db.AddMemberCertification(mc)
+	if err != nil {
+		log.Errorf("error adding member certification: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(mc)
+	w.Write(j)
+
+	go resourcemanager.PushOne(member)
+}
+
+// revokeMemberCertification takes a certification away from a member
+//   and takes them off the resources that require it right away
+func (a API) revokeMemberCertification(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	certificationID, err := strconv.ParseInt(routeVars["certificationID"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid certification id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	mc, err := a.db.GetMemberCertificationByID(certificationID)
+	if err != nil || mc.MemberID != routeVars["id"] {
+		http.Error(w, errors.New("certification not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	mc, err = a.db.RevokeMemberCertification(mc.ID)
+	if err != nil {
+		log.Errorf("error revoking member certification: %s", err)
+		http.Error(w, errors.New("error revoking member certification").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(mc)
+	w.Write(j)
+
+	member, err := a.db.GetMemberByID(mc.MemberID)
+	if err != nil {
+		log.Errorf("error getting member to update resources: %s", err)
+		return
+	}
+
+	a.updateMemberResourceACLs(member)
+}
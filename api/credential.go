@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"memberserver/api/models"
+	"memberserver/database"
+	"memberserver/resourcemanager"
+	"memberserver/rfid"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+func (a API) getMemberCredentials(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	credentials, err := a.db.GetMemberCredentials(routeVars["id"])
+	if err != nil {
+		log.Errorf("error getting member credentials: %s", err)
+		http.Error(w, errors.New("error getting member credentials").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(credentials)
+	w.Write(j)
+}
+
+func (a API) addMemberCredential(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	var credentialRequest models.AddCredentialRequest
+
+	err := json.NewDecoder(req.Body).Decode(&credentialRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := a.db.GetMemberByID(routeVars["id"])
+	if err != nil {
+		log.Errorf("error getting member by id: %s", err)
+		http.Error(w, errors.New("error getting member by id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	credential, err := a.db.AddCredential(member.ID, credentialRequest.RFID, rfid.Format(credentialRequest.Format), credentialRequest.Label)
+	if err != nil {
+		log.Errorf("error adding credential: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(credential)
+	w.Write(j)
+
+	go resourcemanager.PushOne(member)
+}
+
+func (a API) updateMemberCredential(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	credentialID, err := strconv.ParseInt(routeVars["credentialID"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid credential id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	var credentialRequest models.UpdateCredentialRequest
+
+	err = json.NewDecoder(req.Body).Decode(&credentialRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := a.db.GetCredentialByID(credentialID)
+	if err != nil || existing.MemberID != routeVars["id"] {
+		http.Error(w, errors.New("credential not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	credential, err := a.db.UpdateCredential(credentialID, credentialRequest.Label, database.CredentialStatus(credentialRequest.Status))
+	if err != nil {
+		log.Errorf("error updating credential: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(credential)
+	w.Write(j)
+
+	if credential.Status == existing.Status {
+		return
+	}
+
+	member, err := a.db.GetMemberByID(credential.MemberID)
+	if err != nil {
+		log.Errorf("error getting member to push credential change: %s", err)
+		return
+	}
+
+	if credential.Status == database.CredentialActive {
+		go resourcemanager.PushOne(member)
+		return
+	}
+
+	// a lost or revoked tag needs to stop working right away
+	a.updateMemberResourceACLs(member)
+}
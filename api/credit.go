@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"memberserver/api/models"
+	"memberserver/database"
+	"memberserver/resourcemanager"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+func (a API) getMemberCredits(w http.ResponseWriter, req *http.Request) {
+	credits, err := a.db.GetMemberCredits()
+	if err != nil {
+		log.Errorf("error getting member credits: %s", err)
+		http.Error(w, errors.New("error getting member credits").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(credits)
+	w.Write(j)
+}
+
+func (a API) addMemberCredit(w http.ResponseWriter, req *http.Request) {
+	var creditRequest models.MemberCreditRequest
+
+	err := json.NewDecoder(req.Body).Decode(&creditRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := a.db.GetMemberByEmail(creditRequest.Email)
+	if err != nil {
+		log.Errorf("error getting member by email: %s", err)
+		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	credit, err := a.db.AddMemberCredit(member.ID, database.CreditReason(creditRequest.Reason), user.GetUserName(), creditRequest.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.db.SetMemberLevel(member.ID, database.Credited, database.TierChangeCredit)
+	if err != nil {
+		log.Errorf("error crediting member: %s", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(credit)
+	w.Write(j)
+
+	go resourcemanager.ApplyTierEntitlements()
+}
+
+func (a API) updateMemberCredit(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	creditID, err := strconv.ParseInt(routeVars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid credit id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	var creditRequest models.MemberCreditRequest
+
+	err = json.NewDecoder(req.Body).Decode(&creditRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	credit, err := a.db.UpdateMemberCredit(creditID, database.CreditReason(creditRequest.Reason), creditRequest.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if credit.Active {
+		err = a.db.SetMemberLevel(credit.MemberID, database.Credited, database.TierChangeCredit)
+		if err != nil {
+			log.Errorf("error crediting member: %s", err)
+		}
+	} else {
+		a.db.RevokeLapsedCredits()
+		a.db.UpdateMemberTiers()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(credit)
+	w.Write(j)
+
+	go resourcemanager.ApplyTierEntitlements()
+}
+
+func (a API) removeMemberCredit(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	creditID, err := strconv.ParseInt(routeVars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid credit id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.db.RemoveMemberCredit(creditID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the member might not have any credit left
+	//   if they have been paying they will get their tier back
+	a.db.RevokeLapsedCredits()
+	a.db.UpdateMemberTiers()
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+
+	go resourcemanager.ApplyTierEntitlements()
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"memberserver/api/models"
+	"memberserver/database"
+	"memberserver/resourcemanager"
+	"memberserver/rfid"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxHostedGuestPass - the longest guest pass a member can host without an admin
+const maxHostedGuestPass = 24 * time.Hour
+
+// getGuests returns every guest pass for admins
+//   members only get the guests they are hosting
+func (a API) getGuests(w http.ResponseWriter, req *http.Request) {
+	var guests []database.Guest
+	var err error
+
+	if hasRole(req, []UserRole{admin}) {
+		guests, err = a.db.GetGuests()
+	} else {
+		_, user, _ := strategy.AuthenticateRequest(req)
+
+		var member database.Member
+		member, err = a.db.GetActiveMemberByEmail(user.GetUserName())
+		if err == nil {
+			guests, err = a.db.GetGuestsByHost(member.ID)
+		}
+	}
+
+	if err != nil {
+		log.Errorf("error getting guests: %s", err)
+		http.Error(w, errors.New("error getting guests").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(guests)
+	w.Write(j)
+}
+
+// addGuest issues a guest pass.
+//   Admins can issue a pass for any resource.  Active members can host a guest
+//   on the resources they have access to for up to a day.
+func (a API) addGuest(w http.ResponseWriter, req *http.Request) {
+	var guestRequest models.GuestRequest
+
+	err := json.NewDecoder(req.Body).Decode(&guestRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	format := rfid.Format(guestRequest.Format)
+	if format == "" {
+		format = rfid.FormatDecimal
+	}
+
+	uid, err := rfid.Decode(format, guestRequest.RFID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	guest := database.Guest{
+		Name:     guestRequest.Name,
+		Email:    guestRequest.Email,
+		RFID:     uid.String(),
+		IssuedBy: user.GetUserName(),
+		StartsAt: guestRequest.StartsAt,
+		EndsAt:   guestRequest.EndsAt,
+	}
+
+	if !hasRole(req, []UserRole{admin}) {
+		host, err := a.db.GetActiveMemberByEmail(user.GetUserName())
+		if err != nil {
+			log.Errorf("error getting member by email: %s", err)
+			http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = checkGuestHost(host, guestRequest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		guest.HostMemberID = &host.ID
+	}
+
+	guest, err = a.db.AddGuest(guest, guestRequest.Resources)
+	if err != nil {
+		log.Errorf("error adding guest: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Infof("guest pass %d for %s issued by %s from %s until %s", guest.ID, guest.Name, guest.IssuedBy, guest.StartsAt.Format(time.RFC3339), guest.EndsAt.Format(time.RFC3339))
+
+	// passes that have already started are pushed right away, the scheduler picks up the rest
+	if !guest.StartsAt.After(time.Now()) {
+		resourcemanager.PushGuest(guest)
+
+		err = a.db.MarkGuestPushed(guest.ID)
+		if err != nil {
+			log.Errorf("error marking guest pass pushed: %s", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(guest)
+	w.Write(j)
+}
+
+// checkGuestHost makes sure a member is allowed to host the guest
+func checkGuestHost(host database.Member, guestRequest models.GuestRequest) error {
+	if host.Level <= uint8(database.Inactive) {
+		return errors.New("only active members can host guests")
+	}
+
+	if guestRequest.EndsAt.Sub(guestRequest.StartsAt) > maxHostedGuestPass {
+		return fmt.Errorf("members can only host a guest for up to %s", maxHostedGuestPass)
+	}
+
+	hostResources := make(map[string]bool)
+	for _, r := range host.Resources {
+		hostResources[r.ResourceID] = true
+	}
+
+	for _, resourceID := range guestRequest.Resources {
+		if !hostResources[resourceID] {
+			return errors.New("members can only host guests on resources they have access to")
+		}
+	}
+
+	return nil
+}
+
+// revokeGuest ends a guest pass early.  Members can revoke the guests they are hosting.
+func (a API) revokeGuest(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	guestID, err := strconv.ParseInt(routeVars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid guest id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	guest, err := a.db.GetGuestByID(guestID)
+	if err != nil {
+		http.Error(w, errors.New("guest not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	if !hasRole(req, []UserRole{admin}) {
+		_, user, _ := strategy.AuthenticateRequest(req)
+
+		host, err := a.db.GetActiveMemberByEmail(user.GetUserName())
+		if err != nil || guest.HostMemberID == nil || *guest.HostMemberID != host.ID {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+	}
+
+	guest, err = a.db.RemoveGuest(guestID, database.GuestRevoked)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Infof("guest pass %d for %s was revoked", guest.ID, guest.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(guest)
+	w.Write(j)
+
+	go resourcemanager.RemoveGuest(guest)
+}
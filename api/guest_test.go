@@ -0,0 +1,45 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+	"testing"
+	"time"
+)
+
+func TestCheckGuestHost(t *testing.T) {
+	host := database.Member{
+		Level:     uint8(database.Standard),
+		Resources: []database.MemberResource{{ResourceID: "frontdoor"}},
+	}
+
+	start := time.Date(2021, 6, 5, 9, 0, 0, 0, time.UTC)
+
+	dayPass := models.GuestRequest{
+		Resources: []string{"frontdoor"},
+		StartsAt:  start,
+		EndsAt:    start.Add(8 * time.Hour),
+	}
+
+	if err := checkGuestHost(host, dayPass); err != nil {
+		t.Errorf("Expected member to be able to host a day pass. %v", err)
+	}
+
+	tooLong := dayPass
+	tooLong.EndsAt = start.Add(48 * time.Hour)
+	if err := checkGuestHost(host, tooLong); err == nil {
+		t.Error("Expected a pass longer than a day to be rejected")
+	}
+
+	otherResource := dayPass
+	otherResource.Resources = []string{"frontdoor", "woodshop"}
+	if err := checkGuestHost(host, otherResource); err == nil {
+		t.Error("Expected a resource the host doesn't have to be rejected")
+	}
+
+	inactive := host
+	inactive.Level = uint8(database.Inactive)
+	if err := checkGuestHost(inactive, dayPass); err == nil {
+		t.Error("Expected an inactive member to be rejected")
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"memberserver/api/models"
 	"memberserver/database"
 	"memberserver/payments"
 	"memberserver/resourcemanager"
 	"memberserver/slack"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -26,7 +29,18 @@ func (a API) getTiers(w http.ResponseWriter, req *http.Request) {
 }
 
 func (a API) getMembers(w http.ResponseWriter, req *http.Request) {
-	members := a.db.GetMembers()
+	filter, err := parseMemberListFilter(req.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	members, err := a.db.GetMemberList(filter)
+	if err != nil {
+		log.Errorf("error getting member list: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 
@@ -34,21 +48,196 @@ func (a API) getMembers(w http.ResponseWriter, req *http.Request) {
 	w.Write(j)
 }
 
+// parseMemberListFilter reads the paging, filtering and sorting options of the member list
+//   from the query string
+func parseMemberListFilter(query url.Values) (database.MemberListFilter, error) {
+	filter := database.MemberListFilter{
+		Resource: query.Get("resource"),
+		Search:   query.Get("search"),
+		Sort:     query.Get("sort"),
+		Order:    query.Get("order"),
+	}
+
+	var err error
+
+	if v := query.Get("page"); v != "" {
+		filter.Page, err = strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid page: %s", v)
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid limit: %s", v)
+		}
+	}
+
+	if v := query.Get("memberLevel"); v != "" {
+		level, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			return filter, fmt.Errorf("invalid memberLevel: %s", v)
+		}
+		filter.Level = uint8(level)
+	}
+
+	if v := query.Get("hasRFID"); v != "" {
+		hasRFID, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid hasRFID: %s", v)
+		}
+		filter.HasRFID = &hasRFID
+	}
+
+	if v := query.Get("pastDue"); v != "" {
+		filter.PastDue, err = strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid pastDue: %s", v)
+		}
+	}
+
+	return filter, nil
+}
+
 // getCurrentMember returns the logged in member details
 func (a API) getCurrentUserMemberInfo(w http.ResponseWriter, req *http.Request) {
 	_, user, _ := strategy.AuthenticateRequest(req)
 
-	member, err := a.db.GetMemberByEmail(user.GetUserName())
+	member, err := a.db.GetActiveMemberByEmail(user.GetUserName())
+
+	if err != nil {
+		log.Errorf("error getting member by email: %s", err)
+		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(member)
+	w.Write(j)
+}
+
+// updateCurrentUserMemberInfo lets the logged in member update their own profile
+func (a API) updateCurrentUserMemberInfo(w http.ResponseWriter, req *http.Request) {
+	var update models.UpdateMemberSelfRequest
+
+	err := json.NewDecoder(req.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
 
+	member, err := a.db.GetActiveMemberByEmail(user.GetUserName())
 	if err != nil {
 		log.Errorf("error getting member by email: %s", err)
 		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
 		return
 	}
 
+	// members can't change their email since it needs to match their payment provider
+	member, err = a.db.UpdateMember(member.ID, update.Name, "", 0)
+	if err != nil {
+		log.Errorf("error updating member: %s", err)
+		http.Error(w, errors.New("unable to update member").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(member)
+	w.Write(j)
+
+	go resourcemanager.PushOne(member)
+}
+
+// getCurrentUserPayments returns the logged in member's payments
+func (a API) getCurrentUserPayments(w http.ResponseWriter, req *http.Request) {
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	member, err := a.db.GetActiveMemberByEmail(user.GetUserName())
+	if err != nil {
+		log.Errorf("error getting member by email: %s", err)
+		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+		return
+	}
+
+	payments, err := a.db.GetMemberPayments(member.ID)
+	if err != nil {
+		log.Errorf("error getting member payments: %s", err)
+		http.Error(w, errors.New("error getting payments").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(payments)
+	w.Write(j)
+}
+
+// getCurrentUserStatus returns the logged in member's tier, last payment
+//   and how long they have until their access is revoked
+func (a API) getCurrentUserStatus(w http.ResponseWriter, req *http.Request) {
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	member, err := a.db.GetActiveMemberByEmail(user.GetUserName())
+	if err != nil {
+		log.Errorf("error getting member by email: %s", err)
+		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, err := a.db.GetMembershipStatus(member)
+	if err != nil {
+		log.Errorf("error getting membership status: %s", err)
+		http.Error(w, errors.New("error getting membership status").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(status)
+	w.Write(j)
+}
+
+func (a API) updateMember(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	memberID := routeVars["id"]
+
+	var update models.UpdateMemberRequest
+
+	err := json.NewDecoder(req.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if update.Level != 0 {
+		if _, ok := a.db.GetMemberTiers().ByID(database.MemberLevel(update.Level)); !ok {
+			http.Error(w, errors.New("invalid member level").Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	member, err := a.db.UpdateMember(memberID, update.Name, update.Email, database.MemberLevel(update.Level))
+	if errors.Is(err, database.ErrCreditedWithoutCredit) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Errorf("error updating member: %s", err)
+		http.Error(w, errors.New("unable to update member").Error(), http.StatusBadRequest)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	j, _ := json.Marshal(member)
 	w.Write(j)
+
+	go resourcemanager.PushOne(member)
+
+	if update.Level != 0 {
+		go resourcemanager.ApplyTierEntitlements()
+	}
 }
 
 func (a API) getMemberByEmail(w http.ResponseWriter, req *http.Request) {
@@ -80,6 +269,13 @@ func (a API) assignRFIDSelf(w http.ResponseWriter, req *http.Request) {
 	}
 	_, user, _ := strategy.AuthenticateRequest(req)
 
+	_, err = a.db.GetActiveMemberByEmail(user.GetUserName())
+	if err != nil {
+		log.Errorf("error getting member by email: %s", err)
+		http.Error(w, errors.New("error getting member by email").Error(), http.StatusBadRequest)
+		return
+	}
+
 	r, err := a.db.SetRFIDTag(user.GetUserName(), assignRFIDRequest.RFID)
 	if err != nil {
 		log.Errorf("error trying to assign rfid to member: %s", err.Error())
@@ -120,6 +316,7 @@ func (a API) assignRFID(w http.ResponseWriter, req *http.Request) {
 func (a API) refreshPayments(w http.ResponseWriter, req *http.Request) {
 	payments.GetPayments()
 
+	a.db.RevokeLapsedCredits()
 	a.db.ApplyMemberCredits()
 	a.db.UpdateMemberTiers()
 
@@ -174,4 +371,96 @@ func (a API) addNewMember(w http.ResponseWriter, req *http.Request) {
 	a.db.AddUserToDefaultResources(newMember.Email)
 
 	go resourcemanager.PushOne(newMember)
+	go resourcemanager.ApplyTierEntitlements()
+}
+
+func (a API) getArchivedMembers(w http.ResponseWriter, req *http.Request) {
+	members := a.db.GetArchivedMembers()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	j, _ := json.Marshal(members)
+	w.Write(j)
+}
+
+func (a API) archiveMember(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	member, err := a.db.ArchiveMember(routeVars["id"])
+	if err != nil {
+		log.Errorf("error archiving member: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+
+	// push the new access lists so the member's rfid is removed from the devices
+	a.updateMemberResourceACLs(member)
+}
+
+// updateMemberResourceACLs pushes the full access list to every resource the member has access to.
+//   PushOne can only add tags, so this is how tags are removed from the devices.
+func (a API) updateMemberResourceACLs(member database.Member) {
+	for _, mr := range member.Resources {
+		resource, err := a.db.GetResourceByID(mr.ResourceID)
+		if err != nil {
+			log.Errorf("error getting resource to update: %s", err)
+			continue
+		}
+
+		go resourcemanager.UpdateResourceACL(resource)
+	}
+}
+
+func (a API) restoreMember(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	member, err := a.db.RestoreMember(routeVars["id"])
+	if err != nil {
+		log.Errorf("error restoring member: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(member)
+	w.Write(j)
+
+	go resourcemanager.PushOne(member)
+	go resourcemanager.ApplyTierEntitlements()
+}
+
+func (a API) getMemberTierHistory(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	history, err := a.db.GetMemberTierHistory(routeVars["id"])
+	if err != nil {
+		log.Errorf("error getting member tier history: %s", err)
+		http.Error(w, errors.New("error getting member tier history").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(history)
+	w.Write(j)
+}
+
+func (a API) getMemberLedger(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	ledger, err := a.db.GetMemberLedger(routeVars["id"])
+	if err != nil {
+		log.Errorf("error getting member ledger: %s", err)
+		http.Error(w, errors.New("error getting member ledger").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(ledger)
+	w.Write(j)
 }
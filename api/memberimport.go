@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"memberserver/database"
+	"memberserver/resourcemanager"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxMemberImportSize - the largest csv file that can be uploaded
+const maxMemberImportSize = 10 << 20
+
+// memberImportResourceSeparator - resources in the csv are separated by this
+//   since the columns are already separated by commas
+const memberImportResourceSeparator = ";"
+
+// parseMemberImportCSV reads the rows of a member import.
+//   The first line must be a header.  Only the email column is required,
+//   the name, tier, rfid and resources columns are optional.
+func parseMemberImportCSV(r io.Reader) ([]database.MemberImportRow, error) {
+	var rows []database.MemberImportRow
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return rows, errors.New("the csv file is empty")
+	}
+	if err != nil {
+		return rows, fmt.Errorf("error reading csv header: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["email"]; !ok {
+		return rows, errors.New("the csv file must have an email column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	// the header is the first row
+	rowNumber := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("error reading csv: %v", err)
+		}
+
+		rowNumber++
+
+		row := database.MemberImportRow{
+			Row:   rowNumber,
+			Name:  field(record, "name"),
+			Email: field(record, "email"),
+			Tier:  field(record, "tier"),
+			RFID:  field(record, "rfid"),
+		}
+
+		if row.Name == "" && row.Email == "" && row.Tier == "" && row.RFID == "" && field(record, "resources") == "" {
+			// skip blank lines
+			continue
+		}
+
+		for _, resource := range strings.Split(field(record, "resources"), memberImportResourceSeparator) {
+			resource = strings.TrimSpace(resource)
+			if resource != "" {
+				row.Resources = append(row.Resources, resource)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// importMembers accepts a csv of members and returns what the import would change.
+//   The import is only applied when `confirm=true` is passed in the query string.
+func (a API) importMembers(w http.ResponseWriter, req *http.Request) {
+	confirm := false
+	if v := req.URL.Query().Get("confirm"); v != "" {
+		var err error
+		confirm, err = strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid confirm: %s", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxMemberImportSize)
+
+	var file io.Reader = req.Body
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		f, _, err := req.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		file = f
+	}
+
+	rows, err := parseMemberImportCSV(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	result, err := a.db.ImportMembers(rows, confirm, user.GetUserName())
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if errors.Is(err, database.ErrMemberImportHasProblems) {
+		w.WriteHeader(http.StatusConflict)
+	} else if err != nil {
+		log.Errorf("error importing members: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	j, _ := json.Marshal(result)
+	w.Write(j)
+
+	if !result.Applied {
+		return
+	}
+
+	go func() {
+		for _, email := range result.Emails() {
+			resourcemanager.PushOne(database.Member{Email: email})
+		}
+
+		resourcemanager.ApplyTierEntitlements()
+	}()
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMemberImportCSV(t *testing.T) {
+	csv := `Name,Email,Tier,RFID,Resources
+alice,alice@email.com,Standard,0012345678,frontdoor; woodshop
+
+"bob, jr",bob@email.com,,,
+`
+	rows, err := parseMemberImportCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Failed to parse csv. %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, but found %v", len(rows))
+	}
+
+	if rows[0].Row != 2 || rows[0].Email != "alice@email.com" || rows[0].Tier != "Standard" || rows[0].RFID != "0012345678" {
+		t.Errorf("First row was not parsed correctly: %+v", rows[0])
+	}
+
+	if !reflect.DeepEqual(rows[0].Resources, []string{"frontdoor", "woodshop"}) {
+		t.Errorf("Expected resources to be split, but found %v", rows[0].Resources)
+	}
+
+	if rows[1].Name != "bob, jr" || len(rows[1].Resources) != 0 {
+		t.Errorf("Second row was not parsed correctly: %+v", rows[1])
+	}
+}
+
+func TestParseMemberImportCSVOnlyEmail(t *testing.T) {
+	rows, err := parseMemberImportCSV(strings.NewReader("email\nalice@email.com\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse csv. %v", err)
+	}
+
+	if len(rows) != 1 || rows[0].Email != "alice@email.com" {
+		t.Errorf("Expected a single row for alice, but found %+v", rows)
+	}
+}
+
+func TestParseMemberImportCSVRequiresEmail(t *testing.T) {
+	_, err := parseMemberImportCSV(strings.NewReader("name,rfid\nalice,123\n"))
+	if err == nil {
+		t.Error("Expected an error when the email column is missing")
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+// AccessRequestRequest -- ask to be added to a resource
+type AccessRequestRequest struct {
+	// ResourceID - the resource the member wants access to
+	// required: true
+	// example: string
+	ResourceID string `json:"resourceID"`
+	// Note - why the member wants access, i.e. when they were trained
+	// required: false
+	// example: string
+	Note string `json:"note"`
+}
+
+// DecideAccessRequestRequest -- approve or deny an access request
+type DecideAccessRequestRequest struct {
+	// Approve - true to add the member to the resource, false to deny the request
+	// required: true
+	// example: true
+	Approve bool `json:"approve"`
+	// Note - why the request was approved or denied.  This is emailed to the member
+	// required: false
+	// example: string
+	Note string `json:"note"`
+}
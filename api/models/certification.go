@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// CertificationRequest -- add a training that resources can require
+type CertificationRequest struct {
+	// Name - the name of the training
+	// required: true
+	// example: laser cutter safety
+	Name string `json:"name"`
+	// Description - what the training covers
+	// required: false
+	// example: string
+	Description string `json:"description"`
+}
+
+// ResourceCertificationsRequest -- set the certifications a resource requires
+type ResourceCertificationsRequest struct {
+	// ResourceID - the resource that requires the certifications
+	// required: true
+	// example: string
+	ResourceID string `json:"resourceID"`
+	// CertificationIDs - the certifications members need to use the resource.  Leave empty to not require any
+	// required: true
+	// example: [1]
+	CertificationIDs []int `json:"certificationIDs"`
+}
+
+// MemberCertificationRequest -- record that a member was trained
+type MemberCertificationRequest struct {
+	// CertificationID - the training the member completed
+	// required: true
+	// example: 1
+	CertificationID int `json:"certificationID"`
+	// Trainer - who gave the training.  Defaults to the user recording it
+	// required: false
+	// example: string
+	Trainer string `json:"trainer"`
+	// CertifiedAt - when the member was trained.  Defaults to now
+	// required: false
+	// example: 2021-06-05T09:00:00Z
+	CertifiedAt *time.Time `json:"certifiedAt"`
+	// ExpiresAt - when the member needs to be trained again.  Leave empty if it doesn't expire
+	// required: false
+	// example: 2022-06-05T09:00:00Z
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
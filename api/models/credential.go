@@ -0,0 +1,29 @@
+package models
+
+// AddCredentialRequest -- issue an rfid tag to a member
+type AddCredentialRequest struct {
+	// RFID - the tag, i.e. the number printed on it
+	// required: true
+	// example: 0012345678
+	RFID string `json:"rfid"`
+	// Format - how the rfid is written.  Defaults to decimal, the number printed on the tag
+	// required: false
+	// example: decimal
+	Format string `json:"format"`
+	// Label - what the tag is, i.e. card or key fob
+	// required: false
+	// example: key fob
+	Label string `json:"label"`
+}
+
+// UpdateCredentialRequest -- relabel a credential or change its status
+type UpdateCredentialRequest struct {
+	// Label - what the tag is, i.e. card or key fob
+	// required: false
+	// example: key fob
+	Label string `json:"label"`
+	// Status - one of active, lost or revoked
+	// required: true
+	// example: lost
+	Status string `json:"status"`
+}
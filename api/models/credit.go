@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// MemberCreditRequest -- grant or update a member credit
+type MemberCreditRequest struct {
+	// Email - the member's email address.  Only used when granting a new credit
+	// required: false
+	// example: string
+	Email string `json:"email"`
+	// Reason - why the credit was granted.  One of scholarship, volunteer or board
+	// required: true
+	// example: volunteer
+	Reason string `json:"reason"`
+	// ExpiresAt - when the credit ends.  Leave empty for a credit that doesn't expire
+	// required: false
+	// example: 2021-12-31T00:00:00Z
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
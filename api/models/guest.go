@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// GuestRequest -- issue a guest pass
+type GuestRequest struct {
+	// Name - the guest's name
+	// required: true
+	// example: string
+	Name string `json:"name"`
+	// Email - the guest's email address
+	// required: false
+	// example: string
+	Email string `json:"email"`
+	// RFID - the guest's tag, i.e. the number printed on it
+	// required: true
+	// example: 0012345678
+	RFID string `json:"rfid"`
+	// Format - how the rfid is written.  Defaults to decimal, the number printed on the tag
+	// required: false
+	// example: decimal
+	Format string `json:"format"`
+	// Resources - the ids of the resources the guest has access to
+	// required: true
+	Resources []string `json:"resources"`
+	// StartsAt - when the guest pass starts
+	// required: true
+	// example: 2021-06-05T09:00:00Z
+	StartsAt time.Time `json:"startsAt"`
+	// EndsAt - when the guest pass ends
+	// required: true
+	// example: 2021-06-05T17:00:00Z
+	EndsAt time.Time `json:"endsAt"`
+}
@@ -5,3 +5,28 @@ type NewMember struct {
 	Email string `json:"email"`
 	RFID  string `json:"rfid"`
 }
+
+// UpdateMemberRequest - update a member's profile
+type UpdateMemberRequest struct {
+	// Name of the member
+	// required: false
+	// example: string
+	Name string `json:"name"`
+	// Email of the member
+	// required: false
+	// example: string
+	Email string `json:"email"`
+	// MemberLevel - manually set the member's tier.
+	//   Members can only be moved to the credited tier while they have an active credit
+	// required: false
+	// example: 4
+	Level uint8 `json:"memberLevel"`
+}
+
+// UpdateMemberSelfRequest - update the current member's profile
+type UpdateMemberSelfRequest struct {
+	// Name of the member
+	// required: true
+	// example: string
+	Name string `json:"name"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // ChartOptions -- config option for the chart
 type ChartOptions struct {
 	Title     string  `json:"title"`
@@ -27,3 +29,35 @@ type PaymentChart struct {
 	Rows    [][]interface{} `json:"rows"`
 	Cols    []ChartCol      `json:"cols"`
 }
+
+// PaymentRequest -- record a payment that was made in person
+type PaymentRequest struct {
+	// Date - when the payment was made.  Defaults to now
+	// required: false
+	// example: 2021-06-05T00:00:00Z
+	Date *time.Time `json:"date"`
+	// Amount - the amount in minor units, i.e. cents
+	// required: true
+	// example: 3500
+	Amount int64 `json:"amount"`
+	// Currency - the currency code of the amount.  Defaults to USD
+	// required: false
+	// example: USD
+	Currency string `json:"currency"`
+	// Provider - how the payment was made.  One of cash or check
+	// required: true
+	// example: cash
+	Provider string `json:"provider"`
+}
+
+// BackfillRequest -- download the payments of a range of time again
+type BackfillRequest struct {
+	// Start - the first day to download payments for
+	// required: true
+	// example: 2021-06-01T00:00:00Z
+	Start time.Time `json:"start"`
+	// End - the last day to download payments for.  Defaults to now
+	// required: false
+	// example: 2021-09-01T00:00:00Z
+	End *time.Time `json:"end"`
+}
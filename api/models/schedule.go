@@ -0,0 +1,29 @@
+package models
+
+// ScheduleRequest -- limit access to a resource to a window of time
+type ScheduleRequest struct {
+	// ResourceID - the resource the schedule is for.  Ignored when updating a schedule
+	// required: true
+	// example: string
+	ResourceID string `json:"resourceID"`
+	// Level - the tier the schedule applies to.  Leave empty to apply it to every member of the resource
+	// required: false
+	// example: 3
+	Level *int `json:"level"`
+	// Name - what the schedule is for, i.e. open hours
+	// required: false
+	// example: open hours
+	Name string `json:"name"`
+	// Days - the days of the week the schedule opens on, 0 is Sunday
+	// required: true
+	// example: [1, 2, 3, 4, 5]
+	Days []int `json:"days"`
+	// Start - when the schedule opens in 24 hour HH:MM
+	// required: true
+	// example: 09:00
+	Start string `json:"start"`
+	// End - when the schedule closes in 24 hour HH:MM.  Ending before the start runs overnight
+	// required: true
+	// example: 22:00
+	End string `json:"end"`
+}
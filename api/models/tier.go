@@ -0,0 +1,37 @@
+package models
+
+// TierRequest -- add or update a membership tier
+type TierRequest struct {
+	// Name of the tier
+	// required: true
+	// example: Student
+	Name string `json:"name"`
+	// Price - the payment amount in minor units, i.e. cents, that puts a member on the tier
+	// required: true
+	// example: 2000
+	Price int64 `json:"price"`
+	// Currency - the currency code of the price.  Defaults to USD when adding and to the current currency when updating
+	// required: false
+	// example: USD
+	Currency string `json:"currency"`
+	// BillingPeriod - how often the price is paid.  One of month or year
+	// required: true
+	// example: month
+	BillingPeriod string `json:"billingPeriod"`
+	// Active - whether payments are matched to the tier
+	// required: true
+	// example: true
+	Active bool `json:"active"`
+	// SortOrder - where the tier shows up in lists and charts
+	// required: false
+	// example: 6
+	SortOrder int `json:"sortOrder"`
+}
+
+// TierResourcesRequest -- set the resources members of a tier get automatically
+type TierResourcesRequest struct {
+	// ResourceIDs - the resources that come with the tier.  Leave empty to not include any
+	// required: true
+	// example: ["string"]
+	ResourceIDs []string `json:"resourceIDs"`
+}
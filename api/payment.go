@@ -2,28 +2,40 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"memberserver/api/models"
 	"memberserver/database"
+	"memberserver/payments"
+	"memberserver/resourcemanager"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/Rhymond/go-money"
 	"github.com/emirpasic/gods/maps/linkedhashmap"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 )
 
 // countMemberLevels take in a list of payments and return
 //   formatted data to be used in payment charts
-func countMemberLevels(payments []int64) map[database.MemberLevel]uint8 {
+//   payments are counted toward the active tier with the same price, within the payment tolerance
+func countMemberLevels(tiers database.Tiers, payments []money.Money, tolerance int64) map[database.MemberLevel]uint8 {
 	counts := make(map[database.MemberLevel]uint8)
 
 	// set counts to 0
-	//  kind of cheating here by setting them directly
-	counts[database.Inactive] = 0
-	counts[database.Classic] = 0
-	counts[database.Standard] = 0
-	counts[database.Premium] = 0
+	//  credited members don't pay so they are left out unless a payment matches
+	for _, t := range tiers {
+		if t.Active && database.MemberLevel(t.ID) != database.Credited {
+			counts[database.MemberLevel(t.ID)] = 0
+		}
+	}
 
 	for _, p := range payments {
-		if v, found := database.MemberLevelFromAmount[p]; found {
-			counts[v]++
+		if t, found := tiers.ByPrice(p, tolerance); found {
+			counts[database.MemberLevel(t.ID)]++
 		}
 	}
 
@@ -43,7 +55,7 @@ func makeMemberCountTrendChart(payments linkedhashmap.Map) models.PaymentChart {
 	for it.Next() {
 		var row []interface{}
 		row = append(row, it.Key())
-		var paymentAmounts []int64 = it.Value().([]int64)
+		var paymentAmounts []money.Money = it.Value().([]money.Money)
 		row = append(row, len(paymentAmounts))
 		pc.Rows = append(pc.Rows, row)
 	}
@@ -60,19 +72,21 @@ func (a API) getPaymentChart(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	tiers := a.db.GetMemberTiers()
+
 	paymentMapByDate := linkedhashmap.New()
 
 	// get the rows together
 	for _, p := range paymentList {
 		_, found := paymentMapByDate.Get(p.Date.Format("Jan-06"))
-		var paymentAmounts []int64
+		var paymentAmounts []money.Money
 
 		if found {
 			monthPaymentAmounts, _ := paymentMapByDate.Get(p.Date.Format("Jan-06"))
-			paymentAmounts = monthPaymentAmounts.([]int64)
+			paymentAmounts = monthPaymentAmounts.([]money.Money)
 		}
 
-		paymentAmounts = append(paymentAmounts, int64(p.Amount.AsMajorUnits()))
+		paymentAmounts = append(paymentAmounts, p.Amount)
 		paymentMapByDate.Put(p.Date.Format(("Jan-06")), paymentAmounts)
 	}
 
@@ -82,13 +96,13 @@ func (a API) getPaymentChart(w http.ResponseWriter, req *http.Request) {
 		}
 
 		if chartType == "pie" {
-			paymentCharts = makeMemberDistributionChart(*paymentMapByDate, paymentCharts)
+			paymentCharts = makeMemberDistributionChart(tiers, *paymentMapByDate, paymentCharts)
 		}
 	}
 
 	if len(chartType) == 0 {
 		paymentCharts = append(paymentCharts, makeMemberCountTrendChart(*paymentMapByDate))
-		paymentCharts = makeMemberDistributionChart(*paymentMapByDate, paymentCharts)
+		paymentCharts = makeMemberDistributionChart(tiers, *paymentMapByDate, paymentCharts)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -97,7 +111,7 @@ func (a API) getPaymentChart(w http.ResponseWriter, req *http.Request) {
 	w.Write(j)
 }
 
-func makeMemberDistributionChart(payments linkedhashmap.Map, paymentCharts []models.PaymentChart) []models.PaymentChart {
+func makeMemberDistributionChart(tiers database.Tiers, payments linkedhashmap.Map, paymentCharts []models.PaymentChart) []models.PaymentChart {
 	// now the rows are together, but they are in the form of a map
 	// let's massage it out to match our chart contract
 	it := payments.Iterator()
@@ -109,11 +123,17 @@ func makeMemberDistributionChart(payments linkedhashmap.Map, paymentCharts []mod
 		pc.Type = "pie"
 
 		pc.Cols = []models.ChartCol{{Label: "Month", Type: "string"}, {Label: "MemberLevelCount", Type: "number"}}
-		levels := countMemberLevels(it.Value().([]int64))
+		levels := countMemberLevels(tiers, it.Value().([]money.Money), database.PaymentTolerance())
+
+		// keep the rows in the tiers' sort order
+		for _, t := range tiers {
+			count, found := levels[database.MemberLevel(t.ID)]
+			if !found {
+				continue
+			}
 
-		for level, count := range levels {
 			var row []interface{}
-			row = append(row, (string)(database.MemberLevelToStr[level]))
+			row = append(row, t.Name)
 			row = append(row, int(count))
 			pc.Rows = append(pc.Rows, row)
 		}
@@ -123,3 +143,188 @@ func makeMemberDistributionChart(payments linkedhashmap.Map, paymentCharts []mod
 
 	return paymentCharts
 }
+
+// manualProviders - the payments admins record themselves, the others are downloaded from the providers
+var manualProviders = map[database.PaymentProvider]bool{
+	database.Cash:  true,
+	database.Check: true,
+}
+
+func (a API) addMemberPayment(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	var paymentRequest models.PaymentRequest
+
+	err := json.NewDecoder(req.Body).Decode(&paymentRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	provider, ok := database.PaymentProviders[strings.ToLower(paymentRequest.Provider)]
+	if !ok || !manualProviders[provider] {
+		http.Error(w, fmt.Errorf("not a payment that can be recorded: %s", paymentRequest.Provider).Error(), http.StatusBadRequest)
+		return
+	}
+
+	if paymentRequest.Amount <= 0 {
+		http.Error(w, errors.New("a payment needs an amount").Error(), http.StatusBadRequest)
+		return
+	}
+
+	if paymentRequest.Currency == "" {
+		paymentRequest.Currency = "USD"
+	}
+
+	if money.GetCurrency(strings.ToUpper(paymentRequest.Currency)) == nil {
+		http.Error(w, fmt.Errorf("not a valid currency: %s", paymentRequest.Currency).Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := a.db.GetMemberByID(routeVars["id"])
+	if err != nil {
+		http.Error(w, errors.New("member not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	date := time.Now()
+	if paymentRequest.Date != nil {
+		date = *paymentRequest.Date
+	}
+
+	payment, err := a.db.AddPayment(database.Payment{
+		Date:     date,
+		Amount:   *money.New(paymentRequest.Amount, strings.ToUpper(paymentRequest.Currency)),
+		Provider: provider,
+		MemberID: member.ID,
+	})
+	if err != nil {
+		log.Errorf("error recording payment: %s", err)
+		http.Error(w, errors.New("error recording payment").Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.db.UpdateMemberTiers()
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(payment)
+	w.Write(j)
+
+	go resourcemanager.PushOne(member)
+	go resourcemanager.ApplyTierEntitlements()
+}
+
+func (a API) backfillPayments(w http.ResponseWriter, req *http.Request) {
+	var backfillRequest models.BackfillRequest
+
+	err := json.NewDecoder(req.Body).Decode(&backfillRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	end := time.Now()
+	if backfillRequest.End != nil {
+		end = *backfillRequest.End
+	}
+
+	if backfillRequest.Start.IsZero() || !backfillRequest.Start.Before(end) {
+		http.Error(w, errors.New("the backfill has to start before it ends").Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = payments.Backfill(backfillRequest.Start, end)
+
+	// the payments of the providers that could be downloaded were still recorded
+	a.db.RevokeLapsedCredits()
+	a.db.ApplyMemberCredits()
+	a.db.UpdateMemberTiers()
+
+	go resourcemanager.ApplyTierEntitlements()
+
+	if err != nil {
+		log.Errorf("error backfilling payments: %s", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+}
+
+// maxWebhookBytes - the largest webhook event we read
+const maxWebhookBytes = 1 << 20
+
+func (a API) stripeWebhook(w http.ResponseWriter, req *http.Request) {
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBytes))
+	if err != nil {
+		http.Error(w, errors.New("error reading event").Error(), http.StatusBadRequest)
+		return
+	}
+
+	recorded, err := payments.HandleStripeWebhook(payload, req.Header.Get("Stripe-Signature"))
+	if err == payments.ErrStripeSignature {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		// stripe sends the event again when it isn't acknowledged
+		log.Errorf("error handling stripe event: %s", err)
+		http.Error(w, errors.New("error handling stripe event").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	a.applyWebhookPayments(recorded)
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+}
+
+func (a API) paypalWebhook(w http.ResponseWriter, req *http.Request) {
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBytes))
+	if err != nil {
+		http.Error(w, errors.New("error reading event").Error(), http.StatusBadRequest)
+		return
+	}
+
+	recorded, err := payments.HandlePaypalWebhook(payload, req.Header)
+	if err == payments.ErrPaypalSignature {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		// paypal sends the event again when it isn't acknowledged
+		log.Errorf("error handling paypal event: %s", err)
+		http.Error(w, errors.New("error handling paypal event").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	a.applyWebhookPayments(recorded)
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+}
+
+// applyWebhookPayments - updates the tiers of the members that paid and pushes them to the resources
+func (a API) applyWebhookPayments(recorded []database.Payment) {
+	if len(recorded) == 0 {
+		return
+	}
+
+	a.db.UpdateMemberTiers()
+
+	for _, p := range recorded {
+		go resourcemanager.PushOne(database.Member{Email: p.Email})
+	}
+
+	go resourcemanager.ApplyTierEntitlements()
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"memberserver/config"
+	"memberserver/database"
 	"net/http"
 	"strings"
 )
@@ -11,12 +12,16 @@ type UserRole uint
 const (
 	admin UserRole = iota + 1
 	user
+	// trainer can only manage the resources they train on
+	//   handlers that allow trainers have to check the resource with isTrainerFor
+	trainer
 )
 
 func userRoleFromString(role string) UserRole {
 	urMap := map[string]UserRole{
-		"admin": admin,
-		"user":  user,
+		"admin":   admin,
+		"user":    user,
+		"trainer": trainer,
 	}
 
 	if _, ok := urMap[role]; !ok {
@@ -30,6 +35,8 @@ func (ur UserRole) ToString() string {
 	switch ur {
 	case admin:
 		return "admin"
+	case trainer:
+		return "trainer"
 	default:
 		return "user"
 	}
@@ -48,21 +55,41 @@ func contains(slice []string, item string) bool {
 // rbac is middleware that will restrict access based on the roles you pass in
 func (api API) rbac(next http.HandlerFunc, allowedRoles []UserRole) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		conf, _ := config.Load()
-		if strings.Contains(conf.AlwaysAdmin, "true") {
+		if hasRole(r, allowedRoles) {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		_, user, _ := strategy.AuthenticateRequest(r)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+}
 
-		for _, role := range allowedRoles {
-			if contains(user.GetGroups(), role.ToString()) {
-				next.ServeHTTP(w, r)
-				return
-			}
+// hasRole checks if the user making the request has one of the roles
+//   this is for handlers that behave differently for admins
+func hasRole(r *http.Request, allowedRoles []UserRole) bool {
+	conf, _ := config.Load()
+	if strings.Contains(conf.AlwaysAdmin, "true") {
+		return true
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(r)
+
+	for _, role := range allowedRoles {
+		if contains(user.GetGroups(), role.ToString()) {
+			return true
 		}
+	}
 
-		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
-	})
+	return false
+}
+
+// isTrainerFor checks if the user making the request is an admin or trains on the resource
+func isTrainerFor(db *database.Database, r *http.Request, resourceID string) bool {
+	if hasRole(r, []UserRole{admin}) {
+		return true
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(r)
+
+	return db.IsResourceTrainer(user.GetUserName(), resourceID)
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"memberserver/api/models"
 	"memberserver/database"
 	"net/http"
@@ -44,7 +45,7 @@ func (rs resourceAPI) update(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	r, err := rs.db.UpdateResource(updateResourceReq.ID, updateResourceReq.Name, updateResourceReq.Address, updateResourceReq.IsDefault)
+	r, err := rs.db.UpdateResource(updateResourceReq.ID, updateResourceReq.Name, updateResourceReq.Address, updateResourceReq.IsDefault, updateResourceReq.RFIDFormat, updateResourceReq.SupportsSchedules)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -78,6 +79,33 @@ func (rs resourceAPI) delete(w http.ResponseWriter, req *http.Request) {
 	w.Write(j)
 }
 
+// getACL returns the access list of a resource with the tags encoded in the resource's rfid format
+func (rs resourceAPI) getACL(w http.ResponseWriter, req *http.Request) {
+	resourceName := req.URL.Query().Get("resource")
+
+	r, err := rs.db.GetResourceByID(resourceName)
+	if err != nil {
+		r, err = rs.db.GetResourceByName(resourceName)
+	}
+	if err != nil {
+		http.Error(w, errors.New("resource not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	acl, err := rs.db.GetResourceACL(r)
+	if err != nil {
+		log.Errorf("error getting resource acl: %s", err)
+		http.Error(w, errors.New("error getting resource acl").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(acl)
+	w.Write(j)
+}
+
+// addMultipleMembersToResource grants members access to a resource
+//   trainers can only grant access to the resources they train on
 func (rs resourceAPI) addMultipleMembersToResource(w http.ResponseWriter, req *http.Request) {
 	var membersResource models.MembersResourceRelation
 
@@ -88,7 +116,14 @@ func (rs resourceAPI) addMultipleMembersToResource(w http.ResponseWriter, req *h
 		return
 	}
 
-	resource, err := rs.db.AddMultipleMembersToResource(membersResource.Emails, membersResource.ID)
+	if !isTrainerFor(rs.db, req, membersResource.ID) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	resource, err := rs.db.AddMultipleMembersToResource(membersResource.Emails, membersResource.ID, user.GetUserName())
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -100,6 +135,8 @@ func (rs resourceAPI) addMultipleMembersToResource(w http.ResponseWriter, req *h
 	w.Write(j)
 }
 
+// removeMember takes a member off of a resource
+//   trainers can only remove members from the resources they train on
 func (rs resourceAPI) removeMember(w http.ResponseWriter, req *http.Request) {
 	var update models.MemberResourceRelation
 
@@ -109,7 +146,14 @@ func (rs resourceAPI) removeMember(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	err = rs.db.RemoveUserFromResource(update.Email, update.ID)
+	if !isTrainerFor(rs.db, req, update.ID) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	err = rs.db.RemoveUserFromResource(update.Email, update.ID, user.GetUserName())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -139,7 +183,7 @@ func (rs resourceAPI) register(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	r, err := rs.db.RegisterResource(register.Name, register.Address, register.IsDefault)
+	r, err := rs.db.RegisterResource(register.Name, register.Address, register.IsDefault, register.RFIDFormat, register.SupportsSchedules)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -54,6 +54,618 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//
 	// Returns a list of the members in the system.
 	//
+	//   The list can be paged, filtered and sorted with query parameters.
+	//   The response includes the total number of members that match the filters.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMembersResponse
+	rr.HandleFunc("/member", api.rbac(api.getMembers, []UserRole{admin}))
+	// swagger:route POST /api/member/new member addNewMemberRequest
+	//
+	// Add a new member
+	//
+	// Add a member that doesn't exist in our system.
+	//  This would most likely be because they just signed up
+	//  and we don't have information from paypal yet.
+	//
+	// If the paypal email doesn't match, their access will be revoked
+	//   when we next sync with paypal.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/member/new", api.rbac(api.addNewMember, []UserRole{admin}))
+	// swagger:route GET /api/member/self member getCurrentMemberRequest
+	//
+	// Returns current members information
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberResponse
+	rr.HandleFunc("/member/self", api.getCurrentUserMemberInfo).Methods(http.MethodGet)
+	// swagger:route PUT /api/member/self member updateCurrentMemberRequest
+	//
+	// Updates the current members information
+	//
+	//   Members can only update their name.  Email changes
+	//   must go through an admin since the email needs to match the payment provider.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberResponse
+	rr.HandleFunc("/member/self", api.updateCurrentUserMemberInfo).Methods(http.MethodPut)
+	// swagger:route GET /api/member/self/payments member getCurrentMemberPayments
+	//
+	// Returns the current member's payments, most recent first
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberPaymentsResponse
+	rr.HandleFunc("/member/self/payments", api.getCurrentUserPayments).Methods(http.MethodGet)
+	// swagger:route GET /api/member/self/status member getCurrentMembershipStatus
+	//
+	// Returns the current member's membership status
+	//
+	//   Includes the member's tier, their last payment, any active credits
+	//   and how many days are left before their access is revoked for missing payments.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMembershipStatusResponse
+	rr.HandleFunc("/member/self/status", api.getCurrentUserStatus).Methods(http.MethodGet)
+	// swagger:route GET /api/member/self/access-requests member getCurrentMemberAccessRequests
+	//
+	// Returns the current member's resource access requests.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getAccessRequestsResponse
+	rr.HandleFunc("/member/self/access-requests", api.getCurrentUserAccessRequests).Methods(http.MethodGet)
+	// swagger:route POST /api/member/self/access-requests member addAccessRequestRequest
+	//
+	// Asks for the current member to be added to a resource.
+	//
+	//   Admins and the resource's trainers approve or deny the request.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: accessRequestResponse
+	rr.HandleFunc("/member/self/access-requests", api.addCurrentUserAccessRequest).Methods(http.MethodPost)
+	// swagger:route GET /api/member/email/{email} member getMemberByEmailRequest
+	//
+	// Returns a member based on the email address.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberResponse
+	rr.HandleFunc("/member/email/{email}", api.rbac(api.getMemberByEmail, []UserRole{admin}))
+	// swagger:route GET /api/member/slack/nonmembers member getSlackNonMemberList
+	//
+	// Returns a list slack users that are possibly not members.
+	//   It's entirely possible that these people are just using a different email than
+	//   what they signed up with.  So, these accounts should be verified manually.
+	//
+	//     Produces:
+	//     - test/csv
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: text/csv
+	rr.HandleFunc("/member/slack/nonmembers", api.rbac(api.getNonMembersOnSlack, []UserRole{admin}))
+	// swagger:route GET /api/member/tier member getTiers
+	//
+	// Returns a list the member tiers.
+	//
+	//   Tiers are in their sort order.  Inactive and Credited are protected
+	//   since the server manages them.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getTierResponse
+	rr.HandleFunc("/member/tier", api.rbac(api.getTiers, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/member/tier member addTierRequest
+	//
+	// Adds a member tier.
+	//
+	//   Payments are matched to the active tier with the same price and currency,
+	//   so active tiers can't share a price.  Prices are in minor units, i.e. cents.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: tierResponse
+	rr.HandleFunc("/member/tier", api.rbac(api.addTier, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route PUT /api/member/tier/{id} member updateTierRequest
+	//
+	// Updates a member tier.
+	//
+	//   Only the name and sort order of the Inactive and Credited tiers can change.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: tierResponse
+	rr.HandleFunc("/member/tier/{id}", api.rbac(api.updateTier, []UserRole{admin})).Methods(http.MethodPut)
+	// swagger:route DELETE /api/member/tier/{id} member deleteTierRequest
+	//
+	// Removes a member tier.
+	//
+	//   Tiers that members have been on can't be removed, deactivate them instead.
+	//   The Inactive and Credited tiers can't be removed.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/member/tier/{id}", api.rbac(api.deleteTier, []UserRole{admin})).Methods(http.MethodDelete)
+	// swagger:route GET /api/member/tier/{id}/resources member getTierResourcesRequest
+	//
+	// Returns the resources that members of a tier get automatically.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: tierResourcesResponse
+	rr.HandleFunc("/member/tier/{id}/resources", api.rbac(api.getTierResources, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route PUT /api/member/tier/{id}/resources member setTierResourcesRequest
+	//
+	// Sets the resources that members of a tier get automatically.
+	//
+	//   Members are granted the resources of their tier and lose them when they move to another tier.
+	//   Resources that were granted to a member manually are never removed because of a tier change.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: tierResourcesResponse
+	rr.HandleFunc("/member/tier/{id}/resources", api.rbac(api.setTierResources, []UserRole{admin})).Methods(http.MethodPut)
+	// swagger:route GET /api/member/credit member getMemberCredits
+	//
+	// Returns a list of the member credits.
+	//
+	//   Expired credits are included and marked as inactive.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberCreditsResponse
+	rr.HandleFunc("/member/credit", api.rbac(api.getMemberCredits, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/member/credit member addMemberCreditRequest
+	//
+	// Credits a membership to a member.
+	//
+	//   A credit is granted for a reason (scholarship, volunteer or board)
+	//   and can optionally expire.  Expired credits drop off when the member
+	//   status is next evaluated.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: memberCreditResponse
+	rr.HandleFunc("/member/credit", api.rbac(api.addMemberCredit, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route PUT /api/member/credit/{id} member updateMemberCreditRequest
+	//
+	// Updates the reason or expiry of a member credit.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: memberCreditResponse
+	rr.HandleFunc("/member/credit/{id}", api.rbac(api.updateMemberCredit, []UserRole{admin})).Methods(http.MethodPut)
+	// swagger:route DELETE /api/member/credit/{id} member removeMemberCreditRequest
+	//
+	// Removes a member credit.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/member/credit/{id}", api.rbac(api.removeMemberCredit, []UserRole{admin})).Methods(http.MethodDelete)
+	// swagger:route POST /api/member/import member importMembersRequest
+	//
+	// Imports members from a csv file.
+	//
+	//   The first line of the file is a header with the columns email, name, tier, rfid and resources.
+	//   Only email is required.  Resources are separated by a semicolon.
+	//   By default the import is a dry run that returns what would be added or updated.
+	//   Set confirm to true to apply it.  If any rows have problems, nothing is applied.
+	//
+	//     Consumes:
+	//     - multipart/form-data
+	//     - text/csv
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: memberImportResponse
+	//       409: memberImportResponse
+	rr.HandleFunc("/member/import", api.rbac(api.importMembers, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route GET /api/member/archived member getArchivedMembers
+	//
+	// Returns a list of the archived members.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getArchivedMembersResponse
+	rr.HandleFunc("/member/archived", api.rbac(api.getArchivedMembers, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route PUT /api/member/{id} member updateMemberRequest
+	//
+	// Updates a member's name, email or tier.
+	//
+	//   If the email changes, the member's login is updated as well.
+	//   Tier changes are recorded in the member's history as manual changes.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberResponse
+	rr.HandleFunc("/member/{id}", api.rbac(api.updateMember, []UserRole{admin})).Methods(http.MethodPut)
+	// swagger:route DELETE /api/member/{id} member archiveMemberRequest
+	//
+	// Archives a member.
+	//
+	//   Archived members are hidden from the member list and removed from
+	//   the resource access lists.  Their payment and communication history is kept
+	//   so that they can be restored.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/member/{id}", api.rbac(api.archiveMember, []UserRole{admin})).Methods(http.MethodDelete)
+	// swagger:route POST /api/member/{id}/restore member restoreMemberRequest
+	//
+	// Restores an archived member.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberResponse
+	rr.HandleFunc("/member/{id}/restore", api.rbac(api.restoreMember, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route GET /api/member/{id}/history member getMemberTierHistoryRequest
+	//
+	// Returns a member's tier history.
+	//
+	//   Every change of a member's tier is recorded with the previous tier,
+	//   the new tier, when it happened and why.
+	//   The cause is one of payment, credit, revoked or manual.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberTierHistoryResponse
+	rr.HandleFunc("/member/{id}/history", api.rbac(api.getMemberTierHistory, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route GET /api/member/{id}/ledger member getMemberLedgerRequest
+	//
+	// Returns how each of a member's payments extended their paid through date.
+	//
+	//   A payment pays for whole billing periods of its tier and pro-rates what is left over.
+	//   Payments that are made early start when the member's previous payments run out.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberLedgerResponse
+	rr.HandleFunc("/member/{id}/ledger", api.rbac(api.getMemberLedger, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/member/{id}/payments member addMemberPaymentRequest
+	//
+	// Records a payment that was made in person.
+	//
+	//   Cash and check payments are recorded by an admin,
+	//   payments made online are downloaded from the payment providers.
+	//   The payment counts toward the member's paid through date right away.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: paymentResponse
+	rr.HandleFunc("/member/{id}/payments", api.rbac(api.addMemberPayment, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route GET /api/member/{id}/credentials member getMemberCredentialsRequest
+	//
+	// Returns a member's rfid credentials.
+	//
+	//   Lost and revoked credentials are included.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberCredentialsResponse
+	rr.HandleFunc("/member/{id}/credentials", api.rbac(api.getMemberCredentials, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/member/{id}/credentials member addMemberCredentialRequest
+	//
+	// Issues a new rfid credential to a member.
+	//
+	//   The member's other credentials stay active.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: credentialResponse
+	rr.HandleFunc("/member/{id}/credentials", api.rbac(api.addMemberCredential, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route PUT /api/member/{id}/credentials/{credentialID} member updateMemberCredentialRequest
+	//
+	// Updates the label or status of a member's rfid credential.
+	//
+	//   Marking a credential lost or revoked removes it from the resources right away.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: credentialResponse
+	rr.HandleFunc("/member/{id}/credentials/{credentialID}", api.rbac(api.updateMemberCredential, []UserRole{admin})).Methods(http.MethodPut)
+	// swagger:route GET /api/member/{id}/certifications member getMemberCertificationsRequest
+	//
+	// Returns a member's certifications.
+	//
+	//   Expired and revoked certifications are included.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getMemberCertificationsResponse
+	rr.HandleFunc("/member/{id}/certifications", api.rbac(api.getMemberCertifications, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/member/{id}/certifications member addMemberCertificationRequest
+	//
+	// Records that a member was trained.
+	//
+	//   The member is pushed to the resources that required the certification.
+	//   Trainers can certify members for what the resources they train on require.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: memberCertificationResponse
+	rr.HandleFunc("/member/{id}/certifications", api.rbac(api.addMemberCertification, []UserRole{admin, trainer})).Methods(http.MethodPost)
+	// swagger:route DELETE /api/member/{id}/certifications/{certificationID} member revokeMemberCertificationRequest
+	//
+	// Revokes a member's certification.
+	//
+	//   The member is removed from the resources that require it right away.
+	//
 	//     Produces:
 	//     - application/json
 	//
@@ -63,18 +675,29 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     - bearerAuth:
 	//
 	//     Responses:
-	//       200: getMembersResponse
-	rr.HandleFunc("/member", api.rbac(api.getMembers, []UserRole{admin}))
-	// swagger:route POST /api/member/new member addNewMemberRequest
+	//       200: memberCertificationResponse
+	rr.HandleFunc("/member/{id}/certifications/{certificationID}", api.rbac(api.revokeMemberCertification, []UserRole{admin})).Methods(http.MethodDelete)
+	// swagger:route GET /api/certification certification getCertifications
 	//
-	// Add a new member
+	// Returns the trainings that resources can require.
 	//
-	// Add a member that doesn't exist in our system.
-	//  This would most likely be because they just signed up
-	//  and we don't have information from paypal yet.
+	//     Produces:
+	//     - application/json
 	//
-	// If the paypal email doesn't match, their access will be revoked
-	//   when we next sync with paypal.
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getCertificationsResponse
+	rr.HandleFunc("/certification", api.rbac(api.getCertifications, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/certification certification addCertificationRequest
+	//
+	// Adds a training that resources can require.
+	//
+	//     Consumes:
+	//     - application/json
 	//
 	//     Produces:
 	//     - application/json
@@ -85,11 +708,13 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     - bearerAuth:
 	//
 	//     Responses:
-	//       200: endpointSuccessResponse
-	rr.HandleFunc("/member/new", api.rbac(api.addNewMember, []UserRole{admin}))
-	// swagger:route GET /api/member/self member getCurrentMemberRequest
+	//       200: certificationResponse
+	rr.HandleFunc("/certification", api.rbac(api.addCertification, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route GET /api/guest guest getGuests
 	//
-	// Returns current members information
+	// Returns the guest passes.
+	//
+	//   Admins get every guest pass, members only get the guests they are hosting.
 	//
 	//     Produces:
 	//     - application/json
@@ -100,11 +725,18 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     - bearerAuth:
 	//
 	//     Responses:
-	//       200: getMemberResponse
-	rr.HandleFunc("/member/self", api.getCurrentUserMemberInfo)
-	// swagger:route GET /api/member/email/{email} member getMemberByEmailRequest
+	//       200: getGuestsResponse
+	rr.HandleFunc("/guest", api.getGuests).Methods(http.MethodGet)
+	// swagger:route POST /api/guest guest addGuestRequest
 	//
-	// Returns a member based on the email address.
+	// Issues a guest pass.
+	//
+	//   The guest's rfid is pushed to the resources when the pass starts
+	//   and removed when it ends.  Admins can issue a pass for any resource.
+	//   Active members can host a guest on the resources they have access to for up to a day.
+	//
+	//     Consumes:
+	//     - application/json
 	//
 	//     Produces:
 	//     - application/json
@@ -115,16 +747,16 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     - bearerAuth:
 	//
 	//     Responses:
-	//       200: getMemberResponse
-	rr.HandleFunc("/member/email/{email}", api.rbac(api.getMemberByEmail, []UserRole{admin}))
-	// swagger:route GET /api/member/slack/nonmembers member getSlackNonMemberList
+	//       200: guestResponse
+	rr.HandleFunc("/guest", api.addGuest).Methods(http.MethodPost)
+	// swagger:route DELETE /api/guest/{id} guest revokeGuestRequest
 	//
-	// Returns a list slack users that are possibly not members.
-	//   It's entirely possible that these people are just using a different email than
-	//   what they signed up with.  So, these accounts should be verified manually.
+	// Revokes a guest pass before it ends.
+	//
+	//   Members can revoke the guests they are hosting.
 	//
 	//     Produces:
-	//     - test/csv
+	//     - application/json
 	//
 	//     Schemes: http, https
 	//
@@ -132,11 +764,13 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     - bearerAuth:
 	//
 	//     Responses:
-	//       200: text/csv
-	rr.HandleFunc("/member/slack/nonmembers", api.rbac(api.getNonMembersOnSlack, []UserRole{admin}))
-	// swagger:route GET /api/member/tier member getTiers
+	//       200: guestResponse
+	rr.HandleFunc("/guest/{id}", api.revokeGuest).Methods(http.MethodDelete)
+	// swagger:route GET /api/access-requests accessRequest getAccessRequests
 	//
-	// Returns a list the member tiers.
+	// Returns the pending resource access requests.
+	//
+	//   Admins get every request, trainers only get the requests for the resources they train on.
 	//
 	//     Produces:
 	//     - application/json
@@ -147,14 +781,36 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     - bearerAuth:
 	//
 	//     Responses:
-	//       200: getTierResponse
-	rr.HandleFunc("/member/tier", api.rbac(api.getTiers, []UserRole{admin}))
+	//       200: getAccessRequestsResponse
+	rr.HandleFunc("/access-requests", api.rbac(api.getAccessRequests, []UserRole{admin, trainer})).Methods(http.MethodGet)
+	// swagger:route PUT /api/access-requests/{id} accessRequest decideAccessRequestRequest
+	//
+	// Approves or denies a resource access request.
+	//
+	//   Approving adds the member to the resource and pushes them to it.
+	//   The member is emailed the outcome.  Trainers can only decide
+	//   requests for the resources they train on.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: accessRequestResponse
+	rr.HandleFunc("/access-requests/{id}", api.rbac(api.decideAccessRequest, []UserRole{admin, trainer})).Methods(http.MethodPut)
 	// swagger:route POST /api/payments/refresh payments getRefreshPayments
 	//
 	// Refresh payment information
 	//
 	// Submits a request to update member status information
-	//   This will reach out to paypal and pull down the latest
+	//   This will reach out to the enabled payment providers and pull down the latest
 	//   transaction information and then evaluate each member's
 	//   membership status
 	//
@@ -172,6 +828,28 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     Responses:
 	//       200: getPaymentRefreshResponse
 	rr.HandleFunc("/payments/refresh", api.rbac(api.refreshPayments, []UserRole{admin}))
+	// swagger:route POST /api/payments/backfill payments backfillPaymentsRequest
+	//
+	// Download the payments of a range of time again
+	//
+	//   Reaches out to the enabled payment providers for the payments made between start and end,
+	//   i.e. to rebuild the payment history after an outage.  Payments that were already recorded are skipped
+	//   and the daily download still resumes where it last ended.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/payments/backfill", api.rbac(api.backfillPayments, []UserRole{admin})).Methods(http.MethodPost)
 	// swagger:route GET /api/payments/charts payments searchPaymentChartRequest
 	//
 	// Get Chart information of payments
@@ -262,6 +940,205 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     Responses:
 	//       200: getResourceStatusResponse
 	rr.HandleFunc("/resource/status", api.rbac(api.resource.status, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route GET /api/resource/acl resource getResourceACLRequest
+	//
+	// Returns the access list of a resource.
+	//
+	//   Every active rfid credential of the members with access is included,
+	//   encoded in the resource's rfid format.  Members whose schedule is closed
+	//   are left off unless the resource supports schedules.  Members missing
+	//   a certification the resource requires are left off.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getResourceACLResponse
+	rr.HandleFunc("/resource/acl", api.rbac(api.resource.getACL, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route GET /api/resource/schedules resource getSchedulesRequest
+	//
+	// Returns the access schedules.
+	//
+	//   Returns the schedules of every resource unless a resource is given.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getSchedulesResponse
+	rr.HandleFunc("/resource/schedules", api.rbac(api.resource.getSchedules, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/resource/schedules resource addScheduleRequest
+	//
+	// Limits access to a resource to a schedule.
+	//
+	//   A schedule without a tier applies to every member of the resource.
+	//   A schedule with a tier applies to the members of that tier instead.
+	//   Resources that support schedules get each member's schedule,
+	//   the others have their access list refreshed as the schedules open and close.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: scheduleResponse
+	rr.HandleFunc("/resource/schedules", api.rbac(api.resource.addSchedule, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route PUT /api/resource/schedules/{id} resource updateScheduleRequest
+	//
+	// Updates an access schedule.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: scheduleResponse
+	rr.HandleFunc("/resource/schedules/{id}", api.rbac(api.resource.updateSchedule, []UserRole{admin})).Methods(http.MethodPut)
+	// swagger:route DELETE /api/resource/schedules/{id} resource deleteScheduleRequest
+	//
+	// Deletes an access schedule.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/resource/schedules/{id}", api.rbac(api.resource.deleteSchedule, []UserRole{admin})).Methods(http.MethodDelete)
+	// swagger:route GET /api/resource/certifications resource getResourceCertificationsRequest
+	//
+	// Returns the certifications a resource requires.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getCertificationsResponse
+	rr.HandleFunc("/resource/certifications", api.rbac(api.resource.getCertifications, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route PUT /api/resource/certifications resource setResourceCertificationsRequest
+	//
+	// Sets the certifications a resource requires.
+	//
+	//   Members without every certification can't be added to the resource
+	//   and are left off of its access list.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getCertificationsResponse
+	rr.HandleFunc("/resource/certifications", api.rbac(api.resource.setCertifications, []UserRole{admin})).Methods(http.MethodPut)
+	// swagger:route GET /api/resource/trainers resource getTrainersRequest
+	//
+	// Returns the trainers of a resource.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getTrainersResponse
+	rr.HandleFunc("/resource/trainers", api.rbac(api.resource.getTrainers, []UserRole{admin})).Methods(http.MethodGet)
+	// swagger:route POST /api/resource/trainers resource trainerRequest
+	//
+	// Makes a member a trainer of a resource.
+	//
+	//   Trainers can add and remove members on the resource and certify members
+	//   for what it requires without being an admin.  The member has to log in
+	//   again to pick up the trainer role.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/resource/trainers", api.rbac(api.resource.addTrainer, []UserRole{admin})).Methods(http.MethodPost)
+	// swagger:route DELETE /api/resource/trainers resource trainerRequest
+	//
+	// Stops a member from training on a resource.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	rr.HandleFunc("/resource/trainers", api.rbac(api.resource.removeTrainer, []UserRole{admin})).Methods(http.MethodDelete)
+	// swagger:route GET /api/resource/log resource getResourceAccessLogRequest
+	//
+	// Returns who granted and removed access to a resource.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Security:
+	//     - bearerAuth:
+	//
+	//     Responses:
+	//       200: getResourceAccessLogResponse
+	rr.HandleFunc("/resource/log", api.rbac(api.resource.getAccessLog, []UserRole{admin})).Methods(http.MethodGet)
 	// swagger:route POST /api/resource/register resource registerResourceRequest
 	//
 	// Updates a resource.
@@ -284,6 +1161,10 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//
 	// Adds multple members to a resource.
 	//
+	//   Nobody is added if a member is missing a certification the resource requires.
+	//   Trainers can add members to the resources they train on.
+	//   Each grant is logged with who made it.
+	//
 	//     Consumes:
 	//     - application/json
 	//
@@ -297,7 +1178,7 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//
 	//     Responses:
 	//       200: addMulitpleMembersToResourceResponse
-	rr.HandleFunc("/resource/member/bulk", api.rbac(api.resource.addMultipleMembersToResource, []UserRole{admin})).Methods(http.MethodPost)
+	rr.HandleFunc("/resource/member/bulk", api.rbac(api.resource.addMultipleMembersToResource, []UserRole{admin, trainer})).Methods(http.MethodPost)
 	// swagger:route DELETE /api/resource/deleteacls resource resourceDeleteACLS
 	//
 	// Clears out all Resource ACLs on those devices
@@ -338,6 +1219,9 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//
 	// Removes a member from a resource.
 	//
+	//   Trainers can remove members from the resources they train on.
+	//   The removal is logged with who made it.
+	//
 	//     Consumes:
 	//     - application/json
 	//
@@ -351,7 +1235,7 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//
 	//     Responses:
 	//       200: removeMemberSuccessResponse
-	rr.HandleFunc("/resource/member", api.rbac(api.resource.removeMember, []UserRole{admin})).Methods(http.MethodDelete)
+	rr.HandleFunc("/resource/member", api.rbac(api.resource.removeMember, []UserRole{admin, trainer})).Methods(http.MethodDelete)
 	// swagger:route GET /api/info info info
 	//
 	// A simple hello world.
@@ -482,5 +1366,46 @@ func registerRoutes(r *mux.Router, api API) *mux.Router {
 	//     Responses:
 	//       200: endpointSuccessResponse
 	r.HandleFunc("/api/auth/register", api.signup)
+	// swagger:route POST /api/payments/stripe/webhook payments stripeWebhookRequest
+	//
+	// Receives events from stripe.
+	//
+	//   Stripe signs each event with the webhook secret in the Stripe-Signature header,
+	//   events that aren't signed are refused.
+	//   Paid invoices are recorded as payments and the members are updated right away,
+	//   cancelled subscriptions are flagged on the member.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	r.HandleFunc("/api/payments/stripe/webhook", api.stripeWebhook).Methods(http.MethodPost)
+	// swagger:route POST /api/payments/paypal/webhook payments paypalWebhookRequest
+	//
+	// Receives events from PayPal.
+	//
+	//   PayPal signs each event with the certificate linked in the PAYPAL-CERT-URL header,
+	//   events that aren't signed for our webhook are refused.
+	//   Events are only applied once, however often PayPal sends them.
+	//   Completed sales are recorded as payments and the members are updated right away,
+	//   cancelled subscriptions are flagged on the member.
+	//
+	//     Consumes:
+	//     - application/json
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Schemes: http, https
+	//
+	//     Responses:
+	//       200: endpointSuccessResponse
+	r.HandleFunc("/api/payments/paypal/webhook", api.paypalWebhook).Methods(http.MethodPost)
 	return rr
 }
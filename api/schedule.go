@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"memberserver/api/models"
+	"memberserver/database"
+	"memberserver/resourcemanager"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// toSchedule validates the tier of a schedule request
+func toSchedule(tiers database.Tiers, scheduleRequest models.ScheduleRequest) (database.Schedule, error) {
+	s := database.Schedule{
+		ResourceID: scheduleRequest.ResourceID,
+		Name:       scheduleRequest.Name,
+		ScheduleWindow: database.ScheduleWindow{
+			Days:  scheduleRequest.Days,
+			Start: scheduleRequest.Start,
+			End:   scheduleRequest.End,
+		},
+	}
+
+	if scheduleRequest.Level != nil {
+		level := database.MemberLevel(*scheduleRequest.Level)
+		if _, ok := tiers.ByID(level); !ok {
+			return s, fmt.Errorf("unknown tier: %d", *scheduleRequest.Level)
+		}
+		s.Level = &level
+	}
+
+	return s, s.Validate()
+}
+
+// pushSchedules sends the resource of a schedule that changed
+func (rs resourceAPI) pushSchedules(resourceID string) {
+	r, err := rs.db.GetResourceByID(resourceID)
+	if err != nil {
+		log.Errorf("error getting resource to push schedules: %s", err)
+		return
+	}
+
+	resourcemanager.UpdateResourceSchedules(r)
+}
+
+func (rs resourceAPI) getSchedules(w http.ResponseWriter, req *http.Request) {
+	var schedules database.Schedules
+	var err error
+
+	resourceID := req.URL.Query().Get("resource")
+	if resourceID == "" {
+		schedules, err = rs.db.GetSchedules()
+	} else {
+		schedules, err = rs.db.GetResourceSchedules(resourceID)
+	}
+
+	if err != nil {
+		log.Errorf("error getting schedules: %s", err)
+		http.Error(w, errors.New("error getting schedules").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(schedules)
+	w.Write(j)
+}
+
+func (rs resourceAPI) addSchedule(w http.ResponseWriter, req *http.Request) {
+	var scheduleRequest models.ScheduleRequest
+
+	err := json.NewDecoder(req.Body).Decode(&scheduleRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := toSchedule(rs.db.GetMemberTiers(), scheduleRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = rs.db.GetResourceByID(s.ResourceID)
+	if err != nil {
+		http.Error(w, errors.New("resource not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	s, err = rs.db.AddSchedule(s)
+	if err != nil {
+		log.Errorf("error adding schedule: %s", err)
+		http.Error(w, errors.New("error adding schedule").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(s)
+	w.Write(j)
+
+	go rs.pushSchedules(s.ResourceID)
+}
+
+func (rs resourceAPI) updateSchedule(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	id, err := strconv.ParseInt(routeVars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid schedule id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	var scheduleRequest models.ScheduleRequest
+
+	err = json.NewDecoder(req.Body).Decode(&scheduleRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := toSchedule(rs.db.GetMemberTiers(), scheduleRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = rs.db.GetScheduleByID(id)
+	if err != nil {
+		http.Error(w, errors.New("schedule not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	s.ID = id
+
+	s, err = rs.db.UpdateSchedule(s)
+	if err != nil {
+		log.Errorf("error updating schedule: %s", err)
+		http.Error(w, errors.New("error updating schedule").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(s)
+	w.Write(j)
+
+	go rs.pushSchedules(s.ResourceID)
+}
+
+func (rs resourceAPI) deleteSchedule(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	id, err := strconv.ParseInt(routeVars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, errors.New("invalid schedule id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := rs.db.DeleteSchedule(id)
+	if err != nil {
+		http.Error(w, errors.New("schedule not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+
+	go rs.pushSchedules(s.ResourceID)
+}
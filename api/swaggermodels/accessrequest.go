@@ -0,0 +1,32 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:response getAccessRequestsResponse
+type getAccessRequestsResponse struct {
+	// in: body
+	Body []database.AccessRequest
+}
+
+// swagger:response accessRequestResponse
+type accessRequestResponse struct {
+	// in: body
+	Body database.AccessRequest
+}
+
+// swagger:parameters addAccessRequestRequest
+type addAccessRequestRequest struct {
+	// in: body
+	Body models.AccessRequestRequest
+}
+
+// swagger:parameters decideAccessRequestRequest
+type decideAccessRequestRequest struct {
+	// in:path
+	ID int64 `json:"id"`
+	// in: body
+	Body models.DecideAccessRequestRequest
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:response getCertificationsResponse
+type getCertificationsResponse struct {
+	// in: body
+	Body []database.Certification
+}
+
+// swagger:response certificationResponse
+type certificationResponse struct {
+	// in: body
+	Body database.Certification
+}
+
+// swagger:parameters addCertificationRequest
+type addCertificationRequest struct {
+	// in: body
+	Body models.CertificationRequest
+}
+
+// swagger:parameters getResourceCertificationsRequest
+type getResourceCertificationsRequest struct {
+	// The resource id
+	// in:query
+	Resource string `json:"resource"`
+}
+
+// swagger:parameters setResourceCertificationsRequest
+type setResourceCertificationsRequest struct {
+	// in: body
+	Body models.ResourceCertificationsRequest
+}
+
+// swagger:parameters getMemberCertificationsRequest
+type getMemberCertificationsRequest struct {
+	// in:path
+	ID string `json:"id"`
+}
+
+// swagger:response getMemberCertificationsResponse
+type getMemberCertificationsResponse struct {
+	// in: body
+	Body []database.MemberCertification
+}
+
+// swagger:response memberCertificationResponse
+type memberCertificationResponse struct {
+	// in: body
+	Body database.MemberCertification
+}
+
+// swagger:parameters addMemberCertificationRequest
+type addMemberCertificationRequest struct {
+	// in:path
+	ID string `json:"id"`
+	// in: body
+	Body models.MemberCertificationRequest
+}
+
+// swagger:parameters revokeMemberCertificationRequest
+type revokeMemberCertificationRequest struct {
+	// in:path
+	ID string `json:"id"`
+	// in:path
+	CertificationID int64 `json:"certificationID"`
+}
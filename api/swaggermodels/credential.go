@@ -0,0 +1,42 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:parameters getMemberCredentialsRequest
+type getMemberCredentialsRequest struct {
+	// in:path
+	ID string `json:"id"`
+}
+
+// swagger:response getMemberCredentialsResponse
+type getMemberCredentialsResponse struct {
+	// in: body
+	Body []database.Credential
+}
+
+// swagger:response credentialResponse
+type credentialResponse struct {
+	// in: body
+	Body database.Credential
+}
+
+// swagger:parameters addMemberCredentialRequest
+type addMemberCredentialRequest struct {
+	// in:path
+	ID string `json:"id"`
+	// in: body
+	Body models.AddCredentialRequest
+}
+
+// swagger:parameters updateMemberCredentialRequest
+type updateMemberCredentialRequest struct {
+	// in:path
+	ID string `json:"id"`
+	// in:path
+	CredentialID int64 `json:"credentialID"`
+	// in: body
+	Body models.UpdateCredentialRequest
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:response getMemberCreditsResponse
+type getMemberCreditsResponse struct {
+	// in: body
+	Body []database.MemberCredit
+}
+
+// swagger:response memberCreditResponse
+type memberCreditResponse struct {
+	// in: body
+	Body database.MemberCredit
+}
+
+// swagger:parameters addMemberCreditRequest
+type addMemberCreditRequest struct {
+	// in: body
+	Body models.MemberCreditRequest
+}
+
+// swagger:parameters updateMemberCreditRequest
+type updateMemberCreditRequest struct {
+	// in:path
+	ID int64 `json:"id"`
+	// in: body
+	Body models.MemberCreditRequest
+}
+
+// swagger:parameters removeMemberCreditRequest
+type removeMemberCreditRequest struct {
+	// in:path
+	ID int64 `json:"id"`
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:response getGuestsResponse
+type getGuestsResponse struct {
+	// in: body
+	Body []database.Guest
+}
+
+// swagger:response guestResponse
+type guestResponse struct {
+	// in: body
+	Body database.Guest
+}
+
+// swagger:parameters addGuestRequest
+type addGuestRequest struct {
+	// in: body
+	Body models.GuestRequest
+}
+
+// swagger:parameters revokeGuestRequest
+type revokeGuestRequest struct {
+	// in:path
+	ID int64 `json:"id"`
+}
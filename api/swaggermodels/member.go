@@ -17,10 +17,41 @@ type getCurrentMemberRequest struct {
 	Email string `json:"email"`
 }
 
+// swagger:parameters getMemberListRequest
+type getMemberListRequest struct {
+	// Page number, starting at 1
+	// in:query
+	Page int `json:"page"`
+	// Number of members per page.  Leave empty to return every member
+	// in:query
+	Limit int `json:"limit"`
+	// Only include members of this tier
+	// in:query
+	MemberLevel uint8 `json:"memberLevel"`
+	// Only include members with access to this resource.  Accepts the resource id or name
+	// in:query
+	Resource string `json:"resource"`
+	// Only include members with (true) or without (false) an rfid
+	// in:query
+	HasRFID bool `json:"hasRFID"`
+	// Only include active members that are past due
+	// in:query
+	PastDue bool `json:"pastDue"`
+	// Search the member's name and email
+	// in:query
+	Search string `json:"search"`
+	// Sort by name, email or memberLevel
+	// in:query
+	Sort string `json:"sort"`
+	// Sort order, asc or desc
+	// in:query
+	Order string `json:"order"`
+}
+
 // swagger:response getMembersResponse
 type getMembersResponse struct {
 	// in: body
-	Body []database.Member
+	Body database.MemberList
 }
 
 // swagger:response getMemberResponse
@@ -63,3 +94,52 @@ type addNewMemberRequest struct {
 	// in: body
 	Body models.NewMember
 }
+
+// swagger:parameters updateMemberRequest
+type updateMemberRequest struct {
+	// in:path
+	ID string `json:"id"`
+	// in: body
+	Body models.UpdateMemberRequest
+}
+
+// swagger:parameters updateCurrentMemberRequest
+type updateCurrentMemberRequest struct {
+	// in: body
+	Body models.UpdateMemberSelfRequest
+}
+
+// swagger:parameters archiveMemberRequest restoreMemberRequest getMemberTierHistoryRequest getMemberLedgerRequest
+type memberIDRequest struct {
+	// in:path
+	ID string `json:"id"`
+}
+
+// swagger:response getArchivedMembersResponse
+type getArchivedMembersResponse struct {
+	// in: body
+	Body []database.ArchivedMember
+}
+
+// swagger:response getMemberTierHistoryResponse
+type getMemberTierHistoryResponse struct {
+	// in: body
+	Body []database.TierChange
+}
+
+// swagger:parameters importMembersRequest
+type importMembersRequest struct {
+	// Apply the import.  Otherwise only the diff is returned
+	// in:query
+	Confirm bool `json:"confirm"`
+	// The csv file
+	// in:formData
+	// swagger:file
+	File interface{} `json:"file"`
+}
+
+// swagger:response memberImportResponse
+type memberImportResponse struct {
+	// in: body
+	Body database.MemberImportResult
+}
@@ -1,6 +1,9 @@
 package api
 
-import "memberserver/api/models"
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
 
 // PaymentResponse response of payment chart information
 // swagger:response getPaymentChartResponse
@@ -14,3 +17,65 @@ type searchPaymentChartRequest struct {
 	// in:query
 	Type string `json:"type"`
 }
+
+// swagger:response getMemberPaymentsResponse
+type getMemberPaymentsResponse struct {
+	// in: body
+	Body []database.Payment
+}
+
+// swagger:response getMembershipStatusResponse
+type getMembershipStatusResponse struct {
+	// in: body
+	Body database.MembershipStatus
+}
+
+// swagger:response getMemberLedgerResponse
+type getMemberLedgerResponse struct {
+	// in: body
+	Body []database.LedgerEntry
+}
+
+// swagger:parameters addMemberPaymentRequest
+type addMemberPaymentRequest struct {
+	// in:path
+	ID string `json:"id"`
+	// in: body
+	Body models.PaymentRequest
+}
+
+// swagger:parameters backfillPaymentsRequest
+type backfillPaymentsRequest struct {
+	// in: body
+	Body models.BackfillRequest
+}
+
+// swagger:response paymentResponse
+type paymentResponse struct {
+	// in: body
+	Body database.Payment
+}
+
+// swagger:parameters stripeWebhookRequest
+type stripeWebhookRequest struct {
+	// in:header
+	StripeSignature string `json:"Stripe-Signature"`
+	// in: body
+	Body interface{}
+}
+
+// swagger:parameters paypalWebhookRequest
+type paypalWebhookRequest struct {
+	// in:header
+	TransmissionID string `json:"PAYPAL-TRANSMISSION-ID"`
+	// in:header
+	TransmissionTime string `json:"PAYPAL-TRANSMISSION-TIME"`
+	// in:header
+	TransmissionSig string `json:"PAYPAL-TRANSMISSION-SIG"`
+	// in:header
+	CertURL string `json:"PAYPAL-CERT-URL"`
+	// in:header
+	AuthAlgo string `json:"PAYPAL-AUTH-ALGO"`
+	// in: body
+	Body interface{}
+}
@@ -36,6 +36,19 @@ type resourceBulkMemberRequest struct {
 	Body models.MembersResourceRelation
 }
 
+// swagger:parameters getResourceACLRequest
+type getResourceACLRequest struct {
+	// The resource id or name
+	// in:query
+	Resource string `json:"resource"`
+}
+
+// swagger:response getResourceACLResponse
+type getResourceACLResponse struct {
+	// in: body
+	Body []string
+}
+
 // swagger:response getResourceResponse
 type getResourceResponse struct {
 	// in: body
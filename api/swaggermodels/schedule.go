@@ -0,0 +1,45 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:parameters getSchedulesRequest
+type getSchedulesRequest struct {
+	// The resource id
+	// in:query
+	Resource string `json:"resource"`
+}
+
+// swagger:response getSchedulesResponse
+type getSchedulesResponse struct {
+	// in: body
+	Body []database.Schedule
+}
+
+// swagger:response scheduleResponse
+type scheduleResponse struct {
+	// in: body
+	Body database.Schedule
+}
+
+// swagger:parameters addScheduleRequest
+type addScheduleRequest struct {
+	// in: body
+	Body models.ScheduleRequest
+}
+
+// swagger:parameters updateScheduleRequest
+type updateScheduleRequest struct {
+	// in:path
+	ID int64 `json:"id"`
+	// in: body
+	Body models.ScheduleRequest
+}
+
+// swagger:parameters deleteScheduleRequest
+type deleteScheduleRequest struct {
+	// in:path
+	ID int64 `json:"id"`
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:response tierResponse
+type tierResponse struct {
+	// in: body
+	Body database.Tier
+}
+
+// swagger:parameters addTierRequest
+type addTierRequest struct {
+	// in: body
+	Body models.TierRequest
+}
+
+// swagger:parameters updateTierRequest
+type updateTierRequest struct {
+	// in:path
+	ID uint8 `json:"id"`
+	// in: body
+	Body models.TierRequest
+}
+
+// swagger:parameters deleteTierRequest
+type deleteTierRequest struct {
+	// in:path
+	ID uint8 `json:"id"`
+}
+
+// swagger:parameters getTierResourcesRequest
+type getTierResourcesRequest struct {
+	// in:path
+	ID uint8 `json:"id"`
+}
+
+// swagger:parameters setTierResourcesRequest
+type setTierResourcesRequest struct {
+	// in:path
+	ID uint8 `json:"id"`
+	// in: body
+	Body models.TierResourcesRequest
+}
+
+// swagger:response tierResourcesResponse
+type tierResourcesResponse struct {
+	// in: body
+	Body []database.MemberResource
+}
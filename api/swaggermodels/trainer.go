@@ -0,0 +1,38 @@
+package api
+
+import (
+	"memberserver/api/models"
+	"memberserver/database"
+)
+
+// swagger:parameters getTrainersRequest
+type getTrainersRequest struct {
+	// The resource id
+	// in:query
+	Resource string `json:"resource"`
+}
+
+// swagger:response getTrainersResponse
+type getTrainersResponse struct {
+	// in: body
+	Body []database.Trainer
+}
+
+// swagger:parameters trainerRequest
+type trainerRequest struct {
+	// in: body
+	Body models.MemberResourceRelation
+}
+
+// swagger:parameters getResourceAccessLogRequest
+type getResourceAccessLogRequest struct {
+	// The resource id
+	// in:query
+	Resource string `json:"resource"`
+}
+
+// swagger:response getResourceAccessLogResponse
+type getResourceAccessLogResponse struct {
+	// in: body
+	Body []database.ResourceAccessChange
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"memberserver/api/models"
+	"memberserver/database"
+	"memberserver/resourcemanager"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+func toTier(tierRequest models.TierRequest) database.Tier {
+	return database.Tier{
+		Name:          tierRequest.Name,
+		Price:         tierRequest.Price,
+		Currency:      tierRequest.Currency,
+		BillingPeriod: database.BillingPeriod(tierRequest.BillingPeriod),
+		Active:        tierRequest.Active,
+		SortOrder:     tierRequest.SortOrder,
+	}
+}
+
+func (a API) addTier(w http.ResponseWriter, req *http.Request) {
+	var tierRequest models.TierRequest
+
+	err := json.NewDecoder(req.Body).Decode(&tierRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tier, err := a.db.AddTier(toTier(tierRequest))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// members that have been paying the new price move to the tier
+	a.db.UpdateMemberTiers()
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(tier)
+	w.Write(j)
+
+	go resourcemanager.ApplyTierEntitlements()
+}
+
+func (a API) updateTier(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	id, err := strconv.ParseUint(routeVars["id"], 10, 8)
+	if err != nil {
+		http.Error(w, errors.New("invalid tier id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	var tierRequest models.TierRequest
+
+	err = json.NewDecoder(req.Body).Decode(&tierRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tier := toTier(tierRequest)
+	tier.ID = uint8(id)
+
+	tier, err = a.db.UpdateTier(tier)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// members on a deactivated tier keep it until their payments match another tier
+	a.db.UpdateMemberTiers()
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(tier)
+	w.Write(j)
+
+	go resourcemanager.ApplyTierEntitlements()
+}
+
+func (a API) deleteTier(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	id, err := strconv.ParseUint(routeVars["id"], 10, 8)
+	if err != nil {
+		http.Error(w, errors.New("invalid tier id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = a.db.DeleteTier(database.MemberLevel(id))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+}
+
+func (a API) getTierResources(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	id, err := strconv.ParseUint(routeVars["id"], 10, 8)
+	if err != nil {
+		http.Error(w, errors.New("invalid tier id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	resources, err := a.db.GetTierResources(database.MemberLevel(id))
+	if err != nil {
+		log.Errorf("error getting tier resources: %s", err)
+		http.Error(w, errors.New("error getting tier resources").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(resources)
+	w.Write(j)
+}
+
+func (a API) setTierResources(w http.ResponseWriter, req *http.Request) {
+	routeVars := mux.Vars(req)
+
+	id, err := strconv.ParseUint(routeVars["id"], 10, 8)
+	if err != nil {
+		http.Error(w, errors.New("invalid tier id").Error(), http.StatusBadRequest)
+		return
+	}
+
+	var tierResourcesRequest models.TierResourcesRequest
+
+	err = json.NewDecoder(req.Body).Decode(&tierResourcesRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tier, err := a.db.GetTierByID(database.MemberLevel(id))
+	if err != nil {
+		http.Error(w, errors.New("tier not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	resources, err := a.db.SetTierResources(database.MemberLevel(tier.ID), tierResourcesRequest.ResourceIDs)
+	if err != nil {
+		log.Errorf("error setting tier resources: %s", err)
+		http.Error(w, errors.New("error setting tier resources").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(resources)
+	w.Write(j)
+
+	go resourcemanager.ApplyTierEntitlements()
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"memberserver/api/models"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func (rs resourceAPI) getTrainers(w http.ResponseWriter, req *http.Request) {
+	trainers, err := rs.db.GetResourceTrainers(req.URL.Query().Get("resource"))
+	if err != nil {
+		log.Errorf("error getting trainers: %s", err)
+		http.Error(w, errors.New("error getting trainers").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(trainers)
+	w.Write(j)
+}
+
+func (rs resourceAPI) addTrainer(w http.ResponseWriter, req *http.Request) {
+	var trainerRequest models.MemberResourceRelation
+
+	err := json.NewDecoder(req.Body).Decode(&trainerRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = rs.db.GetResourceByID(trainerRequest.ID)
+	if err != nil {
+		http.Error(w, errors.New("resource not found").Error(), http.StatusNotFound)
+		return
+	}
+
+	_, user, _ := strategy.AuthenticateRequest(req)
+
+	err = rs.db.AddResourceTrainer(trainerRequest.ID, trainerRequest.Email, user.GetUserName())
+	if err != nil {
+		log.Errorf("error adding trainer: %s", err)
+		http.Error(w, errors.New("error adding trainer").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+}
+
+func (rs resourceAPI) removeTrainer(w http.ResponseWriter, req *http.Request) {
+	var trainerRequest models.MemberResourceRelation
+
+	err := json.NewDecoder(req.Body).Decode(&trainerRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = rs.db.RemoveResourceTrainer(trainerRequest.ID, trainerRequest.Email)
+	if err != nil {
+		log.Errorf("error removing trainer: %s", err)
+		http.Error(w, errors.New("error removing trainer").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(models.EndpointSuccess{
+		Ack: true,
+	})
+	w.Write(j)
+}
+
+func (rs resourceAPI) getAccessLog(w http.ResponseWriter, req *http.Request) {
+	changes, err := rs.db.GetResourceAccessLog(req.URL.Query().Get("resource"))
+	if err != nil {
+		log.Errorf("error getting resource access log: %s", err)
+		http.Error(w, errors.New("error getting resource access log").Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(changes)
+	w.Write(j)
+}
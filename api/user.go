@@ -134,6 +134,10 @@ func getValidator(db *database.Database) basic.AuthenticateFunc {
 			resources = append(resources, resource.Name)
 		}
 
+		if db.IsTrainer(userName) {
+			resources = append(resources, trainer.ToString())
+		}
+
 		conf, _ := config.Load()
 		if strings.Contains(conf.AlwaysAdmin, "true") {
 			resources = append(resources, "admin")
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -15,6 +16,8 @@ type Config struct {
 	PaypalClientID     string `json:"paypalClientID"`
 	PaypalClientSecret string `json:"paypalClientSecret"`
 	PaypalURL          string `json:"paypalURL"`
+	// PaypalWebhookID - id of our webhook in PayPal, used to verify the events PayPal sends
+	PaypalWebhookID    string `json:"paypalWebhookID"`
 	MailgunURL         string `json:"mailgunURL"`
 	MailgunKey         string `json:"mailgunKey"`
 	MailgunFromAddress string `json:"mailgunFromAddress"`
@@ -35,6 +38,27 @@ type Config struct {
 	SlackToken           string `json:"slackToken"`
 	AdminEmail           string `json:"adminEmail"`
 	AlwaysAdmin          string `json:"alwaysAdmin"`
+	// TimeZone - the time zone that access schedules are written in, i.e. America/New_York
+	TimeZone string `json:"timeZone"`
+	// PaymentTolerance - how many cents a payment can be off from a tier's price and still match it,
+	//   i.e. when a payment was rounded or had a fee taken out
+	PaymentTolerance int64 `json:"paymentTolerance"`
+	// PaymentProviders - comma separated list of the providers payments are downloaded from, i.e. paypal
+	PaymentProviders string `json:"paymentProviders"`
+	StripeURL        string `json:"stripeURL"`
+	// StripeSecretKey - api key used to download invoices and look up customers
+	StripeSecretKey string `json:"stripeSecretKey"`
+	// StripeWebhookSecret - signing secret of the webhook endpoint, used to verify the events stripe sends
+	StripeWebhookSecret string `json:"stripeWebhookSecret"`
+	QuickBooksURL       string `json:"quickBooksURL"`
+	QuickBooksTokenURL  string `json:"quickBooksTokenURL"`
+	// QuickBooksRealmID - the id of our company in QuickBooks Online
+	QuickBooksRealmID      string `json:"quickBooksRealmID"`
+	QuickBooksClientID     string `json:"quickBooksClientID"`
+	QuickBooksClientSecret string `json:"quickBooksClientSecret"`
+	// QuickBooksRefreshToken - the refresh token to connect with the first time.
+	//   QuickBooks gives us a new one each time we connect, those are kept in the database
+	QuickBooksRefreshToken string `json:"quickBooksRefreshToken"`
 }
 
 // Load in the config file to memory
@@ -47,6 +71,7 @@ func Load() (Config, error) {
 	c.PaypalClientID = os.Getenv("PAYPAL_CLIENT_ID")
 	c.PaypalClientSecret = os.Getenv("PAYPAL_CLIENT_SECRET")
 	c.PaypalURL = os.Getenv("PAYPAL_API_URL")
+	c.PaypalWebhookID = os.Getenv("PAYPAL_WEBHOOK_ID")
 	c.MailgunURL = os.Getenv("MAILGUN_API_URL")
 	c.MailgunKey = os.Getenv("MAILGUN_KEY")
 	c.MailgunFromAddress = os.Getenv("MAILGUN_FROM_ADDRESS")
@@ -62,6 +87,23 @@ func Load() (Config, error) {
 	c.SlackToken = os.Getenv("SLACK_TOKEN")
 	c.AdminEmail = getEnvOrDefault("ADMIN_EMAIL", "info@hackrva.org")
 	c.AlwaysAdmin = getEnvOrDefault("ALWAYS_ADMIN", "false")
+	c.TimeZone = getEnvOrDefault("TIME_ZONE", "America/New_York")
+
+	tolerance, err := strconv.ParseInt(getEnvOrDefault("PAYMENT_TOLERANCE", "50"), 10, 64)
+	if err != nil {
+		log.Errorf("PAYMENT_TOLERANCE should be a number of cents: %s", err)
+	}
+	c.PaymentTolerance = tolerance
+	c.PaymentProviders = getEnvOrDefault("PAYMENT_PROVIDERS", "paypal")
+	c.StripeURL = getEnvOrDefault("STRIPE_API_URL", "https://api.stripe.com")
+	c.StripeSecretKey = os.Getenv("STRIPE_SECRET_KEY")
+	c.StripeWebhookSecret = os.Getenv("STRIPE_WEBHOOK_SECRET")
+	c.QuickBooksURL = getEnvOrDefault("QUICKBOOKS_API_URL", "https://quickbooks.api.intuit.com")
+	c.QuickBooksTokenURL = getEnvOrDefault("QUICKBOOKS_TOKEN_URL", "https://oauth.platform.intuit.com/oauth2/v1/tokens/bearer")
+	c.QuickBooksRealmID = os.Getenv("QUICKBOOKS_REALM_ID")
+	c.QuickBooksClientID = os.Getenv("QUICKBOOKS_CLIENT_ID")
+	c.QuickBooksClientSecret = os.Getenv("QUICKBOOKS_CLIENT_SECRET")
+	c.QuickBooksRefreshToken = os.Getenv("QUICKBOOKS_REFRESH_TOKEN")
 
 	if len(os.Getenv("ENABLE_INFO_EMAILS")) > 0 {
 		c.EnableInfoEmails = true
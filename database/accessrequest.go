@@ -0,0 +1,177 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+var accessRequestDbMethod AccessRequestDatabaseMethod
+
+// AccessRequestStatus - where an access request is in the approval workflow
+type AccessRequestStatus string
+
+const (
+	// AccessRequestPending - the request is waiting on an admin or a trainer of the resource
+	AccessRequestPending AccessRequestStatus = "pending"
+	// AccessRequestApproved - the member was added to the resource
+	AccessRequestApproved AccessRequestStatus = "approved"
+	// AccessRequestDenied - the member was not added to the resource
+	AccessRequestDenied AccessRequestStatus = "denied"
+)
+
+// uniqueViolation - the postgres error code for a unique index conflict
+const uniqueViolation = "23505"
+
+// ErrAccessRequestDecided - the access request was already approved or denied
+var ErrAccessRequestDecided = errors.New("the access request was already decided")
+
+// AccessRequest - a member asking to be added to a resource
+type AccessRequest struct {
+	ID           int64  `json:"id"`
+	MemberID     string `json:"memberID"`
+	MemberName   string `json:"memberName"`
+	Email        string `json:"email"`
+	ResourceID   string `json:"resourceID"`
+	ResourceName string `json:"resourceName"`
+	// Note - why the member wants access, i.e. when they were trained
+	Note        string              `json:"note"`
+	Status      AccessRequestStatus `json:"status"`
+	RequestedAt time.Time           `json:"requestedAt"`
+	DecidedBy   *string             `json:"decidedBy"`
+	DecidedAt   *time.Time          `json:"decidedAt"`
+	// DecisionNote - why the request was approved or denied
+	DecisionNote string `json:"decisionNote"`
+}
+
+func scanAccessRequest(row pgx.Row) (AccessRequest, error) {
+	var ar AccessRequest
+	var status string
+
+	err := row.Scan(&ar.ID, &ar.MemberID, &ar.MemberName, &ar.Email, &ar.ResourceID, &ar.ResourceName, &ar.Note, &status,
+		&ar.RequestedAt, &ar.DecidedBy, &ar.DecidedAt, &ar.DecisionNote)
+	if err != nil {
+		return ar, err
+	}
+
+	ar.Status = AccessRequestStatus(status)
+
+	return ar, nil
+}
+
+func (db *Database) getAccessRequests(query string, args ...interface{}) ([]AccessRequest, error) {
+	var requests []AccessRequest
+
+	rows, err := db.getConn().Query(db.ctx, query, args...)
+	if err != nil {
+		return requests, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		ar, err := scanAccessRequest(rows)
+		if err != nil {
+			return requests, fmt.Errorf("error reading access request: %v", err)
+		}
+
+		requests = append(requests, ar)
+	}
+
+	return requests, nil
+}
+
+// GetMemberAccessRequests - gets every access request a member made
+func (db *Database) GetMemberAccessRequests(memberID string) ([]AccessRequest, error) {
+	return db.getAccessRequests(accessRequestDbMethod.getMemberAccessRequests(), memberID)
+}
+
+// GetPendingAccessRequests - gets the access requests that are waiting on a decision
+func (db *Database) GetPendingAccessRequests() ([]AccessRequest, error) {
+	return db.getAccessRequests(accessRequestDbMethod.getPendingAccessRequests())
+}
+
+// GetPendingAccessRequestsForTrainer - gets the access requests that are waiting on a decision
+//   for the resources the member trains on
+func (db *Database) GetPendingAccessRequestsForTrainer(email string) ([]AccessRequest, error) {
+	return db.getAccessRequests(accessRequestDbMethod.getPendingAccessRequestsForTrainer(), email)
+}
+
+// GetAccessRequestByID - lookup an access request by its id
+func (db *Database) GetAccessRequestByID(id int64) (AccessRequest, error) {
+	ar, err := scanAccessRequest(db.getConn().QueryRow(db.ctx, accessRequestDbMethod.getAccessRequestByID(), id))
+	if err != nil {
+		return ar, fmt.Errorf("error getting access request: %v", err)
+	}
+
+	return ar, nil
+}
+
+// AddAccessRequest - asks for a member to be added to a resource
+//   a member can only have one pending request for a resource
+func (db *Database) AddAccessRequest(m Member, resourceID string, note string) (AccessRequest, error) {
+	for _, r := range m.Resources {
+		if r.ResourceID == resourceID {
+			return AccessRequest{}, fmt.Errorf("%s already has access to %s", m.Email, r.Name)
+		}
+	}
+
+	var id int64
+	err := db.getConn().QueryRow(db.ctx, accessRequestDbMethod.insertAccessRequest(), m.ID, resourceID, note).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return AccessRequest{}, errors.New("there is already a pending request for this resource")
+		}
+		return AccessRequest{}, fmt.Errorf("error adding access request: %v", err)
+	}
+
+	return db.GetAccessRequestByID(id)
+}
+
+// DecideAccessRequest - approves or denies a pending access request.
+//   Approving the request grants access the same way AddMultipleMembersToResource does,
+//   so the request can't be approved if the member is missing a certification.
+func (db *Database) DecideAccessRequest(id int64, approve bool, decidedBy string, note string) (AccessRequest, error) {
+	ar, err := db.GetAccessRequestByID(id)
+	if err != nil {
+		return ar, err
+	}
+
+	status := AccessRequestDenied
+	if approve {
+		status = AccessRequestApproved
+	}
+
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return ar, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	err = tx.QueryRow(db.ctx, accessRequestDbMethod.decideAccessRequest(), id, string(status), decidedBy, note).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return ar, ErrAccessRequestDecided
+	}
+	if err != nil {
+		return ar, fmt.Errorf("error deciding access request: %v", err)
+	}
+
+	if approve {
+		_, err = db.addMultipleMembersToResource(tx, []string{ar.Email}, ar.ResourceID, decidedBy)
+		if err != nil {
+			return ar, err
+		}
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return ar, fmt.Errorf("error committing access request: %v", err)
+	}
+
+	return db.GetAccessRequestByID(id)
+}
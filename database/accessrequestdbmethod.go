@@ -0,0 +1,57 @@
+package database
+
+// AccessRequestDatabaseMethod -- method container that holds the extension methods to query the access requests table
+type AccessRequestDatabaseMethod struct{}
+
+func (accessRequest *AccessRequestDatabaseMethod) selectAccessRequests(where string) string {
+	return `SELECT ar.id, ar.member_id, m.name, m.email, ar.resource_id, r.description, ar.note, ar.status,
+	ar.requested_at, ar.decided_by, ar.decided_at, ar.decision_note
+	FROM membership.access_requests ar
+	INNER JOIN membership.members m
+	ON m.id = ar.member_id
+	INNER JOIN membership.resources r
+	ON r.id = ar.resource_id
+	WHERE ` + where + `
+	ORDER BY ar.requested_at DESC, ar.id DESC;`
+}
+
+func (accessRequest *AccessRequestDatabaseMethod) getMemberAccessRequests() string {
+	return accessRequest.selectAccessRequests(`ar.member_id = $1`)
+}
+
+func (accessRequest *AccessRequestDatabaseMethod) getPendingAccessRequests() string {
+	return accessRequest.selectAccessRequests(`ar.status = 'pending'`)
+}
+
+func (accessRequest *AccessRequestDatabaseMethod) getPendingAccessRequestsForTrainer() string {
+	return accessRequest.selectAccessRequests(`ar.status = 'pending'
+	AND ar.resource_id IN (
+		SELECT t.resource_id
+		FROM membership.resource_trainers t
+		INNER JOIN membership.members trainer
+		ON trainer.id = t.member_id
+		WHERE trainer.email = $1
+	)`)
+}
+
+func (accessRequest *AccessRequestDatabaseMethod) getAccessRequestByID() string {
+	return accessRequest.selectAccessRequests(`ar.id = $1`)
+}
+
+func (accessRequest *AccessRequestDatabaseMethod) insertAccessRequest() string {
+	const insertAccessRequestQuery = `INSERT INTO membership.access_requests(
+		member_id, resource_id, note)
+		VALUES ($1, $2, $3)
+		RETURNING id;`
+
+	return insertAccessRequestQuery
+}
+
+func (accessRequest *AccessRequestDatabaseMethod) decideAccessRequest() string {
+	const decideAccessRequestQuery = `UPDATE membership.access_requests
+	SET status = $2, decided_by = $3, decision_note = $4, decided_at = NOW()
+	WHERE id = $1 AND status = 'pending'
+	RETURNING id;`
+
+	return decideAccessRequestQuery
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+var certificationDbMethod CertificationDatabaseMethod
+
+// Certification - a training members need before they can use some resources, i.e. laser cutter safety
+type Certification struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// MemberCertification - a record that a member was trained.
+//   Members keep access to the resources that require the certification
+//   until it expires or is revoked.
+type MemberCertification struct {
+	ID              int64  `json:"id"`
+	MemberID        string `json:"memberID"`
+	CertificationID int    `json:"certificationID"`
+	Name            string `json:"name"`
+	// Trainer - who gave the training
+	Trainer string `json:"trainer"`
+	// RecordedBy - the user that recorded the certification
+	RecordedBy  string    `json:"recordedBy"`
+	CertifiedAt time.Time `json:"certifiedAt"`
+	// ExpiresAt - when the member needs to be trained again.  Empty when it doesn't expire
+	ExpiresAt *time.Time `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
+
+// MissingCertificationsError - a member doesn't have the certifications a resource requires
+type MissingCertificationsError struct {
+	Email    string
+	Resource string
+	Missing  []Certification
+}
+
+func (e MissingCertificationsError) Error() string {
+	var names []string
+	for _, c := range e.Missing {
+		names = append(names, c.Name)
+	}
+
+	return fmt.Sprintf("%s needs %s to use %s", e.Email, strings.Join(names, ", "), e.Resource)
+}
+
+func (db *Database) getCertifications(q querier, query string, args ...interface{}) ([]Certification, error) {
+	var certifications []Certification
+
+	rows, err := q.Query(db.ctx, query, args...)
+	if err != nil {
+		return certifications, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Certification
+
+		err = rows.Scan(&c.ID, &c.Name, &c.Description)
+		if err != nil {
+			return certifications, fmt.Errorf("error reading certification: %v", err)
+		}
+
+		certifications = append(certifications, c)
+	}
+
+	return certifications, nil
+}
+
+// GetCertifications - gets the trainings that resources can require
+func (db *Database) GetCertifications() ([]Certification, error) {
+	return db.getCertifications(db.getConn(), certificationDbMethod.getCertifications())
+}
+
+// AddCertification - adds a training that resources can require
+func (db *Database) AddCertification(name string, description string) (Certification, error) {
+	var c Certification
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return c, errors.New("a certification needs a name")
+	}
+
+	err := db.getConn().QueryRow(db.ctx, certificationDbMethod.insertCertification(), name, description).Scan(&c.ID, &c.Name, &c.Description)
+	if err != nil {
+		return c, fmt.Errorf("error adding certification: %v", err)
+	}
+
+	return c, nil
+}
+
+// GetResourceCertifications - gets the certifications a resource requires
+func (db *Database) GetResourceCertifications(resourceID string) ([]Certification, error) {
+	return db.getCertifications(db.getConn(), certificationDbMethod.getResourceCertifications(), resourceID)
+}
+
+// SetResourceCertifications - replaces the certifications a resource requires
+func (db *Database) SetResourceCertifications(resourceID string, certificationIDs []int) ([]Certification, error) {
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	_, err = tx.Exec(db.ctx, certificationDbMethod.removeResourceCertifications(), resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error removing resource certifications: %v", err)
+	}
+
+	for _, id := range certificationIDs {
+		_, err = tx.Exec(db.ctx, certificationDbMethod.insertResourceCertification(), resourceID, id)
+		if err != nil {
+			return nil, fmt.Errorf("error adding resource certification %d: %v", id, err)
+		}
+	}
+
+	certifications, err := db.getCertifications(tx, certificationDbMethod.getResourceCertifications(), resourceID)
+	if err != nil {
+		return certifications, err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return certifications, fmt.Errorf("error committing resource certifications: %v", err)
+	}
+
+	return certifications, nil
+}
+
+// GetMissingCertifications - the certifications a resource requires that the member doesn't have
+func (db *Database) GetMissingCertifications(memberID string, resourceID string) ([]Certification, error) {
+	return db.getMissingCertifications(db.getConn(), memberID, resourceID)
+}
+
+func (db *Database) getMissingCertifications(q querier, memberID string, resourceID string) ([]Certification, error) {
+	return db.getCertifications(q, certificationDbMethod.getMissingCertifications(), memberID, resourceID)
+}
+
+func scanMemberCertification(row pgx.Row) (MemberCertification, error) {
+	var mc MemberCertification
+
+	err := row.Scan(&mc.ID, &mc.MemberID, &mc.CertificationID, &mc.Name, &mc.Trainer, &mc.RecordedBy, &mc.CertifiedAt, &mc.ExpiresAt, &mc.RevokedAt)
+
+	return mc, err
+}
+
+// GetMemberCertifications - gets every certification of a member, including expired and revoked certifications
+func (db *Database) GetMemberCertifications(memberID string) ([]MemberCertification, error) {
+	var certifications []MemberCertification
+
+	rows, err := db.getConn().Query(db.ctx, certificationDbMethod.getMemberCertifications(), memberID)
+	if err != nil {
+		return certifications, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		mc, err := scanMemberCertification(rows)
+		if err != nil {
+			return certifications, fmt.Errorf("error reading member certification: %v", err)
+		}
+
+		certifications = append(certifications, mc)
+	}
+
+	return certifications, nil
+}
+
+// GetMemberCertificationByID - lookup a member certification by its id
+func (db *Database) GetMemberCertificationByID(id int64) (MemberCertification, error) {
+	mc, err := scanMemberCertification(db.getConn().QueryRow(db.ctx, certificationDbMethod.getMemberCertificationByID(), id))
+	if err != nil {
+		return mc, fmt.Errorf("error getting member certification: %v", err)
+	}
+
+	return mc, nil
+}
+
+// AddMemberCertification - records that a member was trained
+func (db *Database) AddMemberCertification(mc MemberCertification) (MemberCertification, error) {
+	if strings.TrimSpace(mc.Trainer) == "" {
+		return mc, errors.New("a certification needs a trainer")
+	}
+
+	if mc.CertifiedAt.IsZero() {
+		mc.CertifiedAt = time.Now()
+	}
+
+	if mc.ExpiresAt != nil && !mc.ExpiresAt.After(mc.CertifiedAt) {
+		return mc, errors.New("a certification has to expire after it was given")
+	}
+
+	var id int64
+	err := db.getConn().QueryRow(db.ctx, certificationDbMethod.insertMemberCertification(), mc.MemberID, mc.CertificationID, mc.Trainer, mc.RecordedBy, mc.CertifiedAt, mc.ExpiresAt).Scan(&id)
+	if err != nil {
+		return mc, fmt.Errorf("error adding member certification: %v", err)
+	}
+
+	return db.GetMemberCertificationByID(id)
+}
+
+// RevokeMemberCertification - takes a certification away from a member
+func (db *Database) RevokeMemberCertification(id int64) (MemberCertification, error) {
+	err := db.getConn().QueryRow(db.ctx, certificationDbMethod.revokeMemberCertification(), id).Scan(&id)
+	if err != nil {
+		return MemberCertification{}, fmt.Errorf("error revoking member certification: %v", err)
+	}
+
+	return db.GetMemberCertificationByID(id)
+}
+
+// GetResourcesWithExpiredCertifications - the resources that require a certification
+//   that expired for a member after since and up to until
+func (db *Database) GetResourcesWithExpiredCertifications(since time.Time, until time.Time) ([]Resource, error) {
+	var resources []Resource
+
+	rows, err := db.getConn().Query(db.ctx, certificationDbMethod.getResourcesWithExpiredCertifications(), since, until)
+	if err != nil {
+		return resources, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Resource
+
+		err = rows.Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault, &r.RFIDFormat, &r.SupportsSchedules)
+		if err != nil {
+			return resources, fmt.Errorf("error reading resource: %v", err)
+		}
+
+		r.LastHeartBeat = GetLastHeartbeat(r)
+		resources = append(resources, r)
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,163 @@
+package database
+
+// CertificationDatabaseMethod -- method container that holds the extension methods to query the certification tables
+type CertificationDatabaseMethod struct{}
+
+// validMemberCertification - the member certification mc hasn't been revoked and hasn't expired
+const validMemberCertification = `mc.revoked_at IS NULL
+		AND (mc.expires_at IS NULL OR mc.expires_at > NOW())`
+
+// hasRequiredCertifications - the member has every certification the resource of member_resource requires
+const hasRequiredCertifications = `NOT EXISTS (
+		SELECT 1
+		FROM membership.resource_certifications rc
+		WHERE rc.resource_id = membership.member_resource.resource_id
+		AND NOT EXISTS (
+			SELECT 1
+			FROM membership.member_certifications mc
+			WHERE mc.member_id = membership.members.id
+			AND mc.certification_id = rc.certification_id
+			AND ` + validMemberCertification + `
+		)
+	)`
+
+// guestResourceWithoutCertifications - the resource of guest_resource gr doesn't require any certifications.
+//   Guests aren't trained so they can't be given resources that require a certification
+const guestResourceWithoutCertifications = `NOT EXISTS (
+		SELECT 1
+		FROM membership.resource_certifications rc
+		WHERE rc.resource_id = gr.resource_id
+	)`
+
+const memberCertificationColumns = `mc.id, mc.member_id, mc.certification_id, c.name, mc.trainer, mc.recorded_by, mc.certified_at, mc.expires_at, mc.revoked_at`
+
+func (certification *CertificationDatabaseMethod) getCertifications() string {
+	const getCertificationsQuery = `SELECT id, name, description
+	FROM membership.certifications
+	ORDER BY name;`
+
+	return getCertificationsQuery
+}
+
+func (certification *CertificationDatabaseMethod) insertCertification() string {
+	const insertCertificationQuery = `INSERT INTO membership.certifications(
+		name, description)
+		VALUES ($1, $2)
+		RETURNING id, name, description;`
+
+	return insertCertificationQuery
+}
+
+func (certification *CertificationDatabaseMethod) getResourceCertifications() string {
+	const getResourceCertificationsQuery = `SELECT c.id, c.name, c.description
+	FROM membership.resource_certifications rc
+	INNER JOIN membership.certifications c
+	ON c.id = rc.certification_id
+	WHERE rc.resource_id = $1
+	ORDER BY c.name;`
+
+	return getResourceCertificationsQuery
+}
+
+func (certification *CertificationDatabaseMethod) removeResourceCertifications() string {
+	const removeResourceCertificationsQuery = `DELETE FROM membership.resource_certifications
+	WHERE resource_id = $1;`
+
+	return removeResourceCertificationsQuery
+}
+
+func (certification *CertificationDatabaseMethod) insertResourceCertification() string {
+	const insertResourceCertificationQuery = `INSERT INTO membership.resource_certifications(
+		resource_id, certification_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;`
+
+	return insertResourceCertificationQuery
+}
+
+func (certification *CertificationDatabaseMethod) getResourcesRequiringCertifications() string {
+	const getResourcesRequiringCertificationsQuery = `SELECT r.description
+	FROM membership.resources r
+	WHERE r.id = ANY($1::uuid[])
+	AND EXISTS (
+		SELECT 1
+		FROM membership.resource_certifications rc
+		WHERE rc.resource_id = r.id
+	)
+	ORDER BY r.description;`
+
+	return getResourcesRequiringCertificationsQuery
+}
+
+func (certification *CertificationDatabaseMethod) getMissingCertifications() string {
+	const getMissingCertificationsQuery = `SELECT c.id, c.name, c.description
+	FROM membership.resource_certifications rc
+	INNER JOIN membership.certifications c
+	ON c.id = rc.certification_id
+	WHERE rc.resource_id = $2
+	AND NOT EXISTS (
+		SELECT 1
+		FROM membership.member_certifications mc
+		WHERE mc.member_id = $1
+		AND mc.certification_id = rc.certification_id
+		AND ` + validMemberCertification + `
+	)
+	ORDER BY c.name;`
+
+	return getMissingCertificationsQuery
+}
+
+func (certification *CertificationDatabaseMethod) getMemberCertifications() string {
+	const getMemberCertificationsQuery = `SELECT ` + memberCertificationColumns + `
+	FROM membership.member_certifications mc
+	INNER JOIN membership.certifications c
+	ON c.id = mc.certification_id
+	WHERE mc.member_id = $1
+	ORDER BY mc.certified_at DESC, mc.id DESC;`
+
+	return getMemberCertificationsQuery
+}
+
+func (certification *CertificationDatabaseMethod) getMemberCertificationByID() string {
+	const getMemberCertificationByIDQuery = `SELECT ` + memberCertificationColumns + `
+	FROM membership.member_certifications mc
+	INNER JOIN membership.certifications c
+	ON c.id = mc.certification_id
+	WHERE mc.id = $1;`
+
+	return getMemberCertificationByIDQuery
+}
+
+func (certification *CertificationDatabaseMethod) insertMemberCertification() string {
+	const insertMemberCertificationQuery = `INSERT INTO membership.member_certifications(
+		member_id, certification_id, trainer, recorded_by, certified_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id;`
+
+	return insertMemberCertificationQuery
+}
+
+func (certification *CertificationDatabaseMethod) revokeMemberCertification() string {
+	const revokeMemberCertificationQuery = `UPDATE membership.member_certifications
+	SET revoked_at = COALESCE(revoked_at, NOW())
+	WHERE id = $1
+	RETURNING id;`
+
+	return revokeMemberCertificationQuery
+}
+
+func (certification *CertificationDatabaseMethod) getResourcesWithExpiredCertifications() string {
+	// revoked certifications already took the member off the resources when they were revoked
+	const getResourcesWithExpiredCertificationsQuery = `SELECT DISTINCT r.id, r.description, r.device_identifier, r.is_default, r.rfid_format, r.supports_schedules
+	FROM membership.resources r
+	INNER JOIN membership.resource_certifications rc
+	ON rc.resource_id = r.id
+	INNER JOIN membership.member_certifications mc
+	ON mc.certification_id = rc.certification_id
+	WHERE mc.revoked_at IS NULL
+	AND mc.expires_at > $1
+	AND mc.expires_at <= $2
+	ORDER BY r.description;`
+
+	return getResourcesWithExpiredCertificationsQuery
+}
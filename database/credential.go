@@ -0,0 +1,219 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"memberserver/rfid"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+var credentialDbMethod CredentialDatabaseMethod
+
+// CredentialStatus - whether an rfid credential still opens doors
+type CredentialStatus string
+
+const (
+	// CredentialActive - the credential is pushed to the resources
+	CredentialActive CredentialStatus = "active"
+	// CredentialLost - the member lost the tag
+	CredentialLost CredentialStatus = "lost"
+	// CredentialRevoked - the tag was taken away from the member
+	CredentialRevoked CredentialStatus = "revoked"
+)
+
+// CredentialStatuses lists the statuses a credential can have
+var CredentialStatuses = map[CredentialStatus]bool{
+	CredentialActive:  true,
+	CredentialLost:    true,
+	CredentialRevoked: true,
+}
+
+// Credential - an rfid tag that belongs to a member.
+//   A member can have as many credentials as they need, i.e. a card and a key fob.
+//   Only active credentials are sent to the resources.
+type Credential struct {
+	ID       int64  `json:"id"`
+	MemberID string `json:"memberID"`
+	// RFID - the UID of the tag in hex.  Legacy tags are how the esp reader prints them.
+	RFID string `json:"rfid"`
+	// Legacy - the UID of the tag couldn't be recovered when tags were moved to UIDs.
+	//   Legacy tags only work on esp resources.
+	Legacy   bool             `json:"legacy"`
+	Label    string           `json:"label"`
+	Status   CredentialStatus `json:"status"`
+	IssuedAt time.Time        `json:"issuedAt"`
+	// StatusChangedAt - when the credential was last marked active, lost or revoked
+	StatusChangedAt *time.Time `json:"statusChangedAt"`
+}
+
+func scanCredential(row pgx.Row) (Credential, error) {
+	var c Credential
+	var status string
+
+	err := row.Scan(&c.ID, &c.MemberID, &c.RFID, &c.Legacy, &c.Label, &status, &c.IssuedAt, &c.StatusChangedAt)
+	if err != nil {
+		return c, err
+	}
+
+	c.Status = CredentialStatus(status)
+
+	return c, nil
+}
+
+// GetMemberCredentials - gets every credential of a member, including lost and revoked credentials
+func (db *Database) GetMemberCredentials(memberID string) ([]Credential, error) {
+	var credentials []Credential
+
+	rows, err := db.getConn().Query(db.ctx, credentialDbMethod.getMemberCredentials(), memberID)
+	if err != nil {
+		return credentials, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanCredential(rows)
+		if err != nil {
+			log.Errorf("error scanning row: %s", err)
+			continue
+		}
+
+		credentials = append(credentials, c)
+	}
+
+	return credentials, nil
+}
+
+// GetCredentialByID - lookup a credential by its id
+func (db *Database) GetCredentialByID(credentialID int64) (Credential, error) {
+	return scanCredential(db.getConn().QueryRow(db.ctx, credentialDbMethod.getCredentialByID(), credentialID))
+}
+
+// AddCredential - issues a new rfid tag to a member.
+//   The tag is read in the format it was given in, the number printed on the tag is the decimal format.
+func (db *Database) AddCredential(memberID string, tag string, format rfid.Format, label string) (Credential, error) {
+	if format == "" {
+		format = rfid.FormatDecimal
+	}
+
+	uid, err := rfid.Decode(format, tag)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return Credential{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	credentialID, err := db.addCredential(tx, memberID, uid, strings.TrimSpace(label))
+	if err == pgx.ErrNoRows {
+		return Credential{}, errors.New("the member already has this rfid")
+	}
+	if err != nil {
+		return Credential{}, err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return Credential{}, fmt.Errorf("error committing credential: %v", err)
+	}
+
+	return db.GetCredentialByID(credentialID)
+}
+
+// addCredential inserts an active credential and makes it the member's current rfid.
+//   pgx.ErrNoRows is returned if the member already has the tag.
+func (db *Database) addCredential(q querier, memberID string, uid rfid.UID, label string) (int64, error) {
+	var credentialID int64
+
+	err := q.QueryRow(db.ctx, credentialDbMethod.insertCredential(), memberID, uid.String(), label).Scan(&credentialID)
+	if err == pgx.ErrNoRows {
+		return credentialID, err
+	}
+	if err != nil {
+		return credentialID, fmt.Errorf("error adding credential: %v", err)
+	}
+
+	_, err = db.refreshMemberRFID(q, memberID)
+	if err != nil {
+		return credentialID, err
+	}
+
+	return credentialID, nil
+}
+
+// UpdateCredential - changes the label or status of a credential.
+//   Marking a credential lost or revoked removes it from the member,
+//   the caller is responsible for pushing the change to the resources.
+func (db *Database) UpdateCredential(credentialID int64, label string, status CredentialStatus) (Credential, error) {
+	if !CredentialStatuses[status] {
+		return Credential{}, fmt.Errorf("not a valid credential status: %s", status)
+	}
+
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return Credential{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	var memberID string
+	err = tx.QueryRow(db.ctx, credentialDbMethod.updateCredential(), credentialID, strings.TrimSpace(label), string(status)).Scan(&memberID)
+	if err == pgx.ErrNoRows {
+		return Credential{}, errors.New("no rows affected")
+	}
+	if err != nil {
+		return Credential{}, fmt.Errorf("error updating credential: %v", err)
+	}
+
+	_, err = db.refreshMemberRFID(tx, memberID)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return Credential{}, fmt.Errorf("error committing credential: %v", err)
+	}
+
+	return db.GetCredentialByID(credentialID)
+}
+
+// refreshMemberRFID sets the rfid shown on the member to their newest active credential
+func (db *Database) refreshMemberRFID(q querier, memberID string) (string, error) {
+	var rfid string
+
+	err := q.QueryRow(db.ctx, credentialDbMethod.refreshMemberRFID(), memberID).Scan(&rfid)
+	if err != nil {
+		return rfid, fmt.Errorf("error updating member rfid: %v", err)
+	}
+
+	return rfid, nil
+}
+
+// renderTag encodes a tag in the format the resource expects.
+//   Legacy tags are only known in the esp format.
+func renderTag(format rfid.Format, uid *string, legacyRFID *string) (string, error) {
+	if format == "" {
+		format = rfid.DefaultFormat
+	}
+
+	if uid == nil {
+		if legacyRFID != nil && format == rfid.FormatESP {
+			return *legacyRFID, nil
+		}
+		return "", fmt.Errorf("legacy tag can't be encoded as %s", format)
+	}
+
+	u, err := rfid.ParseUID(*uid)
+	if err != nil {
+		return "", err
+	}
+
+	return rfid.Encode(format, u)
+}
@@ -0,0 +1,64 @@
+package database
+
+// CredentialDatabaseMethod -- method container that holds the extension methods to query the member credentials table
+type CredentialDatabaseMethod struct{}
+
+func (credential *CredentialDatabaseMethod) getMemberCredentials() string {
+	const getMemberCredentialsQuery = `SELECT id, member_id, COALESCE(uid, legacy_rfid), uid IS NULL, label, status, issued_at, status_changed_at
+	FROM membership.member_credentials
+	WHERE member_id = $1
+	ORDER BY issued_at DESC, id DESC;`
+
+	return getMemberCredentialsQuery
+}
+
+func (credential *CredentialDatabaseMethod) getCredentialByID() string {
+	const getCredentialByIDQuery = `SELECT id, member_id, COALESCE(uid, legacy_rfid), uid IS NULL, label, status, issued_at, status_changed_at
+	FROM membership.member_credentials
+	WHERE id = $1;`
+
+	return getCredentialByIDQuery
+}
+
+func (credential *CredentialDatabaseMethod) insertCredential() string {
+	// nothing is inserted if the member already has the tag
+	//  if another member has the tag, the unique index on active tags rejects it
+	const insertCredentialQuery = `INSERT INTO membership.member_credentials(
+		member_id, uid, label)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM membership.member_credentials
+			WHERE member_id = $1 AND uid = $2 AND status = 'active'
+		)
+	RETURNING id;`
+
+	return insertCredentialQuery
+}
+
+func (credential *CredentialDatabaseMethod) updateCredential() string {
+	const updateCredentialQuery = `UPDATE membership.member_credentials
+	SET label = $2,
+		status_changed_at = CASE WHEN status = $3 THEN status_changed_at ELSE NOW() END,
+		status = $3
+	WHERE id = $1
+	RETURNING member_id;`
+
+	return updateCredentialQuery
+}
+
+func (credential *CredentialDatabaseMethod) refreshMemberRFID() string {
+	// the member's rfid is their most recently issued active credential
+	const refreshMemberRFIDQuery = `UPDATE membership.members
+	SET rfid = (
+		SELECT COALESCE(uid, legacy_rfid)
+		FROM membership.member_credentials
+		WHERE member_id = $1 AND status = 'active'
+		ORDER BY issued_at DESC, id DESC
+		LIMIT 1
+	)
+	WHERE id = $1
+	RETURNING COALESCE(rfid, 'notset');`
+
+	return refreshMemberRFIDQuery
+}
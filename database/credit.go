@@ -0,0 +1,172 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+var creditDbMethod CreditDatabaseMethod
+
+// CreditReason - why a member was credited a membership
+type CreditReason string
+
+const (
+	// CreditScholarship - the member was granted a scholarship
+	CreditScholarship CreditReason = "scholarship"
+	// CreditVolunteer - the member volunteers for the space
+	CreditVolunteer CreditReason = "volunteer"
+	// CreditBoard - the member serves on the board
+	CreditBoard CreditReason = "board"
+)
+
+// CreditReasons lists the reasons a credit can be granted for
+var CreditReasons = map[CreditReason]bool{
+	CreditScholarship: true,
+	CreditVolunteer:   true,
+	CreditBoard:       true,
+}
+
+// ErrCreditedWithoutCredit - members are only on the Credited tier while they have an active credit,
+//   otherwise the next member status check moves them back to inactive
+var ErrCreditedWithoutCredit = errors.New("grant the member a credit to move them to the credited tier")
+
+// MemberCredit - a membership that was credited to a member
+type MemberCredit struct {
+	ID        int64        `json:"id"`
+	MemberID  string       `json:"memberID"`
+	Name      string       `json:"name"`
+	Email     string       `json:"email"`
+	Reason    CreditReason `json:"reason"`
+	GrantedBy string       `json:"grantedBy"`
+	CreatedAt time.Time    `json:"createdAt"`
+	// ExpiresAt - when the credit ends.  A credit without an expiry doesn't end.
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Active    bool       `json:"active"`
+}
+
+func scanMemberCredit(row pgx.Row) (MemberCredit, error) {
+	var c MemberCredit
+	var reason string
+
+	err := row.Scan(&c.ID, &c.MemberID, &c.Name, &c.Email, &reason, &c.GrantedBy, &c.CreatedAt, &c.ExpiresAt)
+	if err != nil {
+		return c, err
+	}
+
+	c.Reason = CreditReason(reason)
+	c.Active = c.ExpiresAt == nil || c.ExpiresAt.After(time.Now())
+
+	return c, nil
+}
+
+func (db *Database) getMemberCredits(query string, args ...interface{}) ([]MemberCredit, error) {
+	var credits []MemberCredit
+
+	rows, err := db.getConn().Query(db.ctx, query, args...)
+	if err != nil {
+		return credits, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanMemberCredit(rows)
+		if err != nil {
+			log.Errorf("error scanning row: %s", err)
+			continue
+		}
+
+		credits = append(credits, c)
+	}
+
+	return credits, nil
+}
+
+// GetMemberCredits - gets all of the credits that have been granted, including expired credits
+func (db *Database) GetMemberCredits() ([]MemberCredit, error) {
+	return db.getMemberCredits(creditDbMethod.getMemberCredits())
+}
+
+// GetActiveMemberCredits - gets the credits of a member that haven't expired
+func (db *Database) GetActiveMemberCredits(memberID string) ([]MemberCredit, error) {
+	return db.getMemberCredits(creditDbMethod.getActiveMemberCredits(), memberID)
+}
+
+// GetMemberCreditByID - lookup a credit by its id
+func (db *Database) GetMemberCreditByID(creditID int64) (MemberCredit, error) {
+	return scanMemberCredit(db.getConn().QueryRow(db.ctx, creditDbMethod.getMemberCreditByID(), creditID))
+}
+
+// AddMemberCredit - credits a membership to a member
+func (db *Database) AddMemberCredit(memberID string, reason CreditReason, grantedBy string, expiresAt *time.Time) (MemberCredit, error) {
+	if !CreditReasons[reason] {
+		return MemberCredit{}, fmt.Errorf("not a valid credit reason: %s", reason)
+	}
+
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return MemberCredit{}, errors.New("credit expires in the past")
+	}
+
+	var creditID int64
+
+	err := db.getConn().QueryRow(db.ctx, creditDbMethod.insertMemberCredit(), memberID, string(reason), grantedBy, expiresAt).Scan(&creditID)
+	if err != nil {
+		return MemberCredit{}, fmt.Errorf("error inserting member credit: %v", err)
+	}
+
+	return db.GetMemberCreditByID(creditID)
+}
+
+// UpdateMemberCredit - updates the reason or expiry of a credit
+func (db *Database) UpdateMemberCredit(creditID int64, reason CreditReason, expiresAt *time.Time) (MemberCredit, error) {
+	if !CreditReasons[reason] {
+		return MemberCredit{}, fmt.Errorf("not a valid credit reason: %s", reason)
+	}
+
+	err := db.getConn().QueryRow(db.ctx, creditDbMethod.updateMemberCredit(), creditID, string(reason), expiresAt).Scan(&creditID)
+	if err == pgx.ErrNoRows {
+		return MemberCredit{}, errors.New("no rows affected")
+	}
+	if err != nil {
+		return MemberCredit{}, fmt.Errorf("error updating member credit: %v", err)
+	}
+
+	return db.GetMemberCreditByID(creditID)
+}
+
+// RemoveMemberCredit - deletes a credit
+func (db *Database) RemoveMemberCredit(creditID int64) error {
+	commandTag, err := db.getConn().Exec(db.ctx, creditDbMethod.deleteMemberCredit(), creditID)
+	if err != nil {
+		return err
+	}
+
+	if commandTag.RowsAffected() != 1 {
+		return errors.New("No row found to delete")
+	}
+
+	return nil
+}
+
+// RevokeLapsedCredits - moves credited members that no longer have an active credit
+//   back to inactive.  This is how expired credits drop off.
+func (db *Database) RevokeLapsedCredits() {
+	_, err := db.getConn().Exec(db.ctx, creditDbMethod.revokeLapsedCredits(), Inactive, Credited, string(TierChangeCreditLapsed))
+	if err != nil {
+		log.Errorf("revoke lapsed credits failed: %v", err)
+	}
+}
+
+// hasActiveCredit - whether the member has a credit that hasn't expired
+func (db *Database) hasActiveCredit(memberID string) (bool, error) {
+	credits, err := db.GetActiveMemberCredits(memberID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(credits) > 0, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+// CreditDatabaseMethod -- method container that holds the extension methods to query the member credit table
+type CreditDatabaseMethod struct{}
+
+func (credit *CreditDatabaseMethod) getMemberCredits() string {
+	const getMemberCreditsQuery = `SELECT c.id, c.member_id, m.name, m.email, c.reason, c.granted_by, c.created_at, c.expires_at
+	FROM membership.member_credit c
+	INNER JOIN membership.members m
+	ON c.member_id = m.id
+	ORDER BY c.created_at DESC;`
+
+	return getMemberCreditsQuery
+}
+
+func (credit *CreditDatabaseMethod) getActiveMemberCredits() string {
+	const getActiveMemberCreditsQuery = `SELECT c.id, c.member_id, m.name, m.email, c.reason, c.granted_by, c.created_at, c.expires_at
+	FROM membership.member_credit c
+	INNER JOIN membership.members m
+	ON c.member_id = m.id
+	WHERE c.member_id = $1
+	AND (c.expires_at IS NULL OR c.expires_at > NOW())
+	ORDER BY c.created_at DESC;`
+
+	return getActiveMemberCreditsQuery
+}
+
+func (credit *CreditDatabaseMethod) getMemberCreditByID() string {
+	const getMemberCreditByIDQuery = `SELECT c.id, c.member_id, m.name, m.email, c.reason, c.granted_by, c.created_at, c.expires_at
+	FROM membership.member_credit c
+	INNER JOIN membership.members m
+	ON c.member_id = m.id
+	WHERE c.id = $1;`
+
+	return getMemberCreditByIDQuery
+}
+
+func (credit *CreditDatabaseMethod) insertMemberCredit() string {
+	const insertMemberCreditQuery = `INSERT INTO membership.member_credit(
+		member_id, reason, granted_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+	RETURNING id;`
+
+	return insertMemberCreditQuery
+}
+
+func (credit *CreditDatabaseMethod) updateMemberCredit() string {
+	const updateMemberCreditQuery = `UPDATE membership.member_credit
+	SET reason = $2, expires_at = $3
+	WHERE id = $1
+	RETURNING id;`
+
+	return updateMemberCreditQuery
+}
+
+func (credit *CreditDatabaseMethod) deleteMemberCredit() string {
+	const deleteMemberCreditQuery = `DELETE FROM membership.member_credit
+	WHERE id = $1;`
+
+	return deleteMemberCreditQuery
+}
+
+func (credit *CreditDatabaseMethod) revokeLapsedCredits() string {
+	// credited members without an active credit go back to inactive
+	//  if they have been paying, the next tier update will pick that up
+	const revokeLapsedCreditsQuery = `WITH updated AS (
+		UPDATE membership.members m
+		SET member_tier_id = $1
+		FROM membership.members previous
+		WHERE previous.id = m.id
+			AND m.member_tier_id = $2
+			AND NOT EXISTS (
+				SELECT 1
+				FROM membership.member_credit c
+				WHERE c.member_id = m.id
+				AND (c.expires_at IS NULL OR c.expires_at > NOW())
+			)
+		RETURNING m.id, previous.member_tier_id as previous_tier_id, m.member_tier_id
+	)
+	INSERT INTO membership.member_tier_history(member_id, previous_tier_id, new_tier_id, cause)
+	SELECT id, previous_tier_id, member_tier_id, $3
+	FROM updated;`
+
+	return revokeLapsedCreditsQuery
+}
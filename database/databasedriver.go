@@ -9,6 +9,8 @@ import (
 	"memberserver/config"
 	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -42,6 +44,14 @@ func (db *Database) Release() error {
 	return nil
 }
 
+// querier is satisfied by both the connection pool and a transaction
+//   so that queries can be shared between the two
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 func (db *Database) getConn() *pgxpool.Pool {
 
 	db.mu.Lock()
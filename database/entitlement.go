@@ -0,0 +1,131 @@
+package database
+
+import (
+	"fmt"
+)
+
+var entitlementDbMethod EntitlementDatabaseMethod
+
+// TierEntitlement - who is recorded in the resource access log for changes made because of a member's tier
+const TierEntitlement = "tier entitlement"
+
+// GetTierResources - gets the resources that members of a tier get automatically
+func (db *Database) GetTierResources(level MemberLevel) ([]MemberResource, error) {
+	return db.getTierResources(db.getConn(), level)
+}
+
+func (db *Database) getTierResources(q querier, level MemberLevel) ([]MemberResource, error) {
+	var resources []MemberResource
+
+	rows, err := q.Query(db.ctx, entitlementDbMethod.getTierResources(), level)
+	if err != nil {
+		return resources, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var r MemberResource
+
+		err = rows.Scan(&r.ResourceID, &r.Name)
+		if err != nil {
+			return resources, fmt.Errorf("error reading tier resource: %v", err)
+		}
+
+		resources = append(resources, r)
+	}
+
+	return resources, nil
+}
+
+// SetTierResources - replaces the resources that members of a tier get automatically.
+//   Members aren't granted or removed until SyncTierEntitlements runs.
+func (db *Database) SetTierResources(level MemberLevel, resourceIDs []string) ([]MemberResource, error) {
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	_, err = tx.Exec(db.ctx, entitlementDbMethod.removeTierResources(), level)
+	if err != nil {
+		return nil, fmt.Errorf("error removing tier resources: %v", err)
+	}
+
+	for _, id := range resourceIDs {
+		_, err = tx.Exec(db.ctx, entitlementDbMethod.insertTierResource(), level, id)
+		if err != nil {
+			return nil, fmt.Errorf("error adding tier resource %s: %v", id, err)
+		}
+	}
+
+	resources, err := db.getTierResources(tx, level)
+	if err != nil {
+		return resources, err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return resources, fmt.Errorf("error committing tier resources: %v", err)
+	}
+
+	return resources, nil
+}
+
+func (db *Database) syncEntitlements(q querier, query string, action ResourceAccessAction) ([]ResourceAccessChange, error) {
+	var changes []ResourceAccessChange
+
+	rows, err := q.Query(db.ctx, query, string(action), TierEntitlement)
+	if err != nil {
+		return changes, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		c := ResourceAccessChange{
+			Action:    action,
+			ChangedBy: TierEntitlement,
+		}
+
+		err = rows.Scan(&c.MemberID, &c.Email, &c.ResourceID)
+		if err != nil {
+			return changes, fmt.Errorf("error reading entitlement change: %v", err)
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
+
+// SyncTierEntitlements - grants members the resources their tier includes
+//   and removes the resources that were granted by a tier they are no longer on.
+//   Resources that were granted manually are left alone.
+//   Returns each grant and removal so the resources can be pushed.
+func (db *Database) SyncTierEntitlements() ([]ResourceAccessChange, error) {
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	removed, err := db.syncEntitlements(tx, entitlementDbMethod.removeLapsedEntitlements(), ResourceAccessRemoved)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := db.syncEntitlements(tx, entitlementDbMethod.insertEntitlements(), ResourceAccessGranted)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error committing tier entitlements: %v", err)
+	}
+
+	return append(removed, granted...), nil
+}
@@ -0,0 +1,82 @@
+package database
+
+// EntitlementDatabaseMethod -- method container that holds the extension methods to query the tier resources table
+type EntitlementDatabaseMethod struct{}
+
+func (entitlement *EntitlementDatabaseMethod) getTierResources() string {
+	const getTierResourcesQuery = `SELECT r.id, r.description
+	FROM membership.tier_resources tr
+	INNER JOIN membership.resources r
+	ON r.id = tr.resource_id
+	WHERE tr.member_tier_id = $1
+	ORDER BY r.description;`
+
+	return getTierResourcesQuery
+}
+
+func (entitlement *EntitlementDatabaseMethod) removeTierResources() string {
+	const removeTierResourcesQuery = `DELETE FROM membership.tier_resources
+	WHERE member_tier_id = $1;`
+
+	return removeTierResourcesQuery
+}
+
+func (entitlement *EntitlementDatabaseMethod) insertTierResource() string {
+	const insertTierResourceQuery = `INSERT INTO membership.tier_resources(
+		member_tier_id, resource_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;`
+
+	return insertTierResourceQuery
+}
+
+func (entitlement *EntitlementDatabaseMethod) removeLapsedEntitlements() string {
+	// resources that were granted by a tier the member is no longer on
+	const removeLapsedEntitlementsQuery = `WITH removed AS (
+		DELETE FROM membership.member_resource mr
+		WHERE mr.granted_by_tier
+			AND NOT EXISTS (
+				SELECT 1
+				FROM membership.members m
+				INNER JOIN membership.tier_resources tr
+				ON tr.member_tier_id = m.member_tier_id
+				WHERE m.id = mr.member_id
+				AND tr.resource_id = mr.resource_id
+			)
+		RETURNING mr.member_id, mr.resource_id
+	), logged AS (
+		INSERT INTO membership.resource_access_log(member_id, resource_id, action, changed_by)
+		SELECT member_id, resource_id, $1, $2
+		FROM removed
+	)
+	SELECT r.member_id, m.email, r.resource_id
+	FROM removed r
+	INNER JOIN membership.members m
+	ON m.id = r.member_id;`
+
+	return removeLapsedEntitlementsQuery
+}
+
+func (entitlement *EntitlementDatabaseMethod) insertEntitlements() string {
+	// members that already have the resource keep their row as it is
+	const insertEntitlementsQuery = `WITH granted AS (
+		INSERT INTO membership.member_resource(member_id, resource_id, granted_by_tier)
+		SELECT m.id, tr.resource_id, true
+		FROM membership.members m
+		INNER JOIN membership.tier_resources tr
+		ON tr.member_tier_id = m.member_tier_id
+		WHERE m.archived_at IS NULL
+		ON CONFLICT (member_id, resource_id) DO NOTHING
+		RETURNING member_id, resource_id
+	), logged AS (
+		INSERT INTO membership.resource_access_log(member_id, resource_id, action, changed_by)
+		SELECT member_id, resource_id, $1, $2
+		FROM granted
+	)
+	SELECT g.member_id, m.email, g.resource_id
+	FROM granted g
+	INNER JOIN membership.members m
+	ON m.id = g.member_id;`
+
+	return insertEntitlementsQuery
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+var guestDbMethod GuestDatabaseMethod
+
+// GuestRemovedReason - why a guest pass was taken off of the resources
+type GuestRemovedReason string
+
+const (
+	// GuestExpired - the guest pass ran out
+	GuestExpired GuestRemovedReason = "expired"
+	// GuestRevoked - the guest pass was revoked before it ran out
+	GuestRevoked GuestRemovedReason = "revoked"
+)
+
+// Guest - a time limited pass for someone that isn't a member, i.e. an open house visitor or a visiting instructor.
+//   Guests only have access to their resources between StartsAt and EndsAt.
+type Guest struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// RFID - the UID of the guest's tag in hex
+	RFID string `json:"rfid"`
+	// HostMemberID - the member that is hosting the guest.  Empty when an admin issued the pass
+	HostMemberID *string          `json:"hostMemberID"`
+	IssuedBy     string           `json:"issuedBy"`
+	StartsAt     time.Time        `json:"startsAt"`
+	EndsAt       time.Time        `json:"endsAt"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	Resources    []MemberResource `json:"resources"`
+	// PushedAt - when the pass was sent to the resources
+	PushedAt *time.Time `json:"pushedAt"`
+	// RemovedAt - when the pass was taken off of the resources
+	RemovedAt     *time.Time         `json:"removedAt"`
+	RemovedReason GuestRemovedReason `json:"removedReason,omitempty"`
+}
+
+// GuestAccess represents that a guest has access to a certain resource until ValidUntil.
+//  this will get pushed to a device.
+type GuestAccess struct {
+	ResourceAddress string
+	ResourceName    string
+	Name            string
+	RFID            string
+	ValidUntil      time.Time
+	// Schedule - when the guest can use a resource that supports schedules.  Empty when it isn't limited
+	Schedule []ScheduleWindow
+}
+
+func scanGuest(row pgx.Row) (Guest, error) {
+	var g Guest
+	var reason string
+	var rIDs, rNames []string
+
+	err := row.Scan(&g.ID, &g.Name, &g.Email, &g.RFID, &g.HostMemberID, &g.IssuedBy, &g.StartsAt, &g.EndsAt, &g.CreatedAt, &g.PushedAt, &g.RemovedAt, &reason, &rIDs, &rNames)
+	if err != nil {
+		return g, err
+	}
+
+	g.RemovedReason = GuestRemovedReason(reason)
+
+	for i := range rIDs {
+		g.Resources = append(g.Resources, MemberResource{ResourceID: rIDs[i], Name: rNames[i]})
+	}
+
+	return g, nil
+}
+
+func (db *Database) getGuests(query string, args ...interface{}) ([]Guest, error) {
+	var guests []Guest
+
+	rows, err := db.getConn().Query(db.ctx, query, args...)
+	if err != nil {
+		return guests, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		g, err := scanGuest(rows)
+		if err != nil {
+			log.Errorf("error scanning row: %s", err)
+			continue
+		}
+
+		guests = append(guests, g)
+	}
+
+	return guests, nil
+}
+
+// GetGuests - gets every guest pass, newest first
+func (db *Database) GetGuests() ([]Guest, error) {
+	return db.getGuests(guestDbMethod.getGuests())
+}
+
+// GetGuestsByHost - gets the guest passes a member is hosting, newest first
+func (db *Database) GetGuestsByHost(memberID string) ([]Guest, error) {
+	return db.getGuests(guestDbMethod.getGuestsByHost(), memberID)
+}
+
+// GetGuestByID - lookup a guest pass by its id
+func (db *Database) GetGuestByID(guestID int64) (Guest, error) {
+	return scanGuest(db.getConn().QueryRow(db.ctx, guestDbMethod.getGuestByID(), guestID))
+}
+
+// GetGuestsToPush - gets the guest passes that have started but haven't been sent to the resources
+func (db *Database) GetGuestsToPush() ([]Guest, error) {
+	return db.getGuests(guestDbMethod.getGuestsToPush())
+}
+
+// GetExpiredGuests - gets the guest passes that have ended but are still on the resources
+func (db *Database) GetExpiredGuests() ([]Guest, error) {
+	return db.getGuests(guestDbMethod.getExpiredGuests())
+}
+
+// AddGuest - issues a guest pass for the resources.
+//   Resources that require a certification can't be given to guests
+func (db *Database) AddGuest(g Guest, resourceIDs []string) (Guest, error) {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return g, errors.New("a guest needs a name")
+	}
+
+	if !g.EndsAt.After(g.StartsAt) {
+		return g, errors.New("a guest pass must end after it starts")
+	}
+
+	if !g.EndsAt.After(time.Now()) {
+		return g, errors.New("the guest pass has already ended")
+	}
+
+	if len(resourceIDs) == 0 {
+		return g, errors.New("a guest pass needs at least one resource")
+	}
+
+	var certified []string
+	rows, err := db.getConn().Query(db.ctx, certificationDbMethod.getResourcesRequiringCertifications(), resourceIDs)
+	if err != nil {
+		return g, fmt.Errorf("error checking guest resources: %v", err)
+	}
+
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			rows.Close()
+			return g, fmt.Errorf("error checking guest resources: %v", err)
+		}
+		certified = append(certified, name)
+	}
+	rows.Close()
+
+	if len(certified) > 0 {
+		return g, fmt.Errorf("guests can't be given %s, a certification is required", strings.Join(certified, ", "))
+	}
+
+	var owner string
+	err = db.getConn().QueryRow(db.ctx, memberDbMethod.getMemberEmailByRFID(), g.RFID).Scan(&owner)
+	if err == nil {
+		return g, fmt.Errorf("the rfid belongs to %s", owner)
+	}
+
+	var overlapping int
+	err = db.getConn().QueryRow(db.ctx, guestDbMethod.countOverlappingGuests(), g.RFID, g.StartsAt, g.EndsAt).Scan(&overlapping)
+	if err != nil {
+		return g, fmt.Errorf("error checking guest rfid: %v", err)
+	}
+	if overlapping > 0 {
+		return g, errors.New("the rfid is already used by another guest at that time")
+	}
+
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return g, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	err = tx.QueryRow(db.ctx, guestDbMethod.insertGuest(), g.Name, strings.TrimSpace(g.Email), g.RFID, g.HostMemberID, g.IssuedBy, g.StartsAt, g.EndsAt).Scan(&g.ID)
+	if err != nil {
+		return g, fmt.Errorf("error adding guest: %v", err)
+	}
+
+	for _, resourceID := range resourceIDs {
+		_, err = tx.Exec(db.ctx, guestDbMethod.insertGuestResource(), g.ID, resourceID)
+		if err != nil {
+			return g, fmt.Errorf("error adding guest to resource: %v", err)
+		}
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return g, fmt.Errorf("error committing guest: %v", err)
+	}
+
+	return db.GetGuestByID(g.ID)
+}
+
+// MarkGuestPushed - records that the guest pass was sent to the resources
+func (db *Database) MarkGuestPushed(guestID int64) error {
+	_, err := db.getConn().Exec(db.ctx, guestDbMethod.markGuestPushed(), guestID)
+	if err != nil {
+		return fmt.Errorf("error marking guest pushed: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveGuest - ends a guest pass.  The guest is kept so there is a record of the pass,
+//   the caller is responsible for updating the resources.
+func (db *Database) RemoveGuest(guestID int64, reason GuestRemovedReason) (Guest, error) {
+	err := db.getConn().QueryRow(db.ctx, guestDbMethod.removeGuest(), guestID, string(reason)).Scan(&guestID)
+	if err == pgx.ErrNoRows {
+		return Guest{}, errors.New("the guest pass was already removed")
+	}
+	if err != nil {
+		return Guest{}, fmt.Errorf("error removing guest: %v", err)
+	}
+
+	return db.GetGuestByID(guestID)
+}
+
+// GetGuestAccess returns the resources a guest has access to
+//   with the guest's tag encoded in each resource's rfid format.
+//   Guests get the resource's schedules, resources that are closed are left off
+//   unless they support schedules.
+func (db *Database) GetGuestAccess(g Guest) ([]GuestAccess, error) {
+	var guestAccess []GuestAccess
+
+	schedules, err := db.GetSchedules()
+	if err != nil {
+		return guestAccess, err
+	}
+
+	now := time.Now().In(ScheduleLocation())
+
+	rows, err := db.getConn().Query(db.ctx, guestDbMethod.getGuestAccess(), g.ID)
+	if err != nil {
+		return guestAccess, fmt.Errorf("error getting guest access info: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var access GuestAccess
+		var r Resource
+		var uid string
+
+		rows.Scan(&r.ID, &access.ResourceAddress, &access.ResourceName, &r.RFIDFormat, &r.SupportsSchedules, &access.Name, &uid, &access.ValidUntil)
+
+		resourceSchedules := schedules.ForResource(r.ID)
+		if !resourceSchedules.allowed(r, 0, now) {
+			continue
+		}
+
+		access.RFID, err = renderTag(r.RFIDFormat, &uid, nil)
+		if err != nil {
+			log.Errorf("error encoding guest tag for %s: %s", access.ResourceName, err)
+			continue
+		}
+
+		if r.SupportsSchedules {
+			access.Schedule = resourceSchedules.ForLevel(0).Windows()
+		}
+
+		guestAccess = append(guestAccess, access)
+	}
+
+	return guestAccess, nil
+}
@@ -0,0 +1,112 @@
+package database
+
+// GuestDatabaseMethod -- method container that holds the extension methods to query the guests table
+type GuestDatabaseMethod struct{}
+
+func (guest *GuestDatabaseMethod) selectGuests(where string) string {
+	return `SELECT g.id, g.name, g.email, g.uid, g.host_member_id, g.issued_by, g.starts_at, g.ends_at, g.created_at,
+	g.pushed_at, g.removed_at, COALESCE(g.removed_reason, ''),
+	ARRAY(
+	SELECT r.id::text
+	FROM membership.guest_resource gr
+	INNER JOIN membership.resources r
+	ON r.id = gr.resource_id
+	WHERE gr.guest_id = g.id
+	ORDER BY r.description, r.id
+	) as resource_ids,
+	ARRAY(
+	SELECT r.description
+	FROM membership.guest_resource gr
+	INNER JOIN membership.resources r
+	ON r.id = gr.resource_id
+	WHERE gr.guest_id = g.id
+	ORDER BY r.description, r.id
+	) as resource_names
+	FROM membership.guests g
+	WHERE ` + where + `
+	ORDER BY g.starts_at DESC, g.id DESC;`
+}
+
+func (guest *GuestDatabaseMethod) getGuests() string {
+	return guest.selectGuests("TRUE")
+}
+
+func (guest *GuestDatabaseMethod) getGuestsByHost() string {
+	return guest.selectGuests("g.host_member_id = $1")
+}
+
+func (guest *GuestDatabaseMethod) getGuestByID() string {
+	return guest.selectGuests("g.id = $1")
+}
+
+func (guest *GuestDatabaseMethod) getGuestsToPush() string {
+	return guest.selectGuests(`g.removed_at IS NULL
+	AND g.pushed_at IS NULL
+	AND g.starts_at <= NOW()
+	AND g.ends_at > NOW()`)
+}
+
+func (guest *GuestDatabaseMethod) getExpiredGuests() string {
+	return guest.selectGuests(`g.removed_at IS NULL
+	AND g.ends_at <= NOW()`)
+}
+
+func (guest *GuestDatabaseMethod) countOverlappingGuests() string {
+	const countOverlappingGuestsQuery = `SELECT COUNT(*)
+	FROM membership.guests
+	WHERE uid = $1
+	AND removed_at IS NULL
+	AND starts_at < $3
+	AND ends_at > $2;`
+
+	return countOverlappingGuestsQuery
+}
+
+func (guest *GuestDatabaseMethod) insertGuest() string {
+	const insertGuestQuery = `INSERT INTO membership.guests(
+		name, email, uid, host_member_id, issued_by, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id;`
+
+	return insertGuestQuery
+}
+
+func (guest *GuestDatabaseMethod) insertGuestResource() string {
+	const insertGuestResourceQuery = `INSERT INTO membership.guest_resource(
+		guest_id, resource_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING;`
+
+	return insertGuestResourceQuery
+}
+
+func (guest *GuestDatabaseMethod) markGuestPushed() string {
+	const markGuestPushedQuery = `UPDATE membership.guests
+	SET pushed_at = NOW()
+	WHERE id = $1;`
+
+	return markGuestPushedQuery
+}
+
+func (guest *GuestDatabaseMethod) removeGuest() string {
+	const removeGuestQuery = `UPDATE membership.guests
+	SET removed_at = NOW(), removed_reason = $2
+	WHERE id = $1
+	AND removed_at IS NULL
+	RETURNING id;`
+
+	return removeGuestQuery
+}
+
+func (guest *GuestDatabaseMethod) getGuestAccess() string {
+	const getGuestAccessQuery = `SELECT r.id, r.device_identifier, r.description, r.rfid_format, r.supports_schedules, g.name, g.uid, g.ends_at
+	FROM membership.guest_resource gr
+	INNER JOIN membership.guests g
+	ON g.id = gr.guest_id
+	INNER JOIN membership.resources r
+	ON r.id = gr.resource_id
+	WHERE g.id = $1
+	AND ` + guestResourceWithoutCertifications + `;`
+
+	return getGuestAccessQuery
+}
@@ -2,8 +2,11 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"memberserver/rfid"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	log "github.com/sirupsen/logrus"
@@ -27,6 +30,13 @@ type Member struct {
 	Resources []MemberResource `json:"resources"`
 }
 
+// ArchivedMember -- a member that has been archived
+//   archived members are hidden from the member list and device ACLs
+type ArchivedMember struct {
+	Member
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
 // AssignRFIDRequest -- request to associate an rfid to a member
 type AssignRFIDRequest struct {
 	Email string `json:"email"`
@@ -83,6 +93,170 @@ func (db *Database) GetMembers() []Member {
 	return members
 }
 
+// MemberListFilter - options for filtering, sorting and paging the member list
+type MemberListFilter struct {
+	// Page - 1 based page number
+	Page int
+	// Limit - number of members on a page.  0 returns every member
+	Limit int
+	// Level - only include members of this tier
+	Level uint8
+	// Resource - only include members that have access to the resource with this ID or name
+	Resource string
+	// HasRFID - only include members with or without an rfid tag
+	HasRFID *bool
+	// PastDue - only include active members that are past their paid through date
+	PastDue bool
+	// Search - matches part of a member's name or email
+	Search string
+	// Sort - one of MemberListSortOptions
+	Sort string
+	// Order - asc or desc
+	Order string
+}
+
+// MemberList - a page of members along with how they were found
+type MemberList struct {
+	Members     []Member `json:"members"`
+	Total       int      `json:"total"`
+	Page        int      `json:"page"`
+	Limit       int      `json:"limit"`
+	Sort        string   `json:"sort"`
+	Order       string   `json:"order"`
+	SortOptions []string `json:"sortOptions"`
+}
+
+// MemberListSortOptions are the fields that the member list can be sorted by
+var MemberListSortOptions = []string{"name", "email", "memberLevel"}
+
+var memberListSortColumns = map[string]string{
+	"name":        "m.name",
+	"email":       "m.email",
+	"memberLevel": "m.member_tier_id",
+}
+
+// GetMemberList - gets a filtered and sorted page of members
+func (db *Database) GetMemberList(filter MemberListFilter) (MemberList, error) {
+	list := MemberList{
+		Members:     []Member{},
+		Page:        filter.Page,
+		Limit:       filter.Limit,
+		Sort:        filter.Sort,
+		Order:       strings.ToLower(filter.Order),
+		SortOptions: MemberListSortOptions,
+	}
+
+	if list.Page < 1 {
+		list.Page = 1
+	}
+
+	if list.Limit < 0 {
+		return list, errors.New("limit must not be negative")
+	}
+
+	if list.Sort == "" {
+		list.Sort = "name"
+	}
+
+	sortColumn, ok := memberListSortColumns[list.Sort]
+	if !ok {
+		return list, fmt.Errorf("not a valid sort option: %s", list.Sort)
+	}
+
+	if list.Order == "" {
+		list.Order = "asc"
+	}
+
+	if list.Order != "asc" && list.Order != "desc" {
+		return list, fmt.Errorf("not a valid sort order: %s", list.Order)
+	}
+
+	conditions := []string{"m.archived_at IS NULL"}
+	var args []interface{}
+
+	addArg := func(arg interface{}) string {
+		args = append(args, arg)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Level != 0 {
+		conditions = append(conditions, "m.member_tier_id = "+addArg(filter.Level))
+	}
+
+	if filter.Resource != "" {
+		conditions = append(conditions, `EXISTS (
+		SELECT 1
+		FROM membership.member_resource mr
+		INNER JOIN membership.resources r
+		ON r.id = mr.resource_id
+		WHERE mr.member_id = m.id
+		AND (r.id::text = `+addArg(filter.Resource)+` OR r.description = `+addArg(filter.Resource)+`))`)
+	}
+
+	if filter.HasRFID != nil {
+		hasRFID := `EXISTS (
+		SELECT 1
+		FROM membership.member_credentials c
+		WHERE c.member_id = m.id
+		AND c.status = 'active')`
+
+		if *filter.HasRFID {
+			conditions = append(conditions, hasRFID)
+		} else {
+			conditions = append(conditions, "NOT "+hasRFID)
+		}
+	}
+
+	if filter.PastDue {
+		conditions = append(conditions, `m.member_tier_id NOT IN (`+addArg(Inactive)+`, `+addArg(Credited)+`)
+		AND COALESCE(m.paid_through, '0001-01-01') < current_date`)
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := addArg("%" + search + "%")
+		conditions = append(conditions, "(m.name ILIKE "+pattern+" OR m.email ILIKE "+pattern+")")
+	}
+
+	where := strings.Join(conditions, "\n\tAND ")
+
+	err := db.getConn().QueryRow(db.ctx, memberDbMethod.countMemberList(where), args...).Scan(&list.Total)
+	if err != nil {
+		return list, fmt.Errorf("error counting members: %v", err)
+	}
+
+	limit := ""
+	if list.Limit > 0 {
+		limit = "LIMIT " + addArg(list.Limit) + " OFFSET " + addArg((list.Page-1)*list.Limit)
+	}
+
+	rows, err := db.getConn().Query(db.ctx, memberDbMethod.getMemberList(where, sortColumn+" "+list.Order, limit), args...)
+	if err != nil {
+		return list, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var m Member
+		var rIDs []string
+		var rNames []string
+
+		err = rows.Scan(&m.ID, &m.Name, &m.Email, &m.RFID, &m.Level, &rIDs, &rNames)
+		if err != nil {
+			log.Errorf("error scanning row: %s", err)
+			continue
+		}
+
+		for i := range rIDs {
+			m.Resources = append(m.Resources, MemberResource{ResourceID: rIDs[i], Name: rNames[i]})
+		}
+
+		list.Members = append(list.Members, m)
+	}
+
+	return list, nil
+}
+
 // GetMembersWithCredit - gets members that have been credited a membership
 //  if a member exists in the member_credits table
 //  they are credited a membership
@@ -111,10 +285,14 @@ func (db *Database) GetMembersWithCredit() []Member {
 
 // GetMemberByEmail - lookup a member by their email address
 func (db *Database) GetMemberByEmail(memberEmail string) (Member, error) {
+	return db.getMemberByEmail(db.getConn(), memberEmail)
+}
+
+func (db *Database) getMemberByEmail(q querier, memberEmail string) (Member, error) {
 	var m Member
 	var rIDs []string
 
-	err := db.getConn().QueryRow(context.Background(), memberDbMethod.getMemberByEmail(), memberEmail).Scan(&m.ID, &m.Name, &m.Email, &m.RFID, &m.Level, &rIDs)
+	err := q.QueryRow(context.Background(), memberDbMethod.getMemberByEmail(), memberEmail).Scan(&m.ID, &m.Name, &m.Email, &m.RFID, &m.Level, &rIDs)
 	if err == pgx.ErrNoRows {
 		return m, err
 	}
@@ -148,6 +326,33 @@ func (db *Database) GetMemberByEmail(memberEmail string) (Member, error) {
 	return m, err
 }
 
+// GetActiveMemberByEmail - lookup a member that isn't archived by their email address.
+//   The logged in member is looked up this way so archived members lose the self service endpoints
+//   even when they still have a token
+func (db *Database) GetActiveMemberByEmail(memberEmail string) (Member, error) {
+	archived, err := db.IsArchivedMember(memberEmail)
+	if err != nil {
+		return Member{}, err
+	}
+	if archived {
+		return Member{}, errors.New("member is archived")
+	}
+
+	return db.GetMemberByEmail(memberEmail)
+}
+
+// IsArchivedMember - whether the email belongs to an archived member
+func (db *Database) IsArchivedMember(memberEmail string) (bool, error) {
+	var archived bool
+
+	err := db.getConn().QueryRow(db.ctx, memberDbMethod.isArchivedMember(), memberEmail).Scan(&archived)
+	if err != nil {
+		return archived, fmt.Errorf("error checking if member is archived: %v", err)
+	}
+
+	return archived, nil
+}
+
 // GetMemberByID - lookup a member by their memberID
 func (db *Database) GetMemberByID(memberID string) (Member, error) {
 	var m Member
@@ -183,24 +388,160 @@ func (db *Database) GetMemberByID(memberID string) (Member, error) {
 	return m, err
 }
 
-// SetRFIDTag sets the rfid tag as
+// SetRFIDTag issues the rfid tag to the member as a new credential.
+//   The member's other credentials are left active.
 func (db *Database) SetRFIDTag(email string, RFIDTag string) (Member, error) {
-	m, err := db.GetMemberByEmail(email)
+	return db.setRFIDTag(db.getConn(), email, RFIDTag)
+}
+
+func (db *Database) setRFIDTag(q querier, email string, RFIDTag string) (Member, error) {
+	m, err := db.getMemberByEmail(q, email)
 	if err != nil {
 		log.Errorf("error retrieving a member with that email address %s", err.Error())
 		return m, err
 	}
 
-	err = db.getConn().QueryRow(context.Background(), memberDbMethod.setMemberRFIDTag(), email, encodeRFID(RFIDTag)).Scan(&m.RFID)
+	uid, err := rfid.Decode(rfid.FormatDecimal, RFIDTag)
 	if err != nil {
-		return m, fmt.Errorf("conn.Query failed: %v", err)
+		return m, err
+	}
+
+	// the member already having the tag is fine
+	_, err = db.addCredential(q, m.ID, uid, "")
+	if err != nil && err != pgx.ErrNoRows {
+		return m, err
 	}
 
+	m.RFID, err = db.refreshMemberRFID(q, m.ID)
+
 	return m, err
 }
 
+// UpdateMember updates a member's name, email and tier.  Empty values are left unchanged.
+//   If the email changes, the member's login is updated in the same transaction
+//   so that they are still able to sign in.  A tier change is only recorded when the whole update succeeds.
+func (db *Database) UpdateMember(memberID string, name string, email string, level MemberLevel) (Member, error) {
+	var m Member
+
+	if memberID == "" {
+		return m, errors.New("invalid memberID")
+	}
+
+	if level == Credited {
+		credited, err := db.hasActiveCredit(memberID)
+		if err != nil {
+			return m, err
+		}
+		if !credited {
+			return m, ErrCreditedWithoutCredit
+		}
+	}
+
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return m, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	var previousEmail string
+	err = tx.QueryRow(db.ctx, memberDbMethod.getMemberEmailForUpdate(), memberID).Scan(&previousEmail)
+	if err != nil {
+		return m, fmt.Errorf("error getting member to update: %w", err)
+	}
+
+	var updatedEmail string
+	err = tx.QueryRow(db.ctx, memberDbMethod.updateMember(), memberID, strings.TrimSpace(name), strings.TrimSpace(email)).Scan(&updatedEmail)
+	if err != nil {
+		return m, fmt.Errorf("error updating member: %v", err)
+	}
+
+	if previousEmail != updatedEmail {
+		_, err = tx.Exec(db.ctx, userDbMethod.updateUserEmail(), previousEmail, updatedEmail)
+		if err != nil {
+			return m, fmt.Errorf("error updating user email: %v", err)
+		}
+	}
+
+	if level != 0 {
+		err = db.setMemberLevel(tx, memberID, level, TierChangeManual)
+		if err != nil {
+			return m, fmt.Errorf("error updating member level: %v", err)
+		}
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return m, fmt.Errorf("error committing member update: %v", err)
+	}
+
+	return db.GetMemberByID(memberID)
+}
+
+// GetArchivedMembers - gets the members that have been archived
+func (db *Database) GetArchivedMembers() []ArchivedMember {
+	rows, err := db.getConn().Query(db.ctx, memberDbMethod.getArchivedMembers())
+	if err != nil {
+		log.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	var members []ArchivedMember
+
+	for rows.Next() {
+		var m ArchivedMember
+		err = rows.Scan(&m.ID, &m.Name, &m.Email, &m.RFID, &m.Level, &m.ArchivedAt)
+		if err != nil {
+			log.Errorf("error scanning row: %s", err)
+		}
+
+		members = append(members, m)
+	}
+
+	return members
+}
+
+// ArchiveMember - hides a member from the member list and from device ACLs.
+//   Their payments, resources and communication history are kept so that
+//   they can be restored later.
+func (db *Database) ArchiveMember(memberID string) (Member, error) {
+	m, err := db.GetMemberByID(memberID)
+	if err != nil {
+		return m, err
+	}
+
+	err = db.getConn().QueryRow(db.ctx, memberDbMethod.archiveMember(), memberID).Scan(&m.ID)
+	if err == pgx.ErrNoRows {
+		return m, errors.New("member is already archived")
+	}
+	if err != nil {
+		return m, fmt.Errorf("error archiving member: %v", err)
+	}
+
+	return m, nil
+}
+
+// RestoreMember - restores an archived member
+func (db *Database) RestoreMember(memberID string) (Member, error) {
+	var m Member
+
+	err := db.getConn().QueryRow(db.ctx, memberDbMethod.restoreMember(), memberID).Scan(&m.ID)
+	if err == pgx.ErrNoRows {
+		return m, errors.New("member is not archived")
+	}
+	if err != nil {
+		return m, fmt.Errorf("error restoring member: %v", err)
+	}
+
+	return db.GetMemberByID(memberID)
+}
+
 // AddMembers adds multiple members to the database
 func (db *Database) AddMembers(members []Member) error {
+	return db.addMembers(db.getConn(), members)
+}
+
+func (db *Database) addMembers(q querier, members []Member) error {
 	sqlStr := `INSERT INTO membership.members(
 name, email, member_tier_id)
 VALUES `
@@ -216,18 +557,20 @@ VALUES `
 			m.Level = uint8(Inactive)
 		}
 
-		valStr = append(valStr, fmt.Sprintf("('%s', '%s', %d)", memberName, m.Email, m.Level))
+		memberEmail := strings.Replace(m.Email, "'", "''", -1)
+
+		valStr = append(valStr, fmt.Sprintf("('%s', '%s', %d)", memberName, memberEmail, m.Level))
 	}
 
 	str := strings.Join(valStr, ",")
 
-	_, err := db.getConn().Exec(context.Background(), sqlStr+str+" ON CONFLICT DO NOTHING;")
+	_, err := q.Exec(context.Background(), sqlStr+str+" ON CONFLICT DO NOTHING;")
 	if err != nil {
 		return fmt.Errorf("add members query failed: %v", err)
 	}
 	for _, m := range members {
 		log.Println("Adding default resource")
-		db.AddUserToDefaultResources(m.Email)
+		db.addUserToDefaultResources(q, m.Email)
 	}
 
 	return err
@@ -13,6 +13,7 @@ func (member *MemberDatabaseMethod) getMember() string {
 	WHERE member_id = membership.members.id
 	) as resources
 	FROM membership.members
+	WHERE archived_at IS NULL
 	ORDER BY name;
 	`
 
@@ -22,8 +23,13 @@ func (member *MemberDatabaseMethod) getMember() string {
 func (member *MemberDatabaseMethod) getMembersWithCredit() string {
 	const getMembersWithCreditQuery = `SELECT id, name, email, COALESCE(rfid,'notset'), member_tier_id
 	FROM membership.members
-	RIGHT JOIN membership.member_credit
-	ON membership.member_credit.member_id = id
+	WHERE archived_at IS NULL
+	AND EXISTS (
+		SELECT 1
+		FROM membership.member_credit
+		WHERE membership.member_credit.member_id = membership.members.id
+		AND (expires_at IS NULL OR expires_at > NOW())
+	)
 	ORDER BY name;
 	`
 
@@ -60,15 +66,6 @@ func (member *MemberDatabaseMethod) getMemberByID() string {
 	return getMemberByIDQuery
 }
 
-func (member *MemberDatabaseMethod) setMemberRFIDTag() string {
-	const setMemberRFIDTagQuery = `UPDATE membership.members
-	SET rfid=$2
-	WHERE email=$1
-	RETURNING rfid;`
-
-	return setMemberRFIDTagQuery
-}
-
 func (member *MemberDatabaseMethod) insertMember() string {
 	const insertMemberQuery = `INSERT INTO membership.members(
 		name, email, rfid, member_tier_id)
@@ -77,3 +74,102 @@ func (member *MemberDatabaseMethod) insertMember() string {
 
 	return insertMemberQuery
 }
+
+func (member *MemberDatabaseMethod) getMemberEmailForUpdate() string {
+	const getMemberEmailForUpdateQuery = `SELECT email
+	FROM membership.members
+	WHERE id = $1
+	FOR UPDATE;`
+
+	return getMemberEmailForUpdateQuery
+}
+
+func (member *MemberDatabaseMethod) updateMember() string {
+	const updateMemberQuery = `UPDATE membership.members
+	SET name = COALESCE(NULLIF($2, ''), name),
+		email = COALESCE(NULLIF($3, ''), email)
+	WHERE id = $1
+	RETURNING email;`
+
+	return updateMemberQuery
+}
+
+func (member *MemberDatabaseMethod) getArchivedMembers() string {
+	const getArchivedMembersQuery = `SELECT id, name, email, COALESCE(rfid,'notset'), member_tier_id, archived_at
+	FROM membership.members
+	WHERE archived_at IS NOT NULL
+	ORDER BY archived_at DESC;`
+
+	return getArchivedMembersQuery
+}
+
+func (member *MemberDatabaseMethod) isArchivedMember() string {
+	const isArchivedMemberQuery = `SELECT EXISTS (
+		SELECT 1
+		FROM membership.members
+		WHERE lower(email) = lower($1)
+		AND archived_at IS NOT NULL
+	);`
+
+	return isArchivedMemberQuery
+}
+
+func (member *MemberDatabaseMethod) archiveMember() string {
+	const archiveMemberQuery = `UPDATE membership.members
+	SET archived_at = NOW()
+	WHERE id = $1
+	AND archived_at IS NULL
+	RETURNING id;`
+
+	return archiveMemberQuery
+}
+
+func (member *MemberDatabaseMethod) restoreMember() string {
+	const restoreMemberQuery = `UPDATE membership.members
+	SET archived_at = NULL
+	WHERE id = $1
+	AND archived_at IS NOT NULL
+	RETURNING id;`
+
+	return restoreMemberQuery
+}
+
+func (member *MemberDatabaseMethod) getMemberList(where string, orderBy string, limit string) string {
+	return `SELECT m.id, m.name, m.email, COALESCE(m.rfid,'notset'), m.member_tier_id,
+	ARRAY(
+	SELECT r.id::text
+	FROM membership.member_resource mr
+	INNER JOIN membership.resources r
+	ON r.id = mr.resource_id
+	WHERE mr.member_id = m.id
+	ORDER BY r.description, r.id
+	) as resource_ids,
+	ARRAY(
+	SELECT r.description
+	FROM membership.member_resource mr
+	INNER JOIN membership.resources r
+	ON r.id = mr.resource_id
+	WHERE mr.member_id = m.id
+	ORDER BY r.description, r.id
+	) as resource_names
+	FROM membership.members m
+	WHERE ` + where + `
+	ORDER BY ` + orderBy + `, m.id
+	` + limit + `;`
+}
+
+func (member *MemberDatabaseMethod) countMemberList(where string) string {
+	return `SELECT COUNT(*)
+	FROM membership.members m
+	WHERE ` + where + `;`
+}
+
+func (member *MemberDatabaseMethod) getMemberEmailByRFID() string {
+	const getMemberEmailByRFIDQuery = `SELECT m.email
+	FROM membership.member_credentials c
+	INNER JOIN membership.members m
+	ON c.member_id = m.id
+	WHERE c.uid = $1 AND c.status = 'active';`
+
+	return getMemberEmailByRFIDQuery
+}
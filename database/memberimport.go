@@ -0,0 +1,386 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"memberserver/rfid"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// MemberImportStatus - what will happen to a row of a member import
+type MemberImportStatus string
+
+const (
+	// ImportNew - the member doesn't exist yet and will be added
+	ImportNew MemberImportStatus = "new"
+	// ImportUpdated - the member exists and some of their information will change
+	ImportUpdated MemberImportStatus = "updated"
+	// ImportUnchanged - the member exists and already matches the row
+	ImportUnchanged MemberImportStatus = "unchanged"
+	// ImportConflictingEmail - the email shows up on more than one row
+	ImportConflictingEmail MemberImportStatus = "conflictingEmail"
+	// ImportDuplicateRFID - the rfid shows up on more than one row or belongs to another member
+	ImportDuplicateRFID MemberImportStatus = "duplicateRFID"
+	// ImportInvalid - the row couldn't be validated
+	ImportInvalid MemberImportStatus = "invalid"
+)
+
+// MemberImportRow - a member to import along with what the import will do with it
+type MemberImportRow struct {
+	// Row - the row number in the uploaded file.  The header is row 1
+	Row       int                `json:"row"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email"`
+	Tier      string             `json:"tier"`
+	RFID      string             `json:"rfid"`
+	Resources []string           `json:"resources"`
+	Status    MemberImportStatus `json:"status"`
+	// Changes - a description of each change that will be made to an existing member
+	Changes []string `json:"changes,omitempty"`
+	// Errors - why the row can't be imported
+	Errors []string `json:"errors,omitempty"`
+
+	memberID     string
+	level        MemberLevel
+	uid          string
+	updateName   bool
+	updateLevel  bool
+	updateRFID   bool
+	newResources []Resource
+}
+
+// MemberImportResult - the diff of a member import
+//   if Applied is false, nothing was written to the db
+type MemberImportResult struct {
+	Applied           bool              `json:"applied"`
+	New               []MemberImportRow `json:"new"`
+	Updated           []MemberImportRow `json:"updated"`
+	Unchanged         []MemberImportRow `json:"unchanged"`
+	ConflictingEmails []MemberImportRow `json:"conflictingEmails"`
+	DuplicateRFIDs    []MemberImportRow `json:"duplicateRFIDs"`
+	Invalid           []MemberImportRow `json:"invalid"`
+}
+
+// HasProblems - true if any of the rows would keep the import from being applied
+func (r MemberImportResult) HasProblems() bool {
+	return len(r.ConflictingEmails) > 0 || len(r.DuplicateRFIDs) > 0 || len(r.Invalid) > 0
+}
+
+// Emails - the emails of the members that are added or updated by the import
+func (r MemberImportResult) Emails() []string {
+	var emails []string
+	for _, row := range append(append([]MemberImportRow{}, r.New...), r.Updated...) {
+		emails = append(emails, row.Email)
+	}
+	return emails
+}
+
+// ErrMemberImportHasProblems - the import can't be applied until the problem rows are fixed
+var ErrMemberImportHasProblems = errors.New("member import has rows with problems")
+
+// ImportMembers - compares the rows with the members in the db and returns the diff.
+//   If apply is true and none of the rows have problems, the import is applied
+//   in a single transaction.  Resource grants are logged as made by importedBy.
+func (db *Database) ImportMembers(rows []MemberImportRow, apply bool, importedBy string) (MemberImportResult, error) {
+	result := db.planMemberImport(rows)
+
+	if !apply {
+		return result, nil
+	}
+
+	if result.HasProblems() {
+		return result, ErrMemberImportHasProblems
+	}
+
+	err := db.applyMemberImport(result, importedBy)
+	if err != nil {
+		return result, err
+	}
+
+	result.Applied = true
+
+	return result, nil
+}
+
+func parseMemberLevel(tiers Tiers, tier string) (MemberLevel, error) {
+	if level, err := strconv.Atoi(tier); err == nil {
+		if _, ok := tiers.ByID(MemberLevel(level)); ok {
+			return MemberLevel(level), nil
+		}
+	}
+
+	if t, ok := tiers.ByName(tier); ok {
+		return MemberLevel(t.ID), nil
+	}
+
+	return 0, fmt.Errorf("unknown tier: %s", tier)
+}
+
+func (db *Database) planMemberImport(rows []MemberImportRow) MemberImportResult {
+	var result MemberImportResult
+
+	tiers := db.GetMemberTiers()
+	emailCount := make(map[string]int)
+	rfidCount := make(map[string]int)
+
+	// validate each row on its own first
+	for i := range rows {
+		row := &rows[i]
+
+		row.Name = strings.TrimSpace(row.Name)
+		row.Email = strings.TrimSpace(row.Email)
+		row.Tier = strings.TrimSpace(row.Tier)
+		row.RFID = strings.TrimSpace(row.RFID)
+
+		if row.Email == "" || !strings.Contains(row.Email, "@") {
+			row.Errors = append(row.Errors, "not a valid email")
+		}
+
+		if row.Tier != "" {
+			level, err := parseMemberLevel(tiers, row.Tier)
+			if err != nil {
+				row.Errors = append(row.Errors, err.Error())
+			}
+			row.level = level
+		}
+
+		if row.RFID != "" {
+			uid, err := rfid.Decode(rfid.FormatDecimal, row.RFID)
+			if err != nil {
+				row.Errors = append(row.Errors, "not a valid rfid")
+			} else {
+				row.uid = uid.String()
+				rfidCount[row.uid]++
+			}
+		}
+
+		emailCount[strings.ToLower(row.Email)]++
+	}
+
+	for i := range rows {
+		row := rows[i]
+
+		if len(row.Errors) > 0 {
+			row.Status = ImportInvalid
+			result.Invalid = append(result.Invalid, row)
+			continue
+		}
+
+		if emailCount[strings.ToLower(row.Email)] > 1 {
+			row.Status = ImportConflictingEmail
+			row.Errors = append(row.Errors, "email is on more than one row")
+			result.ConflictingEmails = append(result.ConflictingEmails, row)
+			continue
+		}
+
+		if row.uid != "" && rfidCount[row.uid] > 1 {
+			row.Status = ImportDuplicateRFID
+			row.Errors = append(row.Errors, "rfid is on more than one row")
+			result.DuplicateRFIDs = append(result.DuplicateRFIDs, row)
+			continue
+		}
+
+		hasRFID := false
+		if row.uid != "" {
+			var owner string
+			err := db.getConn().QueryRow(db.ctx, memberDbMethod.getMemberEmailByRFID(), row.uid).Scan(&owner)
+			hasRFID = err == nil && strings.EqualFold(owner, row.Email)
+			if err == nil && !hasRFID {
+				row.Status = ImportDuplicateRFID
+				row.Errors = append(row.Errors, "rfid belongs to "+owner)
+				result.DuplicateRFIDs = append(result.DuplicateRFIDs, row)
+				continue
+			}
+		}
+
+		member, err := db.GetMemberByEmail(row.Email)
+		exists := err == nil
+		if err != nil && err != pgx.ErrNoRows {
+			row.Status = ImportInvalid
+			row.Errors = append(row.Errors, "unable to lookup member")
+			result.Invalid = append(result.Invalid, row)
+			continue
+		}
+
+		if !exists && row.Name == "" {
+			row.Status = ImportInvalid
+			row.Errors = append(row.Errors, "a name is required for new members")
+			result.Invalid = append(result.Invalid, row)
+			continue
+		}
+
+		if row.level == Credited && (!exists || member.Level != uint8(Credited)) {
+			credited := false
+			if exists {
+				credited, err = db.hasActiveCredit(member.ID)
+			}
+			if err != nil || !credited {
+				row.Status = ImportInvalid
+				row.Errors = append(row.Errors, ErrCreditedWithoutCredit.Error())
+				result.Invalid = append(result.Invalid, row)
+				continue
+			}
+		}
+
+		granted := make(map[string]bool)
+		for _, r := range member.Resources {
+			granted[r.ResourceID] = true
+		}
+
+		for _, name := range row.Resources {
+			resource, err := db.GetResourceByName(name)
+			if err != nil {
+				row.Errors = append(row.Errors, "unknown resource: "+name)
+				continue
+			}
+
+			// new members get the default resources when they are added
+			if granted[resource.ID] || (!exists && resource.IsDefault) {
+				continue
+			}
+
+			// new members can't have been trained yet
+			var missing []Certification
+			if exists {
+				missing, err = db.GetMissingCertifications(member.ID, resource.ID)
+			} else {
+				missing, err = db.GetResourceCertifications(resource.ID)
+			}
+			if err != nil {
+				row.Errors = append(row.Errors, "unable to lookup certifications for "+name)
+				continue
+			}
+			if len(missing) > 0 {
+				row.Errors = append(row.Errors, MissingCertificationsError{Email: row.Email, Resource: name, Missing: missing}.Error())
+				continue
+			}
+
+			granted[resource.ID] = true
+			row.newResources = append(row.newResources, resource)
+		}
+
+		if len(row.Errors) > 0 {
+			row.Status = ImportInvalid
+			result.Invalid = append(result.Invalid, row)
+			continue
+		}
+
+		if !exists {
+			row.Status = ImportNew
+			result.New = append(result.New, row)
+			continue
+		}
+
+		row.memberID = member.ID
+
+		if row.Name != "" && row.Name != member.Name {
+			row.updateName = true
+			row.Changes = append(row.Changes, fmt.Sprintf("name: %s -> %s", member.Name, row.Name))
+		}
+
+		if row.level != 0 && uint8(row.level) != member.Level {
+			row.updateLevel = true
+			row.Changes = append(row.Changes, fmt.Sprintf("tier: %s -> %s", tiers.Name(MemberLevel(member.Level)), tiers.Name(row.level)))
+		}
+
+		// the rfid is added as another credential, the member's other tags are kept
+		if row.uid != "" && !hasRFID {
+			row.updateRFID = true
+			row.Changes = append(row.Changes, "rfid: "+row.uid)
+		}
+
+		for _, r := range row.newResources {
+			row.Changes = append(row.Changes, "resource: "+r.Name)
+		}
+
+		if len(row.Changes) == 0 {
+			row.Status = ImportUnchanged
+			result.Unchanged = append(result.Unchanged, row)
+			continue
+		}
+
+		row.Status = ImportUpdated
+		result.Updated = append(result.Updated, row)
+	}
+
+	return result
+}
+
+func (db *Database) applyMemberImport(result MemberImportResult, importedBy string) error {
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	var newMembers []Member
+	for _, row := range result.New {
+		newMembers = append(newMembers, Member{
+			Name:  row.Name,
+			Email: row.Email,
+			Level: uint8(row.level),
+		})
+	}
+
+	if len(newMembers) > 0 {
+		err = db.addMembers(tx, newMembers)
+		if err != nil {
+			return err
+		}
+	}
+
+	resourceEmails := make(map[string][]string)
+
+	for _, row := range append(append([]MemberImportRow{}, result.New...), result.Updated...) {
+		if row.updateName {
+			var email string
+			err = tx.QueryRow(db.ctx, memberDbMethod.updateMember(), row.memberID, row.Name, "").Scan(&email)
+			if err != nil {
+				return fmt.Errorf("error updating member %s: %v", row.Email, err)
+			}
+		}
+
+		if row.updateLevel {
+			err = db.setMemberLevel(tx, row.memberID, row.level, TierChangeManual)
+			if err != nil {
+				return fmt.Errorf("error updating tier of %s: %v", row.Email, err)
+			}
+		}
+
+		if row.uid != "" && (row.Status == ImportNew || row.updateRFID) {
+			_, err = db.setRFIDTag(tx, row.Email, row.RFID)
+			if err != nil {
+				return fmt.Errorf("error setting rfid of %s: %v", row.Email, err)
+			}
+		}
+
+		for _, r := range row.newResources {
+			resourceEmails[r.ID] = append(resourceEmails[r.ID], row.Email)
+		}
+	}
+
+	// sort the resources so the import is applied in the same order every time
+	var resourceIDs []string
+	for id := range resourceEmails {
+		resourceIDs = append(resourceIDs, id)
+	}
+	sort.Strings(resourceIDs)
+
+	for _, id := range resourceIDs {
+		_, err = db.addMultipleMembersToResource(tx, resourceEmails[id], id, importedBy)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error committing member import: %v", err)
+	}
+
+	return nil
+}
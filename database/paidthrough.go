@@ -0,0 +1,296 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	log "github.com/sirupsen/logrus"
+)
+
+var paidThroughDbMethod PaidThroughDatabaseMethod
+
+// LedgerEntry - how far a payment extended a member's paid through date
+type LedgerEntry struct {
+	PaymentID   string      `json:"paymentID"`
+	PaymentDate time.Time   `json:"paymentDate"`
+	Amount      money.Money `json:"amount"`
+	// Level - the tier the payment was counted toward.  0 when it didn't match a tier
+	Level       MemberLevel `json:"memberLevel"`
+	Tier        string      `json:"tier"`
+	PaidFrom    time.Time   `json:"paidFrom"`
+	PaidThrough time.Time   `json:"paidThrough"`
+}
+
+// ForPayment - the tier a payment is counted toward.
+//   A payment within the tolerance of the price of an active tier pays for that tier.
+//   Otherwise it pays toward the member's current tier, so $70 on a $35 tier is two months.
+//   Members without a paid tier get the most expensive active tier the amount covers,
+//   or the cheapest active tier when the amount doesn't cover any of them.
+//   Only tiers in the currency of the payment are considered.
+//   Inactive and Credited are never paid for.
+func (t Tiers) ForPayment(current MemberLevel, amount money.Money, tolerance int64) (Tier, bool) {
+	if !amount.IsPositive() {
+		return Tier{}, false
+	}
+
+	var paid Tiers
+
+	for _, tier := range t {
+		if !MemberLevel(tier.ID).IsProtected() && tier.Price > 0 && strings.EqualFold(tier.Currency, amount.Currency().Code) {
+			paid = append(paid, tier)
+		}
+	}
+
+	if tier, ok := paid.ByPrice(amount, tolerance); ok {
+		return tier, true
+	}
+
+	if tier, ok := paid.ByID(current); ok {
+		return tier, true
+	}
+
+	var covered, cheapest Tier
+
+	for _, tier := range paid {
+		if !tier.Active {
+			continue
+		}
+
+		if tier.Price <= amount.Amount()+tolerance && tier.Price > covered.Price {
+			covered = tier
+		}
+
+		if cheapest.ID == 0 || tier.Price < cheapest.Price {
+			cheapest = tier
+		}
+	}
+
+	if covered.ID != 0 {
+		return covered, true
+	}
+
+	return cheapest, cheapest.ID != 0
+}
+
+// addBillingPeriods - moves from forward by a number of the tier's billing periods
+func (t Tier) addBillingPeriods(from time.Time, periods int) time.Time {
+	if t.BillingPeriod == BillingYearly {
+		return from.AddDate(periods, 0, 0)
+	}
+
+	return from.AddDate(0, periods, 0)
+}
+
+// PaidThrough - the date a payment for the tier pays through.
+//   The payment starts at the later of when it was paid and the current paid through date,
+//   so paying early stacks on top of the time that is already paid for.
+//   Each multiple of the price pays for a billing period and what is left over
+//   is pro-rated over the days of the next billing period.
+//   A leftover within the tolerance of the price still pays for the whole period.
+func (t Tier) PaidThrough(paidThrough *time.Time, paidOn time.Time, amount int64, tolerance int64) (time.Time, time.Time) {
+	from := paidOn
+	if paidThrough != nil && paidThrough.After(paidOn) {
+		from = *paidThrough
+	}
+
+	if t.Price <= 0 || amount <= 0 {
+		return from, from
+	}
+
+	periods := amount / t.Price
+	remainder := amount % t.Price
+
+	if remainder > 0 && t.Price-remainder <= tolerance {
+		periods++
+		remainder = 0
+	}
+
+	through := t.addBillingPeriods(from, int(periods))
+
+	if remainder > 0 {
+		periodDays := int64(t.addBillingPeriods(through, 1).Sub(through).Hours() / 24)
+		through = through.AddDate(0, 0, int(remainder*periodDays/t.Price))
+	}
+
+	return from, through
+}
+
+type unledgeredPayment struct {
+	id          string
+	memberID    string
+	date        time.Time
+	amount      money.Money
+	level       MemberLevel
+	paidThrough *time.Time
+}
+
+// UpdatePaidThrough - extends the paid through date of each member with the payments
+//   that haven't been counted yet and records them in the ledger
+func (db *Database) UpdatePaidThrough() error {
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	err = db.ledgerPayments(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error committing paid through dates: %v", err)
+	}
+
+	return nil
+}
+
+// RebuildPaidThrough - counts all the payments of the members again, i.e. after a payment was refunded.
+//   Paid through dates only move forward when payments are counted, so the members' ledgers are started over
+func (db *Database) RebuildPaidThrough(memberIDs []string) error {
+	if len(memberIDs) == 0 {
+		return nil
+	}
+
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	_, err = tx.Exec(db.ctx, paidThroughDbMethod.clearMemberLedgers(), memberIDs)
+	if err != nil {
+		return fmt.Errorf("error clearing ledger: %v", err)
+	}
+
+	_, err = tx.Exec(db.ctx, paidThroughDbMethod.clearMemberPaidThrough(), memberIDs)
+	if err != nil {
+		return fmt.Errorf("error clearing paid through dates: %v", err)
+	}
+
+	err = db.ledgerPayments(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error committing paid through dates: %v", err)
+	}
+
+	return nil
+}
+
+// ledgerPayments - counts the payments that aren't in the ledger yet.
+//   A payment counts for what is left of it after its refunds and reversals,
+//   one that was given back entirely doesn't move the paid through date.
+func (db *Database) ledgerPayments(q querier) error {
+	tiers := db.GetMemberTiers()
+	tolerance := PaymentTolerance()
+
+	rows, err := q.Query(db.ctx, paidThroughDbMethod.getUnledgeredPayments())
+	if err != nil {
+		return fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	var payments []unledgeredPayment
+
+	for rows.Next() {
+		var p unledgeredPayment
+		var amount int64
+		var currency string
+
+		err = rows.Scan(&p.id, &p.memberID, &p.date, &amount, &currency, &p.level, &p.paidThrough)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("error reading payment: %v", err)
+		}
+
+		p.amount = *money.New(amount, currency)
+
+		payments = append(payments, p)
+	}
+
+	rows.Close()
+
+	// payments of the same member build on each other
+	paidThrough := make(map[string]*time.Time)
+
+	for _, p := range payments {
+		current, found := paidThrough[p.memberID]
+		if !found {
+			current = p.paidThrough
+		}
+
+		tier, ok := tiers.ForPayment(p.level, p.amount, tolerance)
+
+		var tierID *uint8
+		if ok {
+			tierID = &tier.ID
+		}
+
+		from, through := tier.PaidThrough(current, p.date, p.amount.Amount(), tolerance)
+
+		_, err = q.Exec(db.ctx, paidThroughDbMethod.insertLedgerEntry(), p.id, p.memberID, tierID, from, through)
+		if err != nil {
+			return fmt.Errorf("error adding ledger entry: %v", err)
+		}
+
+		if p.amount.IsPositive() && (current == nil || through.After(*current)) {
+			current = &through
+		}
+
+		paidThrough[p.memberID] = current
+	}
+
+	for memberID, through := range paidThrough {
+		if through == nil {
+			continue
+		}
+
+		_, err = q.Exec(db.ctx, paidThroughDbMethod.updateMemberPaidThrough(), memberID, *through)
+		if err != nil {
+			return fmt.Errorf("error updating paid through date: %v", err)
+		}
+	}
+
+	if len(payments) > 0 {
+		log.Debugf("counted %d payments toward paid through dates", len(payments))
+	}
+
+	return nil
+}
+
+// GetMemberLedger - how each of a member's payments extended their paid through date
+func (db *Database) GetMemberLedger(memberID string) ([]LedgerEntry, error) {
+	var ledger []LedgerEntry
+
+	rows, err := db.getConn().Query(db.ctx, paidThroughDbMethod.getMemberLedger(), memberID)
+	if err != nil {
+		return ledger, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var e LedgerEntry
+		var amount int64
+		var currency string
+
+		err = rows.Scan(&e.PaymentID, &e.PaymentDate, &amount, &currency, &e.Level, &e.Tier, &e.PaidFrom, &e.PaidThrough)
+		if err != nil {
+			return ledger, fmt.Errorf("error reading ledger entry: %v", err)
+		}
+
+		e.Amount = *money.New(amount, currency)
+
+		ledger = append(ledger, e)
+	}
+
+	return ledger, nil
+}
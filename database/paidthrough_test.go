@@ -0,0 +1,92 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+var paymentTiers = Tiers{
+	{ID: uint8(Inactive), Name: "Inactive", Price: 0, Currency: "USD", BillingPeriod: BillingMonthly, Active: true},
+	{ID: uint8(Credited), Name: "Credited", Price: 100, Currency: "USD", BillingPeriod: BillingMonthly, Active: true},
+	{ID: uint8(Classic), Name: "Classic", Price: 3000, Currency: "USD", BillingPeriod: BillingMonthly, Active: true},
+	{ID: uint8(Standard), Name: "Standard", Price: 3500, Currency: "USD", BillingPeriod: BillingMonthly, Active: true},
+	{ID: uint8(Premium), Name: "Premium", Price: 5000, Currency: "USD", BillingPeriod: BillingMonthly, Active: true},
+	{ID: 6, Name: "Annual", Price: 35000, Currency: "USD", BillingPeriod: BillingYearly, Active: true},
+}
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestTiersForPayment(t *testing.T) {
+	tests := []struct {
+		current  MemberLevel
+		amount   int64
+		expected uint8
+	}{
+		{Standard, 3500, uint8(Standard)},
+		{Standard, 35000, 6},
+		// within the tolerance of a price
+		{Classic, 3499, uint8(Standard)},
+		{Inactive, 2950, uint8(Classic)},
+		// payments that don't match a tier pay toward the member's tier
+		{Standard, 7000, uint8(Standard)},
+		{Premium, 2500, uint8(Premium)},
+		// members without a paid tier get the tier the payment covers
+		{Inactive, 4500, uint8(Standard)},
+		{Inactive, 1000, uint8(Classic)},
+		// credited isn't paid for
+		{Inactive, 100, uint8(Classic)},
+	}
+
+	for _, test := range tests {
+		tier, ok := paymentTiers.ForPayment(test.current, *money.New(test.amount, "USD"), 50)
+		if !ok || tier.ID != test.expected {
+			t.Errorf("expected %d on tier %d to pay for tier %d, got %d", test.amount, test.current, test.expected, tier.ID)
+		}
+	}
+
+	if _, ok := paymentTiers.ForPayment(Standard, *money.New(0, "USD"), 50); ok {
+		t.Error("expected a payment of 0 to not pay for a tier")
+	}
+
+	if _, ok := paymentTiers.ForPayment(Standard, *money.New(3500, "EUR"), 50); ok {
+		t.Error("expected a payment in another currency to not pay for a tier")
+	}
+}
+
+func TestTierPaidThrough(t *testing.T) {
+	standard, _ := paymentTiers.ByID(Standard)
+	annual, _ := paymentTiers.ByID(6)
+	paidAhead := date("2021-07-01")
+	lapsed := date("2021-05-01")
+
+	tests := []struct {
+		tier        Tier
+		paidThrough *time.Time
+		amount      int64
+		from        time.Time
+		through     time.Time
+	}{
+		{standard, nil, 3500, date("2021-06-05"), date("2021-07-05")},
+		{standard, nil, 7000, date("2021-06-05"), date("2021-08-05")},
+		{annual, nil, 35000, date("2021-06-05"), date("2022-06-05")},
+		// half of the days in July
+		{standard, nil, 5250, date("2021-06-05"), date("2021-07-20")},
+		// short by less than the tolerance
+		{standard, nil, 3499, date("2021-06-05"), date("2021-07-05")},
+		{standard, nil, 6990, date("2021-06-05"), date("2021-08-05")},
+		{standard, &paidAhead, 3500, paidAhead, date("2021-08-01")},
+		{standard, &lapsed, 3500, date("2021-06-05"), date("2021-07-05")},
+	}
+
+	for _, test := range tests {
+		from, through := test.tier.PaidThrough(test.paidThrough, date("2021-06-05"), test.amount, 50)
+		if !from.Equal(test.from) || !through.Equal(test.through) {
+			t.Errorf("expected %d for %s to pay from %s through %s, got %s through %s", test.amount, test.tier.Name, test.from.Format("2006-01-02"), test.through.Format("2006-01-02"), from.Format("2006-01-02"), through.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+// PaidThroughDatabaseMethod -- method container that holds the extension methods to query the paid through ledger
+type PaidThroughDatabaseMethod struct{}
+
+func (paidThrough *PaidThroughDatabaseMethod) getUnledgeredPayments() string {
+	// payments that haven't extended a paid through date yet, oldest first for each member.
+	//   Refunds and reversals aren't counted themselves, they are taken off the payment they give money back for
+	const getUnledgeredPaymentsQuery = `SELECT p.id, p.member_id, p.date,
+		p.amount_minor + COALESCE((
+			SELECT SUM(r.amount_minor)
+			FROM membership.payments r
+			WHERE r.refund_of = p.id
+		), 0),
+		p.currency, m.member_tier_id, m.paid_through
+	FROM membership.payments p
+	INNER JOIN membership.members m
+	ON m.id = p.member_id
+	WHERE p.refund_of IS NULL
+	AND NOT EXISTS (
+		SELECT 1
+		FROM membership.paid_through_ledger l
+		WHERE l.payment_id = p.id
+	)
+	ORDER BY p.member_id, p.date, p.id;`
+
+	return getUnledgeredPaymentsQuery
+}
+
+func (paidThrough *PaidThroughDatabaseMethod) insertLedgerEntry() string {
+	const insertLedgerEntryQuery = `INSERT INTO membership.paid_through_ledger(
+		payment_id, member_id, member_tier_id, paid_from, paid_through)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING;`
+
+	return insertLedgerEntryQuery
+}
+
+func (paidThrough *PaidThroughDatabaseMethod) updateMemberPaidThrough() string {
+	const updateMemberPaidThroughQuery = `UPDATE membership.members
+	SET paid_through = $2
+	WHERE id = $1
+	AND (paid_through IS NULL OR paid_through < $2);`
+
+	return updateMemberPaidThroughQuery
+}
+
+func (paidThrough *PaidThroughDatabaseMethod) clearMemberLedgers() string {
+	const clearMemberLedgersQuery = `DELETE FROM membership.paid_through_ledger
+	WHERE member_id = ANY($1::uuid[]);`
+
+	return clearMemberLedgersQuery
+}
+
+func (paidThrough *PaidThroughDatabaseMethod) clearMemberPaidThrough() string {
+	const clearMemberPaidThroughQuery = `UPDATE membership.members
+	SET paid_through = NULL
+	WHERE id = ANY($1::uuid[]);`
+
+	return clearMemberPaidThroughQuery
+}
+
+func (paidThrough *PaidThroughDatabaseMethod) getMemberLedger() string {
+	const getMemberLedgerQuery = `SELECT l.payment_id, p.date, p.amount_minor, p.currency, COALESCE(l.member_tier_id, 0), COALESCE(t.description, ''), l.paid_from, l.paid_through
+	FROM membership.paid_through_ledger l
+	INNER JOIN membership.payments p
+	ON p.id = l.payment_id
+	LEFT JOIN membership.member_tiers t
+	ON t.id = l.member_tier_id
+	WHERE l.member_id = $1
+	ORDER BY l.paid_through DESC, p.date DESC;`
+
+	return getMemberLedgerQuery
+}
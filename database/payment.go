@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"strings"
@@ -18,32 +19,122 @@ var paymentDbMethod PaymentDatabaseMethod
 type PaymentProvider int
 
 const (
+	//UnknownProvider ... a payment whose provider wasn't set, it can't be recorded
+	UnknownProvider PaymentProvider = iota
 	//QuickBooks ... payment provider
-	QuickBooks PaymentProvider = iota
+	QuickBooks
 	//Paypal ... payment provider
 	Paypal
+	//Cash ... payment recorded by an admin
+	Cash
+	//Check ... payment recorded by an admin
+	Check
+	//Stripe ... payment provider
+	Stripe
+)
+
+// ErrUnknownProvider - a payment can't be recorded without the provider it was made through
+var ErrUnknownProvider = errors.New("the payment has no provider")
+
+// PaymentProviders - the payment providers by the name used in the config and the api
+var PaymentProviders = map[string]PaymentProvider{
+	"quickbooks": QuickBooks,
+	"paypal":     Paypal,
+	"cash":       Cash,
+	"check":      Check,
+	"stripe":     Stripe,
+}
+
+// String - the name of the payment provider
+func (p PaymentProvider) String() string {
+	for name, provider := range PaymentProviders {
+		if provider == p {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("provider %d", int(p))
+}
+
+// PaymentKind - whether an entry is money that was paid or money that was given back
+type PaymentKind int
+
+const (
+	//PaymentReceived ... money a member paid
+	PaymentReceived PaymentKind = iota
+	//PaymentRefund ... money we gave back for a payment
+	PaymentRefund
+	//PaymentReversal ... money that was taken back from us for a payment, i.e. a chargeback
+	PaymentReversal
 )
 
 // Payment represents a payment made
 // this will be pulled down from the providers
 type Payment struct {
-	ID string
+	ID string `json:"id"`
 	// Date is when the payment was made
-	Date     time.Time
-	Amount   money.Money
-	Provider PaymentProvider
-	MemberID string
-	Email    string
-	Name     string
+	Date time.Time `json:"date"`
+	// Amount is in minor units, i.e. cents, along with its currency
+	Amount   money.Money     `json:"amount"`
+	Provider PaymentProvider `json:"provider"`
+	MemberID string          `json:"memberID"`
+	Email    string          `json:"email"`
+	Name     string          `json:"name"`
+	// TransactionID - the id the provider gave the transaction.  Payments recorded by an admin are given one of their own.
+	//   A provider's transaction is only recorded once
+	TransactionID string      `json:"transactionID"`
+	Kind          PaymentKind `json:"kind"`
+	// RefundOf - the payment a refund or reversal gives money back for.  Its amount is negative
+	RefundOf *string `json:"refundOf"`
+}
+
+// MemberGracePeriod - days past a member's paid through date before their access is revoked.
+//   Members are warned as soon as they are past their paid through date.
+const MemberGracePeriod = 15
+
+// PastGracePeriod - whether a member that is daysPastDue has their access revoked
+func PastGracePeriod(daysPastDue int) bool {
+	return daysPastDue > MemberGracePeriod
+}
+
+// daysUntilRevocation - how many days a member that is daysPastDue has before their access is revoked.
+//   The last day of the grace period is 1, a member that is past the grace period has 0
+func daysUntilRevocation(daysPastDue int) int {
+	if PastGracePeriod(daysPastDue) {
+		return 0
+	}
+
+	return MemberGracePeriod + 1 - daysPastDue
+}
+
+// MembershipStatus - where a member stands with their dues
+type MembershipStatus struct {
+	Level MemberLevel `json:"memberLevel"`
+	Tier  string      `json:"tier"`
+	// LastPaymentDate - empty when the member has never paid
+	LastPaymentDate      *time.Time `json:"lastPaymentDate"`
+	DaysSinceLastPayment *int       `json:"daysSinceLastPayment"`
+	// PaidThrough - the date the member's payments cover them through.  Empty when the member has never paid
+	PaidThrough *time.Time `json:"paidThrough"`
+	// PastDue - the member is past their paid through date and will be warned
+	PastDue bool `json:"pastDue"`
+	// DaysUntilRevocation - days left before the grace period ends and access is revoked.
+	//   Empty when the member's tier isn't revoked for missing payments, i.e. Credited or Inactive
+	DaysUntilRevocation *int `json:"daysUntilRevocation"`
+	// Credits - the member's active credits
+	Credits []MemberCredit `json:"credits"`
+	// SubscriptionCancelledAt - when the member cancelled their subscription with a payment provider.
+	//   Empty when they haven't cancelled or subscribed again since
+	SubscriptionCancelledAt *time.Time `json:"subscriptionCancelledAt"`
 }
 
-// PastDueAccount represents accounts that do not have a recent payment recorded
+// PastDueAccount represents accounts that are past their paid through date
 type PastDueAccount struct {
-	MemberId             string
-	Name                 string
-	Email                string
-	LastPaymentDate      time.Time
-	DaysSinceLastPayment int
+	MemberId    string
+	Name        string
+	Email       string
+	PaidThrough time.Time
+	DaysPastDue int
 }
 
 // GetPayments - get list of payments that we have in the db
@@ -60,12 +151,36 @@ func (db *Database) GetPayments() ([]Payment, error) {
 	for rows.Next() {
 		var p Payment
 		var amount int64
-		err = rows.Scan(&p.ID, &p.Date, &amount)
+		var currency string
+		err = rows.Scan(&p.ID, &p.Date, &amount, &currency, &p.Provider)
 		if err != nil {
 			log.Errorf("error scanning row: %s", err)
 		}
 
-		p.Amount = *money.New(amount*100, "USD")
+		p.Amount = *money.New(amount, currency)
+
+		payments = append(payments, p)
+	}
+
+	return payments, nil
+}
+
+// GetMemberPayments - get the payments of a member, most recent first
+func (db *Database) GetMemberPayments(memberID string) ([]Payment, error) {
+	var payments []Payment
+
+	rows, err := db.getConn().Query(db.ctx, paymentDbMethod.getMemberPayments(), memberID)
+	if err != nil {
+		return payments, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return payments, fmt.Errorf("error scanning row: %v", err)
+		}
 
 		payments = append(payments, p)
 	}
@@ -73,78 +188,206 @@ func (db *Database) GetPayments() ([]Payment, error) {
 	return payments, nil
 }
 
-// AddPayment adds a member to the database
-func (db *Database) AddPayment(payment Payment) error {
+// GetMembershipStatus - the member's tier, last payment and how long until their access is revoked.
+//   This follows the same rules the scheduler uses to warn and revoke past due members.
+func (db *Database) GetMembershipStatus(m Member) (MembershipStatus, error) {
+	status := MembershipStatus{
+		Level: MemberLevel(m.Level),
+	}
+
+	tier, err := db.GetTierByID(status.Level)
+	if err != nil {
+		return status, err
+	}
+
+	status.Tier = tier.Name
+
+	var daysPastDue int
+
+	err = db.getConn().QueryRow(db.ctx, paymentDbMethod.memberLastPayment(), m.ID).Scan(&status.LastPaymentDate, &status.DaysSinceLastPayment, &status.PaidThrough, &daysPastDue, &status.SubscriptionCancelledAt)
+	if err != nil {
+		return status, fmt.Errorf("error getting last payment: %v", err)
+	}
+
+	if !status.Level.IsProtected() {
+		status.PastDue = daysPastDue > 0
+
+		days := daysUntilRevocation(daysPastDue)
+		status.DaysUntilRevocation = &days
+	}
+
+	status.Credits, err = db.GetActiveMemberCredits(m.ID)
+	if err != nil {
+		return status, err
+	}
+
+	return status, nil
+}
+
+// scanPayment - reads a payment selected with the columns of getMemberPayments
+func scanPayment(row pgx.Row) (Payment, error) {
 	var p Payment
 	var amount int64
+	var currency string
 
-	err := db.getConn().QueryRow(context.Background(), paymentDbMethod.insertPayment(), payment.Date, payment.Amount.AsMajorUnits(), payment.MemberID).Scan(&p.ID, &p.Date, &amount, &p.MemberID)
+	err := row.Scan(&p.ID, &p.Date, &amount, &currency, &p.Provider, &p.MemberID, &p.TransactionID, &p.Kind, &p.RefundOf)
 	if err != nil {
-		return fmt.Errorf("conn.Query failed: %v", err)
+		return p, err
 	}
 
-	p.Amount = *money.New(amount*100, "USD")
+	p.Amount = *money.New(amount, currency)
 
-	return err
+	return p, nil
 }
 
-// AddPayments adds multiple payments to the database
+// nullIfEmpty - stores an empty string as NULL
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+// AddPayment adds a payment to the database.
+//   A payment without a provider is refused with ErrUnknownProvider
+func (db *Database) AddPayment(payment Payment) (Payment, error) {
+	if payment.Provider == UnknownProvider {
+		return payment, ErrUnknownProvider
+	}
+
+	p, err := scanPayment(db.getConn().QueryRow(context.Background(), paymentDbMethod.insertPayment(), payment.Date, payment.Amount.Amount(), payment.Amount.Currency().Code, payment.Provider, payment.MemberID, nullIfEmpty(payment.TransactionID)))
+	if err != nil {
+		return p, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	return p, err
+}
+
+// AddPayments adds multiple payments to the database.
+//   Payments are told apart by the provider's transaction id, so syncing the same transactions again doesn't add them twice
+//   while two payments of the same amount on the same day are both kept.
+//   Payments recorded before the provider's ids were kept are given the id of the transaction they match
+//   instead of being recorded again
 func (db *Database) AddPayments(payments []Payment) error {
-	var valStr []string
+	payments = recordablePayments(payments)
+	if len(payments) == 0 {
+		return nil
+	}
 
-	sqlStr := `INSERT INTO membership.payments(
-date, amount, member_id)
-VALUES `
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(db.ctx)
 
 	for _, p := range payments {
-		if p.MemberID == "" {
-			continue
+		_, err = tx.Exec(db.ctx, paymentDbMethod.adoptLegacyPayment(), p.Provider, p.MemberID, p.Date.Format("2006-01-02"), p.Amount.Amount(), p.Amount.Currency().Code, p.TransactionID)
+		if err != nil {
+			return fmt.Errorf("error matching legacy payment: %v", err)
 		}
-		valStr = append(valStr, fmt.Sprintf("('%s', %d, '%s')", p.Date.Format("2006-01-02"), p.Amount.Amount()/100, p.MemberID))
 	}
 
-	str := strings.Join(valStr, ",")
+	sqlStr, args := insertPayments(payments)
 
-	_, err := db.getConn().Exec(context.Background(), sqlStr+str+" ON CONFLICT DO NOTHING;")
+	_, err = tx.Exec(db.ctx, sqlStr, args...)
 	if err != nil {
 		return fmt.Errorf("conn.Exec failed: %v", err)
 	}
 
-	return err
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return fmt.Errorf("error committing payments: %v", err)
+	}
+
+	return nil
+}
+
+// recordablePayments - the payments that have a member and a transaction id
+func recordablePayments(payments []Payment) []Payment {
+	var recordable []Payment
+
+	for _, p := range payments {
+		if p.MemberID == "" {
+			continue
+		}
+		if p.Provider == UnknownProvider {
+			log.Errorf("payment of %s on %s has no provider, it can't be recorded", p.Amount.Display(), p.Date.Format("2006-01-02"))
+			continue
+		}
+		if p.TransactionID == "" {
+			log.Errorf("payment of %s on %s has no transaction id, it can't be recorded", p.Amount.Display(), p.Date.Format("2006-01-02"))
+			continue
+		}
+
+		recordable = append(recordable, p)
+	}
+
+	return recordable
+}
+
+// insertPayments - the insert of the payments, the ones that were already recorded are skipped
+func insertPayments(payments []Payment) (string, []interface{}) {
+	var valStr []string
+	var args []interface{}
+
+	sqlStr := `INSERT INTO membership.payments(
+date, amount_minor, currency, provider, member_id, transaction_id)
+VALUES `
+
+	for _, p := range payments {
+		n := len(args)
+		valStr = append(valStr, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6))
+		args = append(args, p.Date.Format("2006-01-02"), p.Amount.Amount(), p.Amount.Currency().Code, p.Provider, p.MemberID, p.TransactionID)
+	}
+
+	return sqlStr + strings.Join(valStr, ",") + " ON CONFLICT (provider, transaction_id) DO NOTHING;", args
 }
 
 // SetMemberLevel sets a member's membership tier
-func (db *Database) SetMemberLevel(memberId string, level MemberLevel) error {
-	rows, err := db.getConn().Query(context.Background(), paymentDbMethod.updateMembershipLevel(), memberId, level)
+//   the change is recorded in the member's tier history along with the cause
+func (db *Database) SetMemberLevel(memberId string, level MemberLevel, cause TierChangeCause) error {
+	return db.setMemberLevel(db.getConn(), memberId, level, cause)
+}
+
+func (db *Database) setMemberLevel(q querier, memberId string, level MemberLevel, cause TierChangeCause) error {
+	_, err := q.Exec(context.Background(), paymentDbMethod.updateMembershipLevel(), memberId, level, string(cause))
 	if err != nil {
 		log.Errorf("Set member level failed: %v", err)
 		return err
 	}
-	defer rows.Close()
 	return nil
 }
 
 // ApplyMemberCredits updates members tiers for all members with credit to Credited
 func (db *Database) ApplyMemberCredits() {
-	//	Member credits are currently managed by DB commands.  #102 will address this.
 	memberCredits := db.GetMembersWithCredit()
 	for _, m := range memberCredits {
-		err := db.SetMemberLevel(m.ID, Credited)
+		err := db.SetMemberLevel(m.ID, Credited, TierChangeCredit)
 		if err != nil {
 			log.Errorf("member credit failed: %v", err)
 		}
 	}
 }
 
-// UpdateMemberTiers updates member tiers based on the most recent payment amount
+// UpdateMemberTiers counts new payments toward the members' paid through dates
+//   and moves the members that are paid up to the tier of their most recent payment
 func (db *Database) UpdateMemberTiers() {
-	db.getConn().Exec(context.Background(), paymentDbMethod.updateMemberTiers())
+	err := db.UpdatePaidThrough()
+	if err != nil {
+		log.Errorf("update paid through dates failed: %v", err)
+	}
+
+	_, err = db.getConn().Exec(context.Background(), paymentDbMethod.updateMemberTiers(), string(TierChangePayment))
+	if err != nil {
+		log.Errorf("update member tiers failed: %v", err)
+	}
 }
 
-// GetPastDueAccounts retrieves all active members without a payment in the last month
+// GetPastDueAccounts retrieves all active members that are past their paid through date
 func (db *Database) GetPastDueAccounts() []PastDueAccount {
 	var pastDueAccounts []PastDueAccount
-	rows, err := db.getConn().Query(context.Background(), paymentDbMethod.pastDuePayments())
+	rows, err := db.getConn().Query(context.Background(), paymentDbMethod.pastDuePayments(), Inactive, Credited)
 
 	if err == pgx.ErrNoRows {
 		return pastDueAccounts
@@ -157,7 +400,7 @@ func (db *Database) GetPastDueAccounts() []PastDueAccount {
 
 	for rows.Next() {
 		var p PastDueAccount
-		err = rows.Scan(&p.MemberId, &p.Name, &p.Email, &p.LastPaymentDate, &p.DaysSinceLastPayment)
+		err = rows.Scan(&p.MemberId, &p.Name, &p.Email, &p.PaidThrough, &p.DaysPastDue)
 		if err != nil {
 			log.Errorf("error scanning row: %s", err)
 		}
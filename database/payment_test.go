@@ -0,0 +1,67 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+func TestDaysUntilRevocation(t *testing.T) {
+	tests := []struct {
+		daysPastDue int
+		expected    int
+	}{
+		{0, MemberGracePeriod + 1},
+		{1, MemberGracePeriod},
+		// the scheduler revokes the member the day after the grace period ends
+		{MemberGracePeriod, 1},
+		{MemberGracePeriod + 1, 0},
+		{MemberGracePeriod + 30, 0},
+	}
+
+	for _, test := range tests {
+		days := daysUntilRevocation(test.daysPastDue)
+		if days != test.expected {
+			t.Errorf("expected %d days until revocation at %d days past due, got %d", test.expected, test.daysPastDue, days)
+		}
+
+		if PastGracePeriod(test.daysPastDue) != (days == 0) {
+			t.Errorf("expected a member to be revoked once there are no days left, at %d days past due", test.daysPastDue)
+		}
+	}
+}
+
+func TestInsertPayments(t *testing.T) {
+	date := time.Date(2021, 11, 8, 0, 0, 0, 0, time.UTC)
+
+	// a member paying their dues and for a class on the same day
+	payments := []Payment{
+		{Date: date, Amount: *money.New(3500, "USD"), Provider: Paypal, MemberID: "ada", TransactionID: "9JX52151EJ9837301"},
+		{Date: date, Amount: *money.New(3500, "USD"), Provider: Paypal, MemberID: "ada", TransactionID: "4RU99428TH283391K"},
+		{Date: date, Amount: *money.New(3500, "USD"), Provider: Paypal, TransactionID: "7HD18402LK928374M"},
+		{Date: date, Amount: *money.New(3500, "USD"), Provider: Paypal, MemberID: "ada"},
+		{Date: date, Amount: *money.New(3500, "USD"), MemberID: "ada", TransactionID: "2LM83910QW827465P"},
+	}
+
+	query, args := insertPayments(recordablePayments(payments))
+
+	// the payments without a member, a transaction id or a provider aren't recorded
+	if len(args) != 12 {
+		t.Fatalf("expected both of the member's payments to be inserted, got %d values", len(args))
+	}
+
+	if args[5] != "9JX52151EJ9837301" || args[11] != "4RU99428TH283391K" {
+		t.Errorf("expected the payments to keep the ids of their transactions, got %v", args)
+	}
+
+	// the same transaction synced again is skipped while other payments on the same day are kept
+	if !strings.HasSuffix(query, "ON CONFLICT (provider, transaction_id) DO NOTHING;") {
+		t.Errorf("expected payments to be told apart by their provider and transaction id, got %s", query)
+	}
+
+	if recordable := recordablePayments(payments[2:]); len(recordable) != 0 {
+		t.Errorf("expected nothing to insert, got %v", recordable)
+	}
+}
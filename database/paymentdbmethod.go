@@ -5,19 +5,74 @@ type PaymentDatabaseMethod struct{}
 
 func (payment *PaymentDatabaseMethod) getPayments() string {
 	const getPaymentsQuery = `
-	SELECT id, date, amount
+	SELECT id, date, amount_minor, currency, provider
 	FROM membership.payments
+	WHERE refund_of IS NULL
 	ORDER BY date;`
 
 	return getPaymentsQuery
 }
 
+func (payment *PaymentDatabaseMethod) adoptLegacyPayment() string {
+	// payments recorded before the provider's ids were kept have their own id as their transaction id.
+	//  They were told apart by member, date, amount and currency, so at most one of them matches the transaction
+	const adoptLegacyPaymentQuery = `UPDATE membership.payments
+	SET transaction_id = $6
+	WHERE id = (
+		SELECT l.id
+		FROM membership.payments l
+		WHERE l.provider = $1
+		AND l.member_id = $2
+		AND l.date = $3
+		AND l.amount_minor = $4
+		AND l.currency = $5
+		AND l.transaction_id = l.id::text
+		AND l.refund_of IS NULL
+		ORDER BY l.id
+		LIMIT 1
+	)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM membership.payments o
+		WHERE o.provider = $1
+		AND o.transaction_id = $6
+	);`
+
+	return adoptLegacyPaymentQuery
+}
+
+func (payment *PaymentDatabaseMethod) getMemberPayments() string {
+	const getMemberPaymentsQuery = `
+	SELECT id, date, amount_minor, currency, provider, member_id, transaction_id, kind, refund_of
+	FROM membership.payments
+	WHERE member_id = $1
+	ORDER BY date DESC;`
+
+	return getMemberPaymentsQuery
+}
+
+func (payment *PaymentDatabaseMethod) memberLastPayment() string {
+	// days past due matches pastDuePayments
+	const memberLastPaymentQuery = `
+	SELECT MAX(p.date), current_date - MAX(p.date), m.paid_through,
+		current_date - COALESCE(m.paid_through, '0001-01-01'), m.subscription_cancelled_at
+	FROM membership.members m
+	LEFT JOIN membership.payments p
+	ON p.member_id = m.id
+		AND p.refund_of IS NULL
+	WHERE m.id = $1
+	GROUP BY m.id, m.paid_through, m.subscription_cancelled_at;`
+
+	return memberLastPaymentQuery
+}
+
 func (payment *PaymentDatabaseMethod) insertPayment() string {
+	// payments recorded by an admin are given an id of their own
 	const insertPaymentQuery = `
 	INSERT INTO membership.payments(
-	date, amount, member_id)
-	VALUES ($1, $2, $3)
-	RETURNING *;`
+	date, amount_minor, currency, provider, member_id, transaction_id)
+	VALUES ($1, $2, $3, $4, $5, COALESCE($6, gen_random_uuid()::text))
+	RETURNING id, date, amount_minor, currency, provider, member_id, transaction_id, kind, refund_of;`
 
 	return insertPaymentQuery
 }
@@ -29,6 +84,7 @@ func (payment *PaymentDatabaseMethod) countPaymentsOfMemberSince() string {
 	LEFT JOIN membership.members
 	ON membership.payments.member_id = membership.members.id
 	WHERE member_id = $1
+	AND refund_of IS NULL
 	AND date >= current_date - $2;`
 
 	return countPaymentsOfMemberSinceQuery
@@ -36,51 +92,66 @@ func (payment *PaymentDatabaseMethod) countPaymentsOfMemberSince() string {
 
 func (payment *PaymentDatabaseMethod) updateMembershipLevel() string {
 	const updateMembershipLevelQuery = `
-	UPDATE membership.members
-	SET member_tier_id=$2
-	WHERE id=$1
-	RETURNING *;`
+	WITH updated AS (
+		UPDATE membership.members m
+		SET member_tier_id = $2
+		FROM membership.members previous
+		WHERE m.id = $1
+			AND previous.id = m.id
+			AND m.member_tier_id != $2
+		RETURNING m.id, previous.member_tier_id as previous_tier_id, m.member_tier_id
+	)
+	INSERT INTO membership.member_tier_history(member_id, previous_tier_id, new_tier_id, cause)
+	SELECT id, previous_tier_id, member_tier_id, $3
+	FROM updated;`
 
 	return updateMembershipLevelQuery
 }
 
 func (payment *PaymentDatabaseMethod) pastDuePayments() string {
+	// the paid through date already has the refunds and reversals taken off
 	const sql = `
-	SELECT m.id, m.name, m.email, COALESCE(max(p.date), '0001-01-01') as lastPaymentDate,
-		current_date - COALESCE(max(p.date), '0001-01-01') as daysSinceLastPayment
+	SELECT m.id, m.name, m.email, COALESCE(m.paid_through, '0001-01-01') as paidThrough,
+		current_date - COALESCE(m.paid_through, '0001-01-01') as daysPastDue
 	FROM membership.members m
-	INNER JOIN membership.member_tiers t
-	on m.member_tier_id = t.id
-	LEFT JOIN membership.payments p
-	on m.id = p.member_id
-	WHERE t.description not in ('Inactive', 'Credited')
-	GROUP BY m.id, m.name, m.email
-	HAVING MAX(p.date) is null or MAX(p.date) < current_date - interval '1 month';`
+	WHERE m.member_tier_id NOT IN ($1, $2)
+		AND m.archived_at IS NULL
+		AND COALESCE(m.paid_through, '0001-01-01') < current_date;`
 	return sql
 }
 
 func (payment *PaymentDatabaseMethod) updateMemberTiers() string {
+	// members that are paid up go to the tier of their most recent payment
 	const sql = `
 	with cte as (
-		SELECT m.id as MemberId, p.amount,
+		SELECT l.member_id as MemberId, l.member_tier_id as tier_id,
 			ROW_NUMBER() over (
-				Partition By m.id
-				order by p.date DESC
+				Partition By l.member_id
+				order by p.date DESC, l.paid_through DESC
 			) row_num
-		FROM membership.members m
+		FROM membership.paid_through_ledger l
 		INNER JOIN membership.payments p
-		ON m.id = p.member_id
-			AND p.amount > 0
-		WHERE p.date > current_date - interval '1 month'
+		ON p.id = l.payment_id
+		INNER JOIN membership.members m
+		ON m.id = l.member_id
+		WHERE l.member_tier_id IS NOT NULL
+			AND p.refund_of IS NULL
+			AND m.paid_through >= current_date
+	)
+	, updated as (
+		UPDATE membership.members m
+		SET member_tier_id = c.tier_id
+		FROM cte c
+		INNER JOIN membership.members previous
+		ON c.memberid = previous.id
+		WHERE c.memberid = m.id
+			AND c.row_num = 1
+			AND m.member_tier_id != c.tier_id
+		RETURNING m.id, previous.member_tier_id as previous_tier_id, m.member_tier_id
 	)
-	UPDATE membership.members m
-	SET member_tier_id = t.id
-	FROM cte c
-	INNER JOIN membership.member_tiers t
-	ON c.amount = t.price
-	WHERE c.memberid = m.id
-		AND c.row_num = 1
-		AND m.member_tier_id != t.id;
+	INSERT INTO membership.member_tier_history(member_id, previous_tier_id, new_tier_id, cause)
+	SELECT id, previous_tier_id, member_tier_id, $1
+	FROM updated;
 	`
 	return sql
 }
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+var paymentSyncDbMethod PaymentSyncDatabaseMethod
+
+// GetSyncedThrough - how far the payments of a provider have been downloaded.  Zero when they never were
+func (db *Database) GetSyncedThrough(provider PaymentProvider) (time.Time, error) {
+	var syncedThrough time.Time
+
+	err := db.getConn().QueryRow(db.ctx, paymentSyncDbMethod.getSyncedThrough(), provider).Scan(&syncedThrough)
+	if err == pgx.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error getting synced through date: %v", err)
+	}
+
+	return syncedThrough, nil
+}
+
+// SetSyncedThrough - moves the high-water mark of a provider forward.  It never moves back
+func (db *Database) SetSyncedThrough(provider PaymentProvider, syncedThrough time.Time) error {
+	_, err := db.getConn().Exec(db.ctx, paymentSyncDbMethod.setSyncedThrough(), provider, syncedThrough)
+	if err != nil {
+		return fmt.Errorf("error saving synced through date: %v", err)
+	}
+
+	return nil
+}
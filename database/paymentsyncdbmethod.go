@@ -0,0 +1,22 @@
+package database
+
+// PaymentSyncDatabaseMethod -- method container that holds the extension methods to query how far payments were downloaded
+type PaymentSyncDatabaseMethod struct{}
+
+func (sync *PaymentSyncDatabaseMethod) getSyncedThrough() string {
+	const getSyncedThroughQuery = `SELECT synced_through
+	FROM membership.payment_sync
+	WHERE provider = $1;`
+
+	return getSyncedThroughQuery
+}
+
+func (sync *PaymentSyncDatabaseMethod) setSyncedThrough() string {
+	const setSyncedThroughQuery = `INSERT INTO membership.payment_sync(
+		provider, synced_through)
+		VALUES ($1, $2)
+	ON CONFLICT (provider) DO UPDATE
+	SET synced_through = GREATEST(payment_sync.synced_through, EXCLUDED.synced_through), updated_at = NOW();`
+
+	return setSyncedThroughQuery
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+var providerTokenDbMethod ProviderTokenDatabaseMethod
+
+// GetRefreshToken - the latest refresh token of a payment provider.  Empty when there isn't one yet
+func (db *Database) GetRefreshToken(provider PaymentProvider) (string, error) {
+	var token string
+
+	err := db.getConn().QueryRow(db.ctx, providerTokenDbMethod.getRefreshToken(), provider).Scan(&token)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting refresh token: %v", err)
+	}
+
+	return token, nil
+}
+
+// SetRefreshToken - keeps the refresh token a payment provider gave us for the next time we connect
+func (db *Database) SetRefreshToken(provider PaymentProvider, token string) error {
+	_, err := db.getConn().Exec(db.ctx, providerTokenDbMethod.setRefreshToken(), provider, token)
+	if err != nil {
+		return fmt.Errorf("error saving refresh token: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package database
+
+// ProviderTokenDatabaseMethod -- method container that holds the extension methods to query the payment provider tokens
+type ProviderTokenDatabaseMethod struct{}
+
+func (token *ProviderTokenDatabaseMethod) getRefreshToken() string {
+	const getRefreshTokenQuery = `SELECT refresh_token
+	FROM membership.payment_provider_tokens
+	WHERE provider = $1;`
+
+	return getRefreshTokenQuery
+}
+
+func (token *ProviderTokenDatabaseMethod) setRefreshToken() string {
+	const setRefreshTokenQuery = `INSERT INTO membership.payment_provider_tokens(
+		provider, refresh_token)
+		VALUES ($1, $2)
+	ON CONFLICT (provider) DO UPDATE
+	SET refresh_token = EXCLUDED.refresh_token, updated_at = NOW();`
+
+	return setRefreshTokenQuery
+}
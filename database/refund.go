@@ -0,0 +1,109 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+var refundDbMethod RefundDatabaseMethod
+
+// Refund - money a provider gave back for a payment it sent us
+type Refund struct {
+	Provider PaymentProvider
+	// TransactionID - the id the provider gave the refund
+	TransactionID string
+	// PaymentTransactionID - the id the provider gave the payment that was refunded
+	PaymentTransactionID string
+	// Email - who the money was given back to.  Payments recorded before the provider's ids were kept
+	//   are found by the member with this email instead
+	Email string
+	// Kind - PaymentRefund or PaymentReversal
+	Kind PaymentKind
+	Date time.Time
+	// Amount - what was given back in minor units, 0 when it was all of the payment
+	Amount   int64
+	Currency string
+}
+
+// AddRefunds - records refunds and reversals as negative entries of the payments they give money back for.
+//   Payments are found by the provider's id.  Payments recorded before the ids were kept are found by
+//   the member's most recent payment of the amount on or before the refund instead.
+//   Refunds that were already recorded are skipped.  A payment is never given back more than what is left of it.
+//   The entries that were recorded are returned along with the refunds whose payment couldn't be found
+func (db *Database) AddRefunds(refunds []Refund) ([]Payment, []Refund, error) {
+	var recorded []Payment
+	var unlinked []Refund
+
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return recorded, unlinked, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	for _, r := range refunds {
+		if r.TransactionID == "" || r.PaymentTransactionID == "" {
+			continue
+		}
+
+		var exists bool
+
+		err = tx.QueryRow(db.ctx, refundDbMethod.refundExists(), r.Provider, r.TransactionID).Scan(&exists)
+		if err != nil {
+			return recorded, unlinked, fmt.Errorf("error checking refund: %v", err)
+		}
+		if exists {
+			continue
+		}
+
+		var paymentID, memberID, currency string
+		var remaining int64
+
+		err = tx.QueryRow(db.ctx, refundDbMethod.getRefundedPayment(), r.Provider, r.PaymentTransactionID).Scan(&paymentID, &memberID, &currency, &remaining)
+		if err == pgx.ErrNoRows && r.Email != "" {
+			err = tx.QueryRow(db.ctx, refundDbMethod.getRefundedLegacyPayment(), r.Provider, r.Email, r.Date.Format("2006-01-02"), r.Amount, r.Currency).Scan(&paymentID, &memberID, &currency, &remaining)
+		}
+		if err == pgx.ErrNoRows {
+			log.Errorf("no %s payment %s was recorded for refund %s", r.Provider, r.PaymentTransactionID, r.TransactionID)
+			unlinked = append(unlinked, r)
+			continue
+		}
+		if err != nil {
+			return recorded, unlinked, fmt.Errorf("error finding refunded payment: %v", err)
+		}
+
+		if r.Currency != "" && !strings.EqualFold(r.Currency, currency) {
+			log.Errorf("refund %s is in %s but payment %s is in %s", r.TransactionID, r.Currency, r.PaymentTransactionID, currency)
+			continue
+		}
+
+		amount := r.Amount
+		if amount == 0 || amount > remaining {
+			amount = remaining
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		p, err := scanPayment(tx.QueryRow(db.ctx, refundDbMethod.insertRefund(), r.Date, -amount, currency, r.Provider, memberID, r.TransactionID, r.Kind, paymentID))
+		if err == pgx.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return recorded, unlinked, fmt.Errorf("error adding refund: %v", err)
+		}
+
+		recorded = append(recorded, p)
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error committing refunds: %v", err)
+	}
+
+	return recorded, unlinked, nil
+}
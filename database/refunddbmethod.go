@@ -0,0 +1,71 @@
+package database
+
+// RefundDatabaseMethod -- method container that holds the extension methods to query the refunds and reversals of payments
+type RefundDatabaseMethod struct{}
+
+func (refund *RefundDatabaseMethod) refundExists() string {
+	const refundExistsQuery = `SELECT EXISTS (
+		SELECT 1
+		FROM membership.payments
+		WHERE provider = $1
+		AND transaction_id = $2
+		AND refund_of IS NOT NULL
+	);`
+
+	return refundExistsQuery
+}
+
+func (refund *RefundDatabaseMethod) getRefundedPayment() string {
+	// what is left of the payment after the refunds that were already recorded
+	const getRefundedPaymentQuery = `SELECT p.id, p.member_id, p.currency,
+		p.amount_minor + COALESCE((
+			SELECT SUM(r.amount_minor)
+			FROM membership.payments r
+			WHERE r.refund_of = p.id
+		), 0)
+	FROM membership.payments p
+	WHERE p.provider = $1
+	AND p.transaction_id = $2
+	AND p.refund_of IS NULL;`
+
+	return getRefundedPaymentQuery
+}
+
+func (refund *RefundDatabaseMethod) getRefundedLegacyPayment() string {
+	// payments recorded before the provider's ids were kept have their own id as their transaction id.
+	//  The refund is of the member's most recent one with the amount, any amount when all of it was given back
+	const getRefundedLegacyPaymentQuery = `SELECT id, member_id, currency, remaining
+	FROM (
+		SELECT p.id, p.member_id, p.currency, p.date, p.amount_minor,
+			p.amount_minor + COALESCE((
+				SELECT SUM(r.amount_minor)
+				FROM membership.payments r
+				WHERE r.refund_of = p.id
+			), 0) AS remaining
+		FROM membership.payments p
+		INNER JOIN membership.members m
+		ON m.id = p.member_id
+		WHERE p.provider = $1
+		AND lower(m.email) = lower($2)
+		AND p.transaction_id = p.id::text
+		AND p.refund_of IS NULL
+		AND p.date <= $3
+		AND ($5 = '' OR upper(p.currency) = upper($5))
+	) legacy
+	WHERE remaining > 0
+	AND ($4::bigint = 0 OR amount_minor = $4::bigint)
+	ORDER BY date DESC
+	LIMIT 1;`
+
+	return getRefundedLegacyPaymentQuery
+}
+
+func (refund *RefundDatabaseMethod) insertRefund() string {
+	const insertRefundQuery = `INSERT INTO membership.payments(
+		date, amount_minor, currency, provider, member_id, transaction_id, kind, refund_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (provider, transaction_id) DO NOTHING
+	RETURNING id, date, amount_minor, currency, provider, member_id, transaction_id, kind, refund_of;`
+
+	return insertRefundQuery
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"errors"
 	"fmt"
+	"memberserver/rfid"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -29,8 +30,17 @@ type Resource struct {
 	// Default state of the Resource
 	// required: true
 	// example: true
-	IsDefault     bool      `json:"isDefault"`
-	LastHeartBeat time.Time `json:"lastHeartBeat"`
+	IsDefault bool `json:"isDefault"`
+	// RFIDFormat - how the resource expects rfid tags to be encoded.  Defaults to esp
+	// required: false
+	// example: esp
+	RFIDFormat rfid.Format `json:"rfidFormat"`
+	// SupportsSchedules - the resource enforces access schedules itself.
+	//   Resources that don't have their access list refreshed as schedules open and close
+	// required: false
+	// example: false
+	SupportsSchedules bool      `json:"supportsSchedules"`
+	LastHeartBeat     time.Time `json:"lastHeartBeat"`
 }
 
 // ResourceDeleteRequest - request for deleting a resource
@@ -59,6 +69,14 @@ type ResourceRequest struct {
 	// required: true
 	// example: true
 	IsDefault bool `json:"isDefault"`
+	// RFIDFormat - how the resource expects rfid tags to be encoded.  Leave empty to keep the current format
+	// required: false
+	// example: esp
+	RFIDFormat rfid.Format `json:"rfidFormat"`
+	// SupportsSchedules - the resource enforces access schedules itself
+	// required: false
+	// example: false
+	SupportsSchedules bool `json:"supportsSchedules"`
 }
 
 // RegisterResourceRequest a resource that can accept an access control list
@@ -75,6 +93,14 @@ type RegisterResourceRequest struct {
 	// required: false
 	// example: true
 	IsDefault bool `json:"isDefault"`
+	// RFIDFormat - how the resource expects rfid tags to be encoded.  Defaults to esp
+	// required: false
+	// example: esp
+	RFIDFormat rfid.Format `json:"rfidFormat"`
+	// SupportsSchedules - the resource enforces access schedules itself
+	// required: false
+	// example: false
+	SupportsSchedules bool `json:"supportsSchedules"`
 }
 
 // MemberResourceRelation  - a relationship between resources and members
@@ -84,6 +110,27 @@ type MemberResourceRelation struct {
 	ResourceID string `json:"resourceID"`
 }
 
+// ResourceAccessAction - whether access to a resource was granted or removed
+type ResourceAccessAction string
+
+const (
+	// ResourceAccessGranted - the member was added to the resource
+	ResourceAccessGranted ResourceAccessAction = "granted"
+	// ResourceAccessRemoved - the member was removed from the resource
+	ResourceAccessRemoved ResourceAccessAction = "removed"
+)
+
+// ResourceAccessChange - a record of who granted or removed a member's access to a resource
+type ResourceAccessChange struct {
+	ID         int64                `json:"id"`
+	MemberID   string               `json:"memberID"`
+	Email      string               `json:"email"`
+	ResourceID string               `json:"resourceID"`
+	Action     ResourceAccessAction `json:"action"`
+	ChangedBy  string               `json:"changedBy"`
+	ChangedAt  time.Time            `json:"changedAt"`
+}
+
 var resourceHeartBeatCache map[string]time.Time
 
 // ResourceHeartbeat stores the most recent timestamp that a resource checked in
@@ -112,7 +159,7 @@ func (db *Database) GetResources() []Resource {
 
 	for rows.Next() {
 		var r Resource
-		_ = rows.Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault)
+		_ = rows.Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault, &r.RFIDFormat, &r.SupportsSchedules)
 
 		r.LastHeartBeat = GetLastHeartbeat(r)
 		resources = append(resources, r)
@@ -125,7 +172,7 @@ func (db *Database) GetResources() []Resource {
 func (db *Database) GetResourceByID(ID string) (Resource, error) {
 	var r Resource
 
-	err := db.getConn().QueryRow(db.ctx, resourceDbMethod.getResourceByID(), ID).Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault)
+	err := db.getConn().QueryRow(db.ctx, resourceDbMethod.getResourceByID(), ID).Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault, &r.RFIDFormat, &r.SupportsSchedules)
 	if err != nil {
 		return r, fmt.Errorf("conn.Query failed: %v", err)
 	}
@@ -137,7 +184,7 @@ func (db *Database) GetResourceByID(ID string) (Resource, error) {
 func (db *Database) GetResourceByName(resourceName string) (Resource, error) {
 	var r Resource
 
-	err := db.getConn().QueryRow(db.ctx, resourceDbMethod.getResourceByName(), resourceName).Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault)
+	err := db.getConn().QueryRow(db.ctx, resourceDbMethod.getResourceByName(), resourceName).Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault, &r.RFIDFormat, &r.SupportsSchedules)
 	if err != nil {
 		return r, fmt.Errorf("getResourceByName failed: %v", err)
 	}
@@ -146,14 +193,24 @@ func (db *Database) GetResourceByName(resourceName string) (Resource, error) {
 }
 
 // RegisterResource - stores a new resource in the db
-func (db *Database) RegisterResource(name string, address string, isDefault bool) (*Resource, error) {
+func (db *Database) RegisterResource(name string, address string, isDefault bool, format rfid.Format, supportsSchedules bool) (*Resource, error) {
 	r := &Resource{}
 
+	if format == "" {
+		format = rfid.DefaultFormat
+	}
+
+	if !rfid.Formats[format] {
+		return r, fmt.Errorf("unknown rfid format: %s", format)
+	}
+
 	r.Name = name
 	r.Address = address
 	r.IsDefault = isDefault
+	r.RFIDFormat = format
+	r.SupportsSchedules = supportsSchedules
 
-	_, err := db.getConn().Exec(db.ctx, resourceDbMethod.insertResource(), r.Name, r.Address, r.IsDefault)
+	_, err := db.getConn().Exec(db.ctx, resourceDbMethod.insertResource(), r.Name, r.Address, r.IsDefault, string(r.RFIDFormat), r.SupportsSchedules)
 	if err != nil {
 		return r, fmt.Errorf("error inserting resource: %s", err.Error())
 	}
@@ -162,7 +219,8 @@ func (db *Database) RegisterResource(name string, address string, isDefault bool
 }
 
 // UpdateResource - updates a resource in the db
-func (db *Database) UpdateResource(id string, name string, address string, isDefault bool) (*Resource, error) {
+//   the rfid format is left unchanged if it is empty
+func (db *Database) UpdateResource(id string, name string, address string, isDefault bool, format rfid.Format, supportsSchedules bool) (*Resource, error) {
 	r := &Resource{}
 
 	// if the resource doesn't already exist let's register it
@@ -171,7 +229,11 @@ func (db *Database) UpdateResource(id string, name string, address string, isDef
 		return r, errors.New("invalid resourseID of 0")
 	}
 
-	row := db.getConn().QueryRow(db.ctx, resourceDbMethod.updateResource(), id, name, address, isDefault).Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault)
+	if format != "" && !rfid.Formats[format] {
+		return r, fmt.Errorf("unknown rfid format: %s", format)
+	}
+
+	row := db.getConn().QueryRow(db.ctx, resourceDbMethod.updateResource(), id, name, address, isDefault, string(format), supportsSchedules).Scan(&r.ID, &r.Name, &r.Address, &r.IsDefault, &r.RFIDFormat, &r.SupportsSchedules)
 	if row == pgx.ErrNoRows {
 		log.Printf("no rows affected %s", row.Error())
 		return r, errors.New("no rows affected")
@@ -193,7 +255,30 @@ func (db *Database) DeleteResource(id string) error {
 }
 
 // AddMultipleMembersToResource grant multiple members access to a resource
-func (db *Database) AddMultipleMembersToResource(emails []string, resourceID string) ([]MemberResourceRelation, error) {
+//   nobody is added if a member doesn't have the certifications the resource requires.
+//   Each grant is logged with who made it.
+func (db *Database) AddMultipleMembersToResource(emails []string, resourceID string, grantedBy string) ([]MemberResourceRelation, error) {
+	tx, err := db.getConn().Begin(db.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer tx.Rollback(db.ctx)
+
+	membersResource, err := db.addMultipleMembersToResource(tx, emails, resourceID, grantedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(db.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error committing resource members: %v", err)
+	}
+
+	return membersResource, nil
+}
+
+func (db *Database) addMultipleMembersToResource(q querier, emails []string, resourceID string, grantedBy string) ([]MemberResourceRelation, error) {
 
 	var membersResource []MemberResourceRelation
 
@@ -204,20 +289,37 @@ func (db *Database) AddMultipleMembersToResource(emails []string, resourceID str
 	}
 
 	for i := 0; i < len(emails); i++ {
-		member, err := db.GetMemberByEmail(emails[i])
+		member, err := db.getMemberByEmail(q, emails[i])
 
 		if err != nil {
 			return membersResource, err
 		}
 
+		missing, err := db.getMissingCertifications(q, member.ID, resource.ID)
+		if err != nil {
+			return membersResource, err
+		}
+
+		if len(missing) > 0 {
+			return membersResource, MissingCertificationsError{Email: member.Email, Resource: resource.Name, Missing: missing}
+		}
+
 		var memberResource MemberResourceRelation
 		memberResource.MemberID = member.ID
 		memberResource.ResourceID = resource.ID
 
-		row := db.getConn().QueryRow(db.ctx, resourceDbMethod.insertMemberResource(), memberResource.MemberID, memberResource.ResourceID).Scan(&memberResource.ID, &memberResource.MemberID, &memberResource.ResourceID)
-		if row == pgx.ErrNoRows {
+		err = q.QueryRow(db.ctx, resourceDbMethod.insertMemberResource(), memberResource.MemberID, memberResource.ResourceID).Scan(&memberResource.ID, &memberResource.MemberID, &memberResource.ResourceID)
+		if err == pgx.ErrNoRows {
 			return membersResource, errors.New("no rows affected")
 		}
+		if err != nil {
+			return membersResource, fmt.Errorf("error adding member to resource: %v", err)
+		}
+
+		_, err = q.Exec(db.ctx, resourceDbMethod.insertResourceAccessLog(), memberResource.MemberID, memberResource.ResourceID, string(ResourceAccessGranted), grantedBy)
+		if err != nil {
+			return membersResource, fmt.Errorf("error logging resource access: %v", err)
+		}
 
 		membersResource = append(membersResource, memberResource)
 
@@ -229,12 +331,16 @@ func (db *Database) AddMultipleMembersToResource(emails []string, resourceID str
 
 // AddUserToDefaultResources - grants a user access to default resources - untested
 func (db *Database) AddUserToDefaultResources(email string) ([]MemberResourceRelation, error) {
-	m, err := db.GetMemberByEmail(email)
+	return db.addUserToDefaultResources(db.getConn(), email)
+}
+
+func (db *Database) addUserToDefaultResources(q querier, email string) ([]MemberResourceRelation, error) {
+	m, err := db.getMemberByEmail(q, email)
 	if err != nil {
 		return []MemberResourceRelation{}, err
 	}
 
-	rows, err := db.getConn().Query(db.ctx, resourceDbMethod.insertMemberDefaultResource(), m.ID)
+	rows, err := q.Query(db.ctx, resourceDbMethod.insertMemberDefaultResource(), m.ID)
 	if err != nil {
 		log.Errorf("conn.Query failed: %v", err)
 	}
@@ -264,7 +370,8 @@ func (db *Database) GetMemberResourceRelation(m Member, r Resource) (MemberResou
 }
 
 // RemoveUserFromResource - removes a users access to a resource
-func (db *Database) RemoveUserFromResource(email string, resourceID string) error {
+//   the removal is logged with who made it
+func (db *Database) RemoveUserFromResource(email string, resourceID string, removedBy string) error {
 	memberResource := MemberResourceRelation{}
 
 	r, err := db.GetResourceByID(resourceID)
@@ -291,13 +398,55 @@ func (db *Database) RemoveUserFromResource(email string, resourceID string) erro
 		return errors.New("No row found to delete")
 	}
 
+	_, err = db.getConn().Exec(db.ctx, resourceDbMethod.insertResourceAccessLog(), memberResource.MemberID, memberResource.ResourceID, string(ResourceAccessRemoved), removedBy)
+	if err != nil {
+		log.Errorf("error logging resource access removal: %s", err)
+	}
+
 	return nil
 }
 
+// GetResourceAccessLog - who granted and removed access to a resource, newest first
+func (db *Database) GetResourceAccessLog(resourceID string) ([]ResourceAccessChange, error) {
+	var changes []ResourceAccessChange
+
+	rows, err := db.getConn().Query(db.ctx, resourceDbMethod.getResourceAccessLog(), resourceID)
+	if err != nil {
+		return changes, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var c ResourceAccessChange
+		var action string
+
+		err = rows.Scan(&c.ID, &c.MemberID, &c.Email, &c.ResourceID, &action, &c.ChangedBy, &c.ChangedAt)
+		if err != nil {
+			return changes, fmt.Errorf("error reading resource access log: %v", err)
+		}
+
+		c.Action = ResourceAccessAction(action)
+
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}
+
 // GetResourceACL returns a list of members that have access to that Resource
+//   every active credential of the members is encoded in the resource's rfid format.
+//   Members whose schedule is closed are left off unless the resource supports schedules.
 func (db *Database) GetResourceACL(r Resource) ([]string, error) {
 	var accessList []string
 
+	schedules, err := db.GetResourceSchedules(r.ID)
+	if err != nil {
+		return accessList, err
+	}
+
+	now := time.Now().In(ScheduleLocation())
+
 	rows, err := db.getConn().Query(db.ctx, resourceDbMethod.getResourceACLByResourceID(), r.ID)
 	if err != nil {
 		return accessList, fmt.Errorf("conn.Query failed: %v", err)
@@ -306,17 +455,38 @@ func (db *Database) GetResourceACL(r Resource) ([]string, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var rfid string
-		rows.Scan(&rfid)
-		accessList = append(accessList, rfid)
+		var uid, legacyRFID *string
+		var level MemberLevel
+		rows.Scan(&uid, &legacyRFID, &level)
+
+		if !schedules.allowed(r, level, now) {
+			continue
+		}
+
+		tag, err := renderTag(r.RFIDFormat, uid, legacyRFID)
+		if err != nil {
+			log.Errorf("error encoding tag for %s: %s", r.Name, err)
+			continue
+		}
+
+		accessList = append(accessList, tag)
 	}
 
 	return accessList, nil
 }
 
 // GetResourceACLWithMemberInfo returns a list of members that have access to that Resource
-func (db *Database) GetResourceACLWithMemberInfo(r Resource) ([]Member, error) {
-	var accessList []Member
+//   a member is listed once for each of their active credentials.
+//   Members whose schedule is closed are left off unless the resource supports schedules.
+func (db *Database) GetResourceACLWithMemberInfo(r Resource) ([]MemberAccess, error) {
+	var accessList []MemberAccess
+
+	schedules, err := db.GetResourceSchedules(r.ID)
+	if err != nil {
+		return accessList, err
+	}
+
+	now := time.Now().In(ScheduleLocation())
 
 	rows, err := db.getConn().Query(db.ctx, resourceDbMethod.getResourceACLByResourceIDQueryWithMemberInfo(), r.ID)
 	if err != nil {
@@ -326,11 +496,30 @@ func (db *Database) GetResourceACLWithMemberInfo(r Resource) ([]Member, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var member Member
+		access := MemberAccess{
+			ResourceAddress: r.Address,
+			ResourceName:    r.Name,
+		}
+		var level MemberLevel
+		var uid, legacyRFID *string
+
+		rows.Scan(&access.Email, &access.Name, &level, &uid, &legacyRFID)
 
-		rows.Scan(&member.ID, &member.Name, &member.RFID)
+		if !schedules.allowed(r, level, now) {
+			continue
+		}
+
+		access.RFID, err = renderTag(r.RFIDFormat, uid, legacyRFID)
+		if err != nil {
+			log.Errorf("error encoding tag of %s for %s: %s", access.Name, r.Name, err)
+			continue
+		}
+
+		if r.SupportsSchedules {
+			access.Schedule = schedules.ForLevel(level).Windows()
+		}
 
-		accessList = append(accessList, member)
+		accessList = append(accessList, access)
 	}
 
 	return accessList, nil
@@ -344,6 +533,8 @@ type MemberAccess struct {
 	ResourceName    string
 	Name            string
 	RFID            string
+	// Schedule - when the member can use a resource that supports schedules.  Empty when it isn't limited
+	Schedule []ScheduleWindow
 }
 
 // GetMembersAccess returns a list of a specific members access
@@ -351,6 +542,13 @@ type MemberAccess struct {
 func (db *Database) GetMembersAccess(m Member) ([]MemberAccess, error) {
 	var memberAccess []MemberAccess
 
+	schedules, err := db.GetSchedules()
+	if err != nil {
+		return memberAccess, err
+	}
+
+	now := time.Now().In(ScheduleLocation())
+
 	rows, err := db.getConn().Query(db.ctx, resourceDbMethod.getResourceACLByEmail(), m.Email)
 	if err != nil {
 		return memberAccess, fmt.Errorf("error getting members access info: %s", err)
@@ -360,8 +558,26 @@ func (db *Database) GetMembersAccess(m Member) ([]MemberAccess, error) {
 
 	for rows.Next() {
 		var resourceUpdate MemberAccess
+		var r Resource
+		var level MemberLevel
+		var uid, legacyRFID *string
+
+		rows.Scan(&resourceUpdate.Email, &r.ID, &resourceUpdate.ResourceAddress, &resourceUpdate.ResourceName, &r.RFIDFormat, &r.SupportsSchedules, &resourceUpdate.Name, &level, &uid, &legacyRFID)
 
-		rows.Scan(&resourceUpdate.Email, &resourceUpdate.ResourceAddress, &resourceUpdate.ResourceName, &resourceUpdate.Name, &resourceUpdate.RFID)
+		resourceSchedules := schedules.ForResource(r.ID)
+		if !resourceSchedules.allowed(r, level, now) {
+			continue
+		}
+
+		resourceUpdate.RFID, err = renderTag(r.RFIDFormat, uid, legacyRFID)
+		if err != nil {
+			log.Errorf("error encoding tag of %s for %s: %s", resourceUpdate.Name, resourceUpdate.ResourceName, err)
+			continue
+		}
+
+		if r.SupportsSchedules {
+			resourceUpdate.Schedule = resourceSchedules.ForLevel(level).Windows()
+		}
 
 		memberAccess = append(memberAccess, resourceUpdate)
 	}
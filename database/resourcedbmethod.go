@@ -4,7 +4,7 @@ package database
 type ResourceDatabaseMethod struct{}
 
 func (resource *ResourceDatabaseMethod) getResource() string {
-	const getResourceQuery = `SELECT id, description, device_identifier, is_default 
+	const getResourceQuery = `SELECT id, description, device_identifier, is_default, rfid_format, supports_schedules
 	FROM membership.resources
 	ORDER BY description;`
 
@@ -13,18 +13,18 @@ func (resource *ResourceDatabaseMethod) getResource() string {
 
 func (resource *ResourceDatabaseMethod) insertResource() string {
 	const insertResourceQuery = `INSERT INTO membership.resources(
-		description, device_identifier, is_default)
-		VALUES ($1, $2, $3)
-		RETURNING *;`
+		description, device_identifier, is_default, rfid_format, supports_schedules)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;`
 
 	return insertResourceQuery
 }
 
 func (resource *ResourceDatabaseMethod) updateResource() string {
 	const updateResourceQuery = `UPDATE membership.resources
-	SET description=$2, device_identifier=$3, is_default=$4
+	SET description=$2, device_identifier=$3, is_default=$4, rfid_format=COALESCE(NULLIF($5, ''), rfid_format), supports_schedules=$6
 	WHERE id=$1
-	RETURNING *;
+	RETURNING id, description, device_identifier, is_default, rfid_format, supports_schedules;
 	`
 
 	return updateResourceQuery
@@ -38,7 +38,7 @@ func (resource *ResourceDatabaseMethod) deleteResource() string {
 }
 
 func (resource *ResourceDatabaseMethod) getResourceByName() string {
-	const getResourceByNameQuery = `SELECT id, description, device_identifier, is_default
+	const getResourceByNameQuery = `SELECT id, description, device_identifier, is_default, rfid_format, supports_schedules
 	FROM membership.resources
 	WHERE description = $1;`
 
@@ -46,7 +46,7 @@ func (resource *ResourceDatabaseMethod) getResourceByName() string {
 }
 
 func (resource *ResourceDatabaseMethod) getResourceByID() string {
-	const getResourceByIDQuery = `SELECT id, description, device_identifier, is_default
+	const getResourceByIDQuery = `SELECT id, description, device_identifier, is_default, rfid_format, supports_schedules
 	FROM membership.resources
 	WHERE id = $1;`
 
@@ -54,35 +54,59 @@ func (resource *ResourceDatabaseMethod) getResourceByID() string {
 }
 
 func (resource *ResourceDatabaseMethod) getResourceACLByResourceID() string {
-	const getResourceACLByResourceIDQuery = `SELECT rfid
+	// members need the certifications the resource requires
+	//  guests are included while their pass is valid, they don't have a tier or certifications
+	const getResourceACLByResourceIDQuery = `SELECT c.uid, c.legacy_rfid, member_tier_id
 	FROM membership.member_resource
-	LEFT JOIN membership.members
+	INNER JOIN membership.members
 	ON membership.member_resource.member_id = membership.members.id
+	INNER JOIN membership.member_credentials c
+	ON c.member_id = membership.members.id
 	WHERE resource_id = $1
-	AND rfid is not NULL;`
+	AND c.status = 'active'
+	AND archived_at IS NULL
+	AND ` + hasRequiredCertifications + `
+	UNION ALL
+	SELECT g.uid, NULL, 0
+	FROM membership.guest_resource gr
+	INNER JOIN membership.guests g
+	ON g.id = gr.guest_id
+	WHERE gr.resource_id = $1
+	AND g.removed_at IS NULL
+	AND g.starts_at <= NOW()
+	AND g.ends_at > NOW()
+	AND ` + guestResourceWithoutCertifications + `;`
 
 	return getResourceACLByResourceIDQuery
 }
 
 func (resource *ResourceDatabaseMethod) getResourceACLByResourceIDQueryWithMemberInfo() string {
-	const getResourceACLByResourceIDQueryWithMemberInfoQuery = `SELECT member_id, name, rfid
+	const getResourceACLByResourceIDQueryWithMemberInfoQuery = `SELECT email, name, member_tier_id, c.uid, c.legacy_rfid
 	FROM membership.member_resource
-	LEFT JOIN membership.members
+	INNER JOIN membership.members
 	ON membership.member_resource.member_id = membership.members.id
+	INNER JOIN membership.member_credentials c
+	ON c.member_id = membership.members.id
 	WHERE resource_id = $1
-	AND rfid is not NULL;`
+	AND c.status = 'active'
+	AND archived_at IS NULL
+	AND ` + hasRequiredCertifications + `;`
 
 	return getResourceACLByResourceIDQueryWithMemberInfoQuery
 }
 
 func (resource *ResourceDatabaseMethod) getResourceACLByEmail() string {
-	return `SELECT email, device_identifier, description, name, rfid
+	return `SELECT email, resource_id, device_identifier, description, rfid_format, supports_schedules, name, member_tier_id, c.uid, c.legacy_rfid
 	FROM membership.member_resource
 	LEFT JOIN membership.members
 	ON membership.member_resource.member_id = membership.members.id
 	LEFT JOIN membership.resources
 	ON membership.member_resource.resource_id = membership.resources.id 
-	WHERE rfid is not NULL and email = $1;`
+	INNER JOIN membership.member_credentials c
+	ON c.member_id = membership.members.id
+	WHERE c.status = 'active' and email = $1
+	AND archived_at IS NULL
+	AND ` + hasRequiredCertifications + `;`
 }
 
 func (resource *ResourceDatabaseMethod) getMemberResource() string {
@@ -94,10 +118,13 @@ func (resource *ResourceDatabaseMethod) getMemberResource() string {
 }
 
 func (resource *ResourceDatabaseMethod) insertMemberResource() string {
+	// a manual grant takes over a row that was granted by the member's tier
+	//  so the member keeps the resource when their tier changes
 	const insertMemberResourceQuery = `INSERT INTO membership.member_resource(
 		member_id, resource_id)
 		VALUES ($1, $2)
-		RETURNING *;`
+		ON CONFLICT (member_id, resource_id) DO UPDATE SET granted_by_tier = false
+		RETURNING id, member_id, resource_id;`
 
 	return insertMemberResourceQuery
 }
@@ -105,7 +132,7 @@ func (resource *ResourceDatabaseMethod) insertMemberResource() string {
 func (resource *ResourceDatabaseMethod) insertMemberDefaultResource() string {
 	const insertMemberDefaultResourceQuery = `INSERT INTO membership.member_resource(member_id, resource_id)
 	VALUES($1, unnest( ARRAY(SELECT resources.id FROM membership.resources AS resources WHERE resources.is_default IS TRUE)))
-	RETURNING *;`
+	RETURNING id, member_id, resource_id;`
 
 	return insertMemberDefaultResourceQuery
 }
@@ -117,13 +144,36 @@ func (resource *ResourceDatabaseMethod) removeMemberResource() string {
 	return removeMemberResourceQuery
 }
 
+func (resource *ResourceDatabaseMethod) insertResourceAccessLog() string {
+	const insertResourceAccessLogQuery = `INSERT INTO membership.resource_access_log(
+		member_id, resource_id, action, changed_by)
+		VALUES ($1, $2, $3, $4);`
+
+	return insertResourceAccessLogQuery
+}
+
+func (resource *ResourceDatabaseMethod) getResourceAccessLog() string {
+	const getResourceAccessLogQuery = `SELECT l.id, l.member_id, m.email, l.resource_id, l.action, l.changed_by, l.changed_at
+	FROM membership.resource_access_log l
+	INNER JOIN membership.members m
+	ON m.id = l.member_id
+	WHERE l.resource_id = $1
+	ORDER BY l.changed_at DESC, l.id DESC;`
+
+	return getResourceAccessLogQuery
+}
+
 func (resource *ResourceDatabaseMethod) getAccessList() string {
 	// getAccessListQuery - get a list of rfid tags that belong to an active member
 	// that have access to a specified resource
-	const getAccessListQuery = `SELECT rfid
+	const getAccessListQuery = `SELECT c.uid, c.legacy_rfid
 	FROM membership.member_resource
 	INNER JOIN membership.members on (member_resource.member_id = members.id)
-	WHERE resource_id = $1 AND member_tier_id > 1;`
+	INNER JOIN membership.member_credentials c on (c.member_id = members.id)
+	WHERE resource_id = $1 AND member_tier_id > 1
+	AND c.status = 'active'
+	AND archived_at IS NULL
+	AND ` + hasRequiredCertifications + `;`
 
 	return getAccessListQuery
 }
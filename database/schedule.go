@@ -0,0 +1,319 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"memberserver/config"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+var scheduleDbMethod ScheduleDatabaseMethod
+
+// ScheduleWindow - the days and time of day that a resource can be accessed.
+//   Start and End are in 24 hour HH:MM in the configured time zone.
+//   A window that ends before it starts runs overnight into the next day
+//   and a window that starts and ends at the same time runs all day.
+//   This is how the schedule is sent to resources that support schedules.
+type ScheduleWindow struct {
+	// Days - the days of the week the window opens on, 0 is Sunday
+	// required: true
+	// example: [1, 2, 3, 4, 5]
+	Days []int `json:"days"`
+	// Start - when the window opens
+	// required: true
+	// example: 09:00
+	Start string `json:"start"`
+	// End - when the window closes
+	// required: true
+	// example: 22:00
+	End string `json:"end"`
+}
+
+// Schedule - limits access to a resource to a window of time.
+//   A schedule without a tier applies to every member of the resource.
+//   A schedule with a tier applies to the members of that tier instead of the resource's schedules.
+//   A resource without schedules can be accessed at any time.
+type Schedule struct {
+	ID         int64  `json:"id"`
+	ResourceID string `json:"resourceID"`
+	// Level - the tier the schedule applies to.  Empty when it applies to every member
+	Level *MemberLevel `json:"level"`
+	Name  string       `json:"name"`
+	ScheduleWindow
+}
+
+// Schedules - the schedules of a resource
+type Schedules []Schedule
+
+var scheduleLocation *time.Location
+var scheduleLocationOnce sync.Once
+
+// ScheduleLocation - the time zone that schedules are written in
+func ScheduleLocation() *time.Location {
+	scheduleLocationOnce.Do(func() {
+		conf, _ := config.Load()
+
+		loc, err := time.LoadLocation(conf.TimeZone)
+		if err != nil {
+			log.Errorf("error loading time zone %s, using local time for schedules: %s", conf.TimeZone, err)
+			loc = time.Local
+		}
+
+		scheduleLocation = loc
+	})
+
+	return scheduleLocation
+}
+
+func parseClock(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 {
+		return 0, fmt.Errorf("%s is not a time of day, use HH:MM", s)
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("%s is not a time of day, use HH:MM", s)
+	}
+
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("%s is not a time of day, use HH:MM", s)
+	}
+
+	return hour*60 + minute, nil
+}
+
+// Validate - makes sure the window has days and the times are HH:MM
+func (w ScheduleWindow) Validate() error {
+	if len(w.Days) == 0 {
+		return errors.New("a schedule needs at least one day")
+	}
+
+	for _, d := range w.Days {
+		if d < int(time.Sunday) || d > int(time.Saturday) {
+			return fmt.Errorf("%d is not a day of the week, use 0 for Sunday through 6 for Saturday", d)
+		}
+	}
+
+	_, err := parseClock(w.Start)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseClock(w.End)
+	return err
+}
+
+func (w ScheduleWindow) hasDay(d time.Weekday) bool {
+	for _, day := range w.Days {
+		if day == int(d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenAt - whether the window is open at t.  t should be in the ScheduleLocation
+func (w ScheduleWindow) OpenAt(t time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	today := w.hasDay(t.Weekday())
+
+	switch {
+	case start == end:
+		return today
+	case start < end:
+		return today && minute >= start && minute < end
+	default:
+		// overnight windows are still open from the day before until they end
+		yesterday := w.hasDay((t.Weekday() + 6) % 7)
+		return (today && minute >= start) || (yesterday && minute < end)
+	}
+}
+
+// ForLevel - the schedules that apply to members of a tier.
+//   Schedules for the tier take the place of the resource's schedules.
+//   Guests don't have a tier so they get the resource's schedules.
+func (s Schedules) ForLevel(level MemberLevel) Schedules {
+	var resourceSchedules, tierSchedules Schedules
+
+	for _, schedule := range s {
+		if schedule.Level == nil {
+			resourceSchedules = append(resourceSchedules, schedule)
+			continue
+		}
+
+		if *schedule.Level == level {
+			tierSchedules = append(tierSchedules, schedule)
+		}
+	}
+
+	if len(tierSchedules) > 0 {
+		return tierSchedules
+	}
+
+	return resourceSchedules
+}
+
+// ForResource - the schedules of a resource
+func (s Schedules) ForResource(resourceID string) Schedules {
+	var schedules Schedules
+
+	for _, schedule := range s {
+		if schedule.ResourceID == resourceID {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	return schedules
+}
+
+// OpenAt - whether any of the schedules are open at t.  No schedules are always open.
+func (s Schedules) OpenAt(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	for _, schedule := range s {
+		if schedule.OpenAt(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ChangedBetween - whether any of the schedules opened or closed between from and to
+func (s Schedules) ChangedBetween(from time.Time, to time.Time) bool {
+	for _, schedule := range s {
+		if schedule.OpenAt(from) != schedule.OpenAt(to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Windows - the schedules as they are sent to a resource.  Empty when access isn't limited
+func (s Schedules) Windows() []ScheduleWindow {
+	var windows []ScheduleWindow
+
+	for _, schedule := range s {
+		windows = append(windows, schedule.ScheduleWindow)
+	}
+
+	return windows
+}
+
+// allowed - whether a member of the tier should be on a resource's access list right now.
+//   Resources that support schedules get everyone and enforce the schedule themselves.
+func (s Schedules) allowed(r Resource, level MemberLevel, now time.Time) bool {
+	return r.SupportsSchedules || s.ForLevel(level).OpenAt(now)
+}
+
+func scanSchedule(row pgx.Row) (Schedule, error) {
+	var s Schedule
+
+	err := row.Scan(&s.ID, &s.ResourceID, &s.Level, &s.Name, &s.Days, &s.Start, &s.End)
+
+	return s, err
+}
+
+func (db *Database) getSchedules(query string, args ...interface{}) (Schedules, error) {
+	var schedules Schedules
+
+	rows, err := db.getConn().Query(db.ctx, query, args...)
+	if err != nil {
+		return schedules, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return schedules, fmt.Errorf("error reading schedule: %v", err)
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, nil
+}
+
+// GetSchedules - every resource's schedules
+func (db *Database) GetSchedules() (Schedules, error) {
+	return db.getSchedules(scheduleDbMethod.getSchedules())
+}
+
+// GetResourceSchedules - the schedules of a resource
+func (db *Database) GetResourceSchedules(resourceID string) (Schedules, error) {
+	return db.getSchedules(scheduleDbMethod.getResourceSchedules(), resourceID)
+}
+
+// GetScheduleByID - lookup a schedule by its id
+func (db *Database) GetScheduleByID(id int64) (Schedule, error) {
+	s, err := scanSchedule(db.getConn().QueryRow(db.ctx, scheduleDbMethod.getScheduleByID(), id))
+	if err != nil {
+		return s, fmt.Errorf("error getting schedule: %v", err)
+	}
+
+	return s, nil
+}
+
+// AddSchedule - limits access to a resource to the schedule
+func (db *Database) AddSchedule(s Schedule) (Schedule, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+
+	s, err = scanSchedule(db.getConn().QueryRow(db.ctx, scheduleDbMethod.insertSchedule(), s.ResourceID, s.Level, s.Name, s.Days, s.Start, s.End))
+	if err != nil {
+		return s, fmt.Errorf("error adding schedule: %v", err)
+	}
+
+	return s, nil
+}
+
+// UpdateSchedule - changes the tier, name and window of a schedule
+func (db *Database) UpdateSchedule(s Schedule) (Schedule, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+
+	s, err = scanSchedule(db.getConn().QueryRow(db.ctx, scheduleDbMethod.updateSchedule(), s.ID, s.Level, s.Name, s.Days, s.Start, s.End))
+	if err != nil {
+		return s, fmt.Errorf("error updating schedule: %v", err)
+	}
+
+	return s, nil
+}
+
+// DeleteSchedule - removes a schedule and returns it
+func (db *Database) DeleteSchedule(id int64) (Schedule, error) {
+	s, err := scanSchedule(db.getConn().QueryRow(db.ctx, scheduleDbMethod.deleteSchedule(), id))
+	if err != nil {
+		return s, fmt.Errorf("error deleting schedule: %v", err)
+	}
+
+	return s, nil
+}
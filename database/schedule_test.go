@@ -0,0 +1,98 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+var weekdays = []int{1, 2, 3, 4, 5}
+
+func at(day int, clock string) time.Time {
+	// 2021-06-06 is a Sunday
+	t, _ := time.Parse("2006-01-02 15:04", "2021-06-06 "+clock)
+	return t.AddDate(0, 0, day)
+}
+
+func TestScheduleWindowOpenAt(t *testing.T) {
+	tests := []struct {
+		window   ScheduleWindow
+		t        time.Time
+		expected bool
+	}{
+		{ScheduleWindow{Days: weekdays, Start: "09:00", End: "22:00"}, at(1, "09:00"), true},
+		{ScheduleWindow{Days: weekdays, Start: "09:00", End: "22:00"}, at(1, "22:00"), false},
+		{ScheduleWindow{Days: weekdays, Start: "09:00", End: "22:00"}, at(1, "08:59"), false},
+		{ScheduleWindow{Days: weekdays, Start: "09:00", End: "22:00"}, at(0, "12:00"), false},
+		// overnight windows run into the next day
+		{ScheduleWindow{Days: []int{5}, Start: "22:00", End: "02:00"}, at(5, "23:30"), true},
+		{ScheduleWindow{Days: []int{5}, Start: "22:00", End: "02:00"}, at(6, "01:59"), true},
+		{ScheduleWindow{Days: []int{5}, Start: "22:00", End: "02:00"}, at(5, "01:00"), false},
+		{ScheduleWindow{Days: []int{6}, Start: "22:00", End: "02:00"}, at(7, "01:00"), true},
+		// starting and ending at the same time runs all day
+		{ScheduleWindow{Days: []int{0}, Start: "00:00", End: "00:00"}, at(0, "23:59"), true},
+		{ScheduleWindow{Days: []int{0}, Start: "00:00", End: "00:00"}, at(1, "00:00"), false},
+	}
+
+	for _, test := range tests {
+		if test.window.OpenAt(test.t) != test.expected {
+			t.Errorf("%+v at %s: expected %v", test.window, test.t.Format("Mon 15:04"), test.expected)
+		}
+	}
+}
+
+func TestScheduleWindowValidate(t *testing.T) {
+	invalid := []ScheduleWindow{
+		{Start: "09:00", End: "22:00"},
+		{Days: []int{7}, Start: "09:00", End: "22:00"},
+		{Days: weekdays, Start: "9:00", End: "22:00"},
+		{Days: weekdays, Start: "09:00", End: "24:00"},
+	}
+
+	for _, w := range invalid {
+		if w.Validate() == nil {
+			t.Errorf("expected %+v to be invalid", w)
+		}
+	}
+
+	if err := (ScheduleWindow{Days: weekdays, Start: "22:00", End: "06:00"}).Validate(); err != nil {
+		t.Errorf("expected an overnight window to be valid: %v", err)
+	}
+}
+
+func TestSchedulesForLevel(t *testing.T) {
+	classic := Classic
+	schedules := Schedules{
+		{ID: 1, ScheduleWindow: ScheduleWindow{Days: []int{0, 1, 2, 3, 4, 5, 6}, Start: "06:00", End: "00:00"}},
+		{ID: 2, Level: &classic, ScheduleWindow: ScheduleWindow{Days: weekdays, Start: "09:00", End: "22:00"}},
+	}
+
+	if s := schedules.ForLevel(Classic); len(s) != 1 || s[0].ID != 2 {
+		t.Errorf("expected the classic schedule to replace the resource schedule, got %+v", s)
+	}
+
+	if s := schedules.ForLevel(Premium); len(s) != 1 || s[0].ID != 1 {
+		t.Errorf("expected premium members to get the resource schedule, got %+v", s)
+	}
+
+	if !(Schedules{}).OpenAt(at(0, "03:00")) {
+		t.Error("expected a resource without schedules to always be open")
+	}
+
+	if schedules.ForLevel(Premium).OpenAt(at(0, "03:00")) {
+		t.Error("expected the resource to be closed overnight")
+	}
+}
+
+func TestSchedulesChangedBetween(t *testing.T) {
+	schedules := Schedules{
+		{ScheduleWindow: ScheduleWindow{Days: weekdays, Start: "09:00", End: "22:00"}},
+	}
+
+	if !schedules.ChangedBetween(at(1, "08:59"), at(1, "09:00")) {
+		t.Error("expected the schedule to open at 09:00")
+	}
+
+	if schedules.ChangedBetween(at(1, "09:00"), at(1, "09:01")) {
+		t.Error("expected the schedule to stay open")
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+// ScheduleDatabaseMethod -- method container that holds the extension methods to query the access schedules table
+type ScheduleDatabaseMethod struct{}
+
+const scheduleColumns = `id, resource_id, member_tier_id, name, days, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')`
+
+func (schedule *ScheduleDatabaseMethod) getSchedules() string {
+	return `SELECT ` + scheduleColumns + `
+	FROM membership.access_schedules
+	ORDER BY resource_id, member_tier_id NULLS FIRST, id;`
+}
+
+func (schedule *ScheduleDatabaseMethod) getResourceSchedules() string {
+	return `SELECT ` + scheduleColumns + `
+	FROM membership.access_schedules
+	WHERE resource_id = $1
+	ORDER BY member_tier_id NULLS FIRST, id;`
+}
+
+func (schedule *ScheduleDatabaseMethod) getScheduleByID() string {
+	return `SELECT ` + scheduleColumns + `
+	FROM membership.access_schedules
+	WHERE id = $1;`
+}
+
+func (schedule *ScheduleDatabaseMethod) insertSchedule() string {
+	return `INSERT INTO membership.access_schedules(
+		resource_id, member_tier_id, name, days, start_time, end_time)
+		VALUES ($1, $2, $3, $4, $5::text::time, $6::text::time)
+		RETURNING ` + scheduleColumns + `;`
+}
+
+func (schedule *ScheduleDatabaseMethod) updateSchedule() string {
+	return `UPDATE membership.access_schedules
+	SET member_tier_id=$2, name=$3, days=$4, start_time=$5::text::time, end_time=$6::text::time
+	WHERE id = $1
+	RETURNING ` + scheduleColumns + `;`
+}
+
+func (schedule *ScheduleDatabaseMethod) deleteSchedule() string {
+	return `DELETE FROM membership.access_schedules
+	WHERE id = $1
+	RETURNING ` + scheduleColumns + `;`
+}
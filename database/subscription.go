@@ -0,0 +1,42 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+var subscriptionDbMethod SubscriptionDatabaseMethod
+
+// GetMemberByStripeCustomer - lookup a member by the stripe customer they pay with
+func (db *Database) GetMemberByStripeCustomer(customerID string) (Member, error) {
+	var memberID string
+
+	err := db.getConn().QueryRow(db.ctx, subscriptionDbMethod.getMemberIDByStripeCustomer(), customerID).Scan(&memberID)
+	if err != nil {
+		return Member{}, err
+	}
+
+	return db.GetMemberByID(memberID)
+}
+
+// SetStripeCustomer - remembers the stripe customer a member pays with.
+//   A member that already has a customer keeps it.
+func (db *Database) SetStripeCustomer(memberID string, customerID string) error {
+	_, err := db.getConn().Exec(db.ctx, subscriptionDbMethod.setStripeCustomer(), memberID, customerID)
+	if err != nil {
+		return fmt.Errorf("error setting stripe customer: %v", err)
+	}
+
+	return nil
+}
+
+// SetSubscriptionCancelled - flags that the member cancelled their subscription.
+//   Pass nil when the member subscribes again.
+func (db *Database) SetSubscriptionCancelled(memberID string, cancelledAt *time.Time) error {
+	_, err := db.getConn().Exec(db.ctx, subscriptionDbMethod.setSubscriptionCancelled(), memberID, cancelledAt)
+	if err != nil {
+		return fmt.Errorf("error flagging cancelled subscription: %v", err)
+	}
+
+	return nil
+}
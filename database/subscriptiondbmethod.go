@@ -0,0 +1,35 @@
+package database
+
+// SubscriptionDatabaseMethod -- method container that holds the extension methods to query the members' payment subscriptions
+type SubscriptionDatabaseMethod struct{}
+
+func (subscription *SubscriptionDatabaseMethod) getMemberIDByStripeCustomer() string {
+	const getMemberIDByStripeCustomerQuery = `SELECT id
+	FROM membership.members
+	WHERE stripe_customer_id = $1;`
+
+	return getMemberIDByStripeCustomerQuery
+}
+
+func (subscription *SubscriptionDatabaseMethod) setStripeCustomer() string {
+	// a member keeps the first customer they paid with
+	const setStripeCustomerQuery = `UPDATE membership.members
+	SET stripe_customer_id = $2
+	WHERE id = $1
+	AND stripe_customer_id IS NULL
+	AND NOT EXISTS (
+		SELECT 1
+		FROM membership.members other
+		WHERE other.stripe_customer_id = $2
+	);`
+
+	return setStripeCustomerQuery
+}
+
+func (subscription *SubscriptionDatabaseMethod) setSubscriptionCancelled() string {
+	const setSubscriptionCancelledQuery = `UPDATE membership.members
+	SET subscription_cancelled_at = $2
+	WHERE id = $1;`
+
+	return setSubscriptionCancelledQuery
+}
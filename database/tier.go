@@ -2,15 +2,27 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 
+	"memberserver/config"
+
+	"github.com/Rhymond/go-money"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	log "github.com/sirupsen/logrus"
 )
 
 var tierDbMethod TierDatabaseMethod
 
-// MemberLevel enum
+// MemberLevel - the id of a member's tier
 type MemberLevel int
 
+// The tiers that are seeded with the database.
+//   Inactive and Credited are managed by the server and can't be changed or removed.
+//   Other tiers are managed by the admins, these are only here for the original tiers.
 const (
 	// Inactive $0
 	Inactive MemberLevel = iota + 1
@@ -24,44 +36,190 @@ const (
 	Premium
 )
 
-// MemberLevelFromAmount convert amount to MemberLevel
-var MemberLevelFromAmount = map[int64]MemberLevel{
-	0:  Inactive,
-	1:  Credited,
-	30: Classic,
-	35: Standard,
-	50: Premium,
-}
+// foreignKeyViolation - the postgres error code for a row that is still referenced
+const foreignKeyViolation = "23503"
 
-// MemberLevelToStr convert MemberLevel to string
-var MemberLevelToStr = map[MemberLevel]string{
-	Inactive: "Inactive",
-	Credited: "Credited",
-	Classic:  "Classic",
-	Standard: "Standard",
-	Premium:  "Premium",
+// BillingPeriod - how often the price of a tier is paid
+type BillingPeriod string
+
+const (
+	// BillingMonthly - the tier is paid every month
+	BillingMonthly BillingPeriod = "month"
+	// BillingYearly - the tier is paid every year
+	BillingYearly BillingPeriod = "year"
+)
+
+// BillingPeriods lists the periods a tier can be billed for
+var BillingPeriods = map[BillingPeriod]bool{
+	BillingMonthly: true,
+	BillingYearly:  true,
 }
 
 // Tier - level of membership
 type Tier struct {
 	ID   uint8  `json:"id"`
 	Name string `json:"level"`
+	// Price - the payment amount in minor units, i.e. cents, that puts a member on this tier
+	Price int64 `json:"price"`
+	// Currency - the currency code of the price, i.e. USD
+	Currency      string        `json:"currency"`
+	BillingPeriod BillingPeriod `json:"billingPeriod"`
+	// Active - only active tiers are matched to payments
+	Active    bool `json:"active"`
+	SortOrder int  `json:"sortOrder"`
+	// Protected - the tier is managed by the server.  Only its name and sort order can change
+	Protected bool `json:"protected"`
+}
+
+// Tiers - the membership tiers in their sort order
+type Tiers []Tier
+
+// IsProtected - whether the tier is managed by the server
+func (l MemberLevel) IsProtected() bool {
+	return l == Inactive || l == Credited
+}
+
+// ByID - lookup a tier by its id
+func (t Tiers) ByID(level MemberLevel) (Tier, bool) {
+	for _, tier := range t {
+		if MemberLevel(tier.ID) == level {
+			return tier, true
+		}
+	}
+
+	return Tier{}, false
+}
+
+// ByName - lookup a tier by its name, ignoring case
+func (t Tiers) ByName(name string) (Tier, bool) {
+	for _, tier := range t {
+		if strings.EqualFold(tier.Name, name) {
+			return tier, true
+		}
+	}
+
+	return Tier{}, false
+}
+
+var paymentTolerance int64
+var paymentToleranceOnce sync.Once
+
+// PaymentTolerance - how many minor units a payment can be off from a tier's price and still match it.
+//   Covers payments that were rounded or had a fee taken out.
+func PaymentTolerance() int64 {
+	paymentToleranceOnce.Do(func() {
+		conf, _ := config.Load()
+		paymentTolerance = conf.PaymentTolerance
+	})
+
+	return paymentTolerance
+}
+
+// priceDifference - how far an amount is from the tier's price
+func (t Tier) priceDifference(amount int64) int64 {
+	if amount > t.Price {
+		return amount - t.Price
+	}
+
+	return t.Price - amount
+}
+
+// ByPrice - the active tier that a payment amount puts a member on.
+//   The amount has to be in the tier's currency and within the tolerance of its price,
+//   when more than one tier is that close the nearest price wins.
+func (t Tiers) ByPrice(amount money.Money, tolerance int64) (Tier, bool) {
+	var match Tier
+	found := false
+
+	for _, tier := range t {
+		if !tier.Active || !strings.EqualFold(tier.Currency, amount.Currency().Code) {
+			continue
+		}
+
+		difference := tier.priceDifference(amount.Amount())
+		if difference > tolerance {
+			continue
+		}
+
+		if !found || difference < match.priceDifference(amount.Amount()) {
+			match = tier
+			found = true
+		}
+	}
+
+	return match, found
+}
+
+// Name - the name of a tier or an empty string when there is no such tier
+func (t Tiers) Name(level MemberLevel) string {
+	tier, _ := t.ByID(level)
+	return tier.Name
+}
+
+// Validate - makes sure the tier has a name, a price that isn't negative, a known currency and a billing period
+func (t Tier) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("a tier needs a name")
+	}
+
+	if t.Price < 0 {
+		return errors.New("a tier can't have a negative price")
+	}
+
+	if money.GetCurrency(t.Currency) == nil {
+		return fmt.Errorf("not a valid currency: %s", t.Currency)
+	}
+
+	if !BillingPeriods[t.BillingPeriod] {
+		return fmt.Errorf("not a valid billing period: %s", t.BillingPeriod)
+	}
+
+	return nil
+}
+
+func scanTier(row pgx.Row) (Tier, error) {
+	var t Tier
+	var billingPeriod string
+
+	err := row.Scan(&t.ID, &t.Name, &t.Price, &t.Currency, &billingPeriod, &t.Active, &t.SortOrder)
+	if err != nil {
+		return t, err
+	}
+
+	t.BillingPeriod = BillingPeriod(billingPeriod)
+	t.Protected = MemberLevel(t.ID).IsProtected()
+
+	return t, nil
+}
+
+func tierError(action string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return errors.New("another tier has that name or another active tier has that price")
+		case foreignKeyViolation:
+			return errors.New("the tier is still in use, deactivate it instead")
+		}
+	}
+
+	return fmt.Errorf("error %s tier: %v", action, err)
 }
 
 // GetMemberTiers - gets the member tiers from DB
-func (db *Database) GetMemberTiers() []Tier {
+func (db *Database) GetMemberTiers() Tiers {
 	rows, err := db.getConn().Query(context.Background(), tierDbMethod.getMemberTiers())
 	if err != nil {
 		log.Errorf("conn.Query failed: %v", err)
+		return nil
 	}
 
 	defer rows.Close()
 
-	var tiers []Tier
+	var tiers Tiers
 
 	for rows.Next() {
-		var t Tier
-		err = rows.Scan(&t.ID, &t.Name)
+		t, err := scanTier(rows)
 		if err == nil {
 			tiers = append(tiers, t)
 		}
@@ -69,3 +227,80 @@ func (db *Database) GetMemberTiers() []Tier {
 
 	return tiers
 }
+
+// GetTierByID - lookup a tier by its id
+func (db *Database) GetTierByID(level MemberLevel) (Tier, error) {
+	t, err := scanTier(db.getConn().QueryRow(db.ctx, tierDbMethod.getTierByID(), level))
+	if err == pgx.ErrNoRows {
+		return t, fmt.Errorf("unknown tier: %d", level)
+	}
+	if err != nil {
+		return t, fmt.Errorf("error getting tier: %v", err)
+	}
+
+	return t, nil
+}
+
+// AddTier - adds a membership tier that payments can be matched to
+func (db *Database) AddTier(t Tier) (Tier, error) {
+	if t.Currency == "" {
+		t.Currency = "USD"
+	}
+
+	err := t.Validate()
+	if err != nil {
+		return t, err
+	}
+
+	t, err = scanTier(db.getConn().QueryRow(db.ctx, tierDbMethod.insertTier(), strings.TrimSpace(t.Name), t.Price, t.Currency, string(t.BillingPeriod), t.Active, t.SortOrder))
+	if err != nil {
+		return t, tierError("adding", err)
+	}
+
+	return t, nil
+}
+
+// UpdateTier - changes a tier.  Inactive and Credited can only be renamed and reordered.
+func (db *Database) UpdateTier(t Tier) (Tier, error) {
+	existing, err := db.GetTierByID(MemberLevel(t.ID))
+	if err != nil {
+		return t, err
+	}
+
+	if t.Currency == "" {
+		t.Currency = existing.Currency
+	}
+
+	err = t.Validate()
+	if err != nil {
+		return t, err
+	}
+
+	if existing.Protected && (t.Price != existing.Price || t.Currency != existing.Currency || t.BillingPeriod != existing.BillingPeriod || t.Active != existing.Active) {
+		return t, fmt.Errorf("%s is managed by the server, only its name and sort order can change", existing.Name)
+	}
+
+	t, err = scanTier(db.getConn().QueryRow(db.ctx, tierDbMethod.updateTier(), t.ID, strings.TrimSpace(t.Name), t.Price, t.Currency, string(t.BillingPeriod), t.Active, t.SortOrder))
+	if err != nil {
+		return t, tierError("updating", err)
+	}
+
+	return t, nil
+}
+
+// DeleteTier - removes a tier that no member has ever been on
+func (db *Database) DeleteTier(level MemberLevel) (Tier, error) {
+	if level.IsProtected() {
+		return Tier{}, errors.New("the tier is managed by the server and can't be removed")
+	}
+
+	t, err := scanTier(db.getConn().QueryRow(db.ctx, tierDbMethod.deleteTier(), level))
+	if err == pgx.ErrNoRows {
+		return t, fmt.Errorf("unknown tier: %d", level)
+	}
+	if err != nil {
+		return t, tierError("deleting", err)
+	}
+
+	return t, nil
+}
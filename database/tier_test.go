@@ -0,0 +1,68 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/Rhymond/go-money"
+)
+
+var tiers = Tiers{
+	{ID: uint8(Inactive), Name: "Inactive", Price: 0, Currency: "USD", Active: true, Protected: true},
+	{ID: uint8(Credited), Name: "Credited", Price: 100, Currency: "USD", Active: true, Protected: true},
+	{ID: uint8(Classic), Name: "Classic", Price: 3000, Currency: "USD", Active: false},
+	{ID: uint8(Standard), Name: "Standard", Price: 3500, Currency: "USD", Active: true},
+	{ID: 6, Name: "Student", Price: 3000, Currency: "USD", Active: true},
+	{ID: 7, Name: "Overseas", Price: 3200, Currency: "EUR", Active: true},
+}
+
+func TestTiersByPrice(t *testing.T) {
+	tests := []struct {
+		amount    money.Money
+		tolerance int64
+		expected  uint8
+	}{
+		{*money.New(3500, "USD"), 0, uint8(Standard)},
+		// inactive tiers aren't matched to payments
+		{*money.New(3000, "USD"), 0, 6},
+		// rounded or with a fee taken out
+		{*money.New(3499, "USD"), 50, uint8(Standard)},
+		{*money.New(3400, "USD"), 100, uint8(Standard)},
+		// the nearest price wins
+		{*money.New(3200, "USD"), 500, 6},
+		{*money.New(3200, "EUR"), 0, 7},
+	}
+
+	for _, test := range tests {
+		tier, found := tiers.ByPrice(test.amount, test.tolerance)
+		if !found || tier.ID != test.expected {
+			t.Errorf("expected a payment of %s to match tier %d, got %d", test.amount.Display(), test.expected, tier.ID)
+		}
+	}
+
+	misses := []money.Money{*money.New(2000, "USD"), *money.New(3499, "USD"), *money.New(3500, "EUR")}
+	for _, amount := range misses {
+		if _, found := tiers.ByPrice(amount, 0); found {
+			t.Errorf("expected a payment of %s to not match a tier", amount.Display())
+		}
+	}
+}
+
+func TestParseMemberLevel(t *testing.T) {
+	tests := map[string]MemberLevel{
+		"4":       Standard,
+		"student": 6,
+		"Classic": Classic,
+	}
+
+	for tier, expected := range tests {
+		level, err := parseMemberLevel(tiers, tier)
+		if err != nil || level != expected {
+			t.Errorf("expected %s to be tier %d, got %d: %v", tier, expected, level, err)
+		}
+	}
+
+	_, err := parseMemberLevel(tiers, "Premium")
+	if err == nil {
+		t.Error("expected Premium to be an unknown tier")
+	}
+}
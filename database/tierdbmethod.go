@@ -4,7 +4,43 @@ package database
 type TierDatabaseMethod struct{}
 
 func (tier *TierDatabaseMethod) getMemberTiers() string {
-	const getMemberTiersQuery = `SELECT id, description FROM membership.member_tiers;`
+	const getMemberTiersQuery = `SELECT id, description, price_minor, currency, billing_period, active, sort_order
+	FROM membership.member_tiers
+	ORDER BY sort_order, id;`
 
 	return getMemberTiersQuery
 }
+
+func (tier *TierDatabaseMethod) getTierByID() string {
+	const getTierByIDQuery = `SELECT id, description, price_minor, currency, billing_period, active, sort_order
+	FROM membership.member_tiers
+	WHERE id = $1;`
+
+	return getTierByIDQuery
+}
+
+func (tier *TierDatabaseMethod) insertTier() string {
+	const insertTierQuery = `INSERT INTO membership.member_tiers(
+		description, price_minor, currency, billing_period, active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, description, price_minor, currency, billing_period, active, sort_order;`
+
+	return insertTierQuery
+}
+
+func (tier *TierDatabaseMethod) updateTier() string {
+	const updateTierQuery = `UPDATE membership.member_tiers
+	SET description = $2, price_minor = $3, currency = $4, billing_period = $5, active = $6, sort_order = $7
+	WHERE id = $1
+	RETURNING id, description, price_minor, currency, billing_period, active, sort_order;`
+
+	return updateTierQuery
+}
+
+func (tier *TierDatabaseMethod) deleteTier() string {
+	const deleteTierQuery = `DELETE FROM membership.member_tiers
+	WHERE id = $1
+	RETURNING id, description, price_minor, currency, billing_period, active, sort_order;`
+
+	return deleteTierQuery
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var tierHistoryDbMethod TierHistoryDatabaseMethod
+
+// TierChangeCause describes why a member moved between tiers
+type TierChangeCause string
+
+const (
+	// TierChangePayment - the tier changed because of a payment that was synced from a provider
+	TierChangePayment TierChangeCause = "payment"
+	// TierChangeCredit - the member was credited a membership
+	TierChangeCredit TierChangeCause = "credit"
+	// TierChangeCreditLapsed - the member's credit expired or was removed
+	TierChangeCreditLapsed TierChangeCause = "credit lapsed"
+	// TierChangeRevoked - the member went past the grace period without a payment
+	TierChangeRevoked TierChangeCause = "revoked"
+	// TierChangeManual - an admin changed the tier
+	TierChangeManual TierChangeCause = "manual"
+)
+
+// TierChange - a record of a member moving from one tier to another
+type TierChange struct {
+	ID                int64           `json:"id"`
+	MemberID          string          `json:"memberID"`
+	PreviousLevel     uint8           `json:"previousLevel"`
+	PreviousLevelName string          `json:"previousLevelName"`
+	NewLevel          uint8           `json:"newLevel"`
+	NewLevelName      string          `json:"newLevelName"`
+	Cause             TierChangeCause `json:"cause"`
+	CreatedAt         time.Time       `json:"createdAt"`
+}
+
+// GetMemberTierHistory - gets every tier change of a member ordered from oldest to newest
+func (db *Database) GetMemberTierHistory(memberID string) ([]TierChange, error) {
+	var history []TierChange
+
+	rows, err := db.getConn().Query(db.ctx, tierHistoryDbMethod.getMemberTierHistory(), memberID)
+	if err != nil {
+		return history, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tc TierChange
+		var cause string
+		err = rows.Scan(&tc.ID, &tc.MemberID, &tc.PreviousLevel, &tc.PreviousLevelName, &tc.NewLevel, &tc.NewLevelName, &cause, &tc.CreatedAt)
+		if err != nil {
+			log.Errorf("error scanning row: %s", err)
+			continue
+		}
+		tc.Cause = TierChangeCause(cause)
+
+		history = append(history, tc)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,18 @@
+package database
+
+// TierHistoryDatabaseMethod -- method container that holds the extension methods to query the member tier history table
+type TierHistoryDatabaseMethod struct{}
+
+func (history *TierHistoryDatabaseMethod) getMemberTierHistory() string {
+	const getMemberTierHistoryQuery = `SELECT h.id, h.member_id, COALESCE(h.previous_tier_id, 0), COALESCE(p.description, ''),
+		h.new_tier_id, n.description, h.cause, h.created_at
+	FROM membership.member_tier_history h
+	LEFT JOIN membership.member_tiers p
+	ON h.previous_tier_id = p.id
+	INNER JOIN membership.member_tiers n
+	ON h.new_tier_id = n.id
+	WHERE h.member_id = $1
+	ORDER BY h.created_at, h.id;`
+
+	return getMemberTierHistoryQuery
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var trainerDbMethod TrainerDatabaseMethod
+
+// Trainer - a member that can add and remove members on a resource
+//   and certify members for what the resource requires, without being an admin
+type Trainer struct {
+	ResourceID string    `json:"resourceID"`
+	MemberID   string    `json:"memberID"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	AddedBy    string    `json:"addedBy"`
+	AddedAt    time.Time `json:"addedAt"`
+}
+
+// GetResourceTrainers - gets the trainers of a resource
+func (db *Database) GetResourceTrainers(resourceID string) ([]Trainer, error) {
+	var trainers []Trainer
+
+	rows, err := db.getConn().Query(db.ctx, trainerDbMethod.getResourceTrainers(), resourceID)
+	if err != nil {
+		return trainers, fmt.Errorf("conn.Query failed: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Trainer
+
+		err = rows.Scan(&t.ResourceID, &t.MemberID, &t.Name, &t.Email, &t.AddedBy, &t.AddedAt)
+		if err != nil {
+			return trainers, fmt.Errorf("error reading trainer: %v", err)
+		}
+
+		trainers = append(trainers, t)
+	}
+
+	return trainers, nil
+}
+
+// AddResourceTrainer - lets a member train on a resource
+func (db *Database) AddResourceTrainer(resourceID string, email string, addedBy string) error {
+	m, err := db.GetMemberByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.getConn().Exec(db.ctx, trainerDbMethod.insertResourceTrainer(), resourceID, m.ID, addedBy)
+	if err != nil {
+		return fmt.Errorf("error adding trainer: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveResourceTrainer - stops a member from training on a resource
+func (db *Database) RemoveResourceTrainer(resourceID string, email string) error {
+	m, err := db.GetMemberByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.getConn().Exec(db.ctx, trainerDbMethod.removeResourceTrainer(), resourceID, m.ID)
+	if err != nil {
+		return fmt.Errorf("error removing trainer: %v", err)
+	}
+
+	return nil
+}
+
+func (db *Database) trainerExists(query string, args ...interface{}) bool {
+	var exists bool
+
+	err := db.getConn().QueryRow(db.ctx, query, args...).Scan(&exists)
+	if err != nil {
+		log.Errorf("error checking trainer: %s", err)
+		return false
+	}
+
+	return exists
+}
+
+// IsTrainer - whether the member trains on any resource
+func (db *Database) IsTrainer(email string) bool {
+	return db.trainerExists(trainerDbMethod.isTrainer(), email)
+}
+
+// IsResourceTrainer - whether the member trains on the resource
+func (db *Database) IsResourceTrainer(email string, resourceID string) bool {
+	return db.trainerExists(trainerDbMethod.isResourceTrainer(), email, resourceID)
+}
+
+// IsCertificationTrainer - whether the member trains on a resource that requires the certification
+func (db *Database) IsCertificationTrainer(email string, certificationID int) bool {
+	return db.trainerExists(trainerDbMethod.isCertificationTrainer(), email, certificationID)
+}
@@ -0,0 +1,73 @@
+package database
+
+// TrainerDatabaseMethod -- method container that holds the extension methods to query the resource trainers table
+type TrainerDatabaseMethod struct{}
+
+func (trainer *TrainerDatabaseMethod) getResourceTrainers() string {
+	const getResourceTrainersQuery = `SELECT t.resource_id, t.member_id, m.name, m.email, t.added_by, t.added_at
+	FROM membership.resource_trainers t
+	INNER JOIN membership.members m
+	ON m.id = t.member_id
+	WHERE t.resource_id = $1
+	ORDER BY m.name;`
+
+	return getResourceTrainersQuery
+}
+
+func (trainer *TrainerDatabaseMethod) insertResourceTrainer() string {
+	const insertResourceTrainerQuery = `INSERT INTO membership.resource_trainers(
+		resource_id, member_id, added_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING;`
+
+	return insertResourceTrainerQuery
+}
+
+func (trainer *TrainerDatabaseMethod) removeResourceTrainer() string {
+	const removeResourceTrainerQuery = `DELETE FROM membership.resource_trainers
+	WHERE resource_id = $1 AND member_id = $2;`
+
+	return removeResourceTrainerQuery
+}
+
+func (trainer *TrainerDatabaseMethod) isResourceTrainer() string {
+	const isResourceTrainerQuery = `SELECT EXISTS (
+		SELECT 1
+		FROM membership.resource_trainers t
+		INNER JOIN membership.members m
+		ON m.id = t.member_id
+		WHERE m.email = $1 AND t.resource_id = $2
+		AND m.archived_at IS NULL
+	);`
+
+	return isResourceTrainerQuery
+}
+
+func (trainer *TrainerDatabaseMethod) isCertificationTrainer() string {
+	// a trainer can certify members for what the resources they train on require
+	const isCertificationTrainerQuery = `SELECT EXISTS (
+		SELECT 1
+		FROM membership.resource_trainers t
+		INNER JOIN membership.members m
+		ON m.id = t.member_id
+		INNER JOIN membership.resource_certifications rc
+		ON rc.resource_id = t.resource_id
+		WHERE m.email = $1 AND rc.certification_id = $2
+		AND m.archived_at IS NULL
+	);`
+
+	return isCertificationTrainerQuery
+}
+
+func (trainer *TrainerDatabaseMethod) isTrainer() string {
+	const isTrainerQuery = `SELECT EXISTS (
+		SELECT 1
+		FROM membership.resource_trainers t
+		INNER JOIN membership.members m
+		ON m.id = t.member_id
+		WHERE m.email = $1
+		AND m.archived_at IS NULL
+	);`
+
+	return isTrainerQuery
+}
@@ -63,7 +63,7 @@ func (db *Database) RegisterUser(email string, password string) error {
 	return nil
 }
 
-// UserSignin - user login
+// UserSignin - user login.  Archived members can't sign in
 func (db *Database) UserSignin(email string, password string) error {
 	// We create another instance of `Credentials` to store the credentials we get from the database
 	storedCreds := &Credentials{}
@@ -80,6 +80,15 @@ func (db *Database) UserSignin(email string, password string) error {
 		return fmt.Errorf("Unauthorized: %s", err)
 	}
 
+	// archived members can't use the self service endpoints until they are restored
+	archived, err := db.IsArchivedMember(email)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return fmt.Errorf("Unauthorized: member is archived")
+	}
+
 	return nil
 }
 
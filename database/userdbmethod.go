@@ -22,3 +22,11 @@ func (user *UserDatabaseMethod) getUser() string {
 
 	return getUserQuery
 }
+
+func (user *UserDatabaseMethod) updateUserEmail() string {
+	const updateUserEmailQuery = `UPDATE membership.users
+	SET email = $2
+	WHERE lower(email) = lower($1);`
+
+	return updateUserEmailQuery
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+var webhookEventDbMethod WebhookEventDatabaseMethod
+
+// ClaimWebhookEvent - records that an event of a payment provider is being handled.
+//   Returns false when the event was already handled, so a resent event isn't applied twice.
+func (db *Database) ClaimWebhookEvent(provider PaymentProvider, eventID string) (bool, error) {
+	var claimed string
+
+	err := db.getConn().QueryRow(db.ctx, webhookEventDbMethod.claimWebhookEvent(), provider, eventID).Scan(&claimed)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error claiming webhook event: %v", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseWebhookEvent - forgets an event that couldn't be handled so it is handled when it is sent again
+func (db *Database) ReleaseWebhookEvent(provider PaymentProvider, eventID string) error {
+	_, err := db.getConn().Exec(db.ctx, webhookEventDbMethod.releaseWebhookEvent(), provider, eventID)
+	if err != nil {
+		return fmt.Errorf("error releasing webhook event: %v", err)
+	}
+
+	return nil
+}
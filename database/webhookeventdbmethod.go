@@ -0,0 +1,21 @@
+package database
+
+// WebhookEventDatabaseMethod -- method container that holds the extension methods to query the handled webhook events
+type WebhookEventDatabaseMethod struct{}
+
+func (event *WebhookEventDatabaseMethod) claimWebhookEvent() string {
+	const claimWebhookEventQuery = `INSERT INTO membership.webhook_events(
+		provider, event_id)
+		VALUES ($1, $2)
+	ON CONFLICT DO NOTHING
+	RETURNING event_id;`
+
+	return claimWebhookEventQuery
+}
+
+func (event *WebhookEventDatabaseMethod) releaseWebhookEvent() string {
+	const releaseWebhookEventQuery = `DELETE FROM membership.webhook_events
+	WHERE provider = $1 AND event_id = $2;`
+
+	return releaseWebhookEventQuery
+}
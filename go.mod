@@ -13,6 +13,7 @@ require (
 	github.com/gojuno/minimock/v3 v3.0.8 // indirect
 	github.com/gorilla/mux v1.8.0
 	github.com/hashicorp/mdns v1.0.3
+	github.com/jackc/pgconn v1.8.0
 	github.com/jackc/pgtype v1.6.2
 	github.com/jackc/pgx/v4 v4.10.0
 	github.com/jordan-wright/email v4.0.1-0.20210109023952-943e75fe5223+incompatible
@@ -25,6 +26,5 @@ require (
 	github.com/sirupsen/logrus v1.4.2
 	golang.org/x/crypto v0.0.0-20200709230013-948cd5f35899
 	golang.org/x/net v0.0.0-20210119194325-5f4716e94777 // indirect
-	gopkg.in/errgo.v2 v2.1.0
 	syreclabs.com/go/faker v1.2.3
 )
@@ -19,6 +19,10 @@ const (
 	PendingRevokationLeadership CommunicationTemplate = "PendingRevokationLeadership"
 	PendingRevokationMember     CommunicationTemplate = "PendingRevokationMember"
 	Welcome                     CommunicationTemplate = "Welcome"
+	AccessRequestApproved       CommunicationTemplate = "AccessRequestApproved"
+	AccessRequestDenied         CommunicationTemplate = "AccessRequestDenied"
+	PaymentReversedLeadership   CommunicationTemplate = "PaymentReversedLeadership"
+	PaymentReversalUnlinked     CommunicationTemplate = "PaymentReversalUnlinked"
 )
 
 // String converts CommunicationTemplate to a string
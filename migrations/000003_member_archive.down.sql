@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE membership.members
+    DROP COLUMN IF EXISTS archived_at;
+
+COMMIT;
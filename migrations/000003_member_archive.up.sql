@@ -0,0 +1,2 @@
+ALTER TABLE membership.members
+    ADD COLUMN IF NOT EXISTS archived_at timestamp;
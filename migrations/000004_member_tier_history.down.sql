@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS membership.member_tier_history;
+
+COMMIT;
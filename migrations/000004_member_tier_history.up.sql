@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS membership.member_tier_history
+(
+    id BIGSERIAL PRIMARY KEY,
+    member_id uuid NOT NULL REFERENCES membership.members(id),
+    previous_tier_id integer REFERENCES membership.member_tiers(id),
+    new_tier_id integer NOT NULL REFERENCES membership.member_tiers(id),
+    cause text NOT NULL,
+    created_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS member_tier_history_member_id
+    ON membership.member_tier_history (member_id, created_at);
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE membership.member_credit
+    DROP COLUMN IF EXISTS id,
+    DROP COLUMN IF EXISTS reason,
+    DROP COLUMN IF EXISTS granted_by,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS expires_at;
+
+COMMIT;
@@ -0,0 +1,19 @@
+ALTER TABLE membership.member_credit
+    ADD COLUMN IF NOT EXISTS id BIGSERIAL PRIMARY KEY,
+    ADD COLUMN IF NOT EXISTS reason text,
+    ADD COLUMN IF NOT EXISTS granted_by text,
+    ADD COLUMN IF NOT EXISTS created_at timestamp NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS expires_at timestamp;
+
+-- credits that existed before this migration were granted by the board
+UPDATE membership.member_credit
+    SET reason = 'board'
+    WHERE reason IS NULL;
+
+UPDATE membership.member_credit
+    SET granted_by = ''
+    WHERE granted_by IS NULL;
+
+ALTER TABLE membership.member_credit
+    ALTER COLUMN reason SET NOT NULL,
+    ALTER COLUMN granted_by SET NOT NULL;
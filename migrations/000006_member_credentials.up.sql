@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS membership.member_credentials
+(
+    id BIGSERIAL PRIMARY KEY,
+    member_id uuid NOT NULL REFERENCES membership.members(id),
+    rfid text NOT NULL,
+    label text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'active',
+    issued_at timestamp NOT NULL DEFAULT NOW(),
+    status_changed_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS member_credentials_member_id
+    ON membership.member_credentials (member_id);
+
+-- a tag can only be active for one member at a time
+CREATE UNIQUE INDEX IF NOT EXISTS member_credentials_active_rfid
+    ON membership.member_credentials (rfid)
+    WHERE status = 'active';
+
+-- the rfid that was assigned to each member becomes their first credential
+INSERT INTO membership.member_credentials (member_id, rfid, label)
+    SELECT id, rfid, 'card'
+    FROM membership.members m
+    WHERE m.rfid IS NOT NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM membership.member_credentials c
+        WHERE c.member_id = m.id
+    );
@@ -0,0 +1,34 @@
+BEGIN;
+
+-- put the tags back the way the esp reader prints them
+UPDATE membership.member_credentials c
+    SET legacy_rfid = (
+        SELECT string_agg(regexp_replace(substr(lower(c.uid), i, 2), '^0(.)$', '\1'), '' ORDER BY i)
+        FROM generate_series(1, length(c.uid), 2) i
+    )
+    WHERE c.uid IS NOT NULL;
+
+DROP INDEX IF EXISTS membership.member_credentials_active_uid;
+
+ALTER TABLE membership.member_credentials
+    DROP COLUMN IF EXISTS uid;
+
+ALTER TABLE membership.member_credentials
+    RENAME COLUMN legacy_rfid TO rfid;
+
+ALTER TABLE membership.member_credentials
+    ALTER COLUMN rfid SET NOT NULL;
+
+UPDATE membership.members m
+    SET rfid = (
+        SELECT c.rfid
+        FROM membership.member_credentials c
+        WHERE c.member_id = m.id AND c.status = 'active'
+        ORDER BY c.issued_at DESC, c.id DESC
+        LIMIT 1
+    );
+
+ALTER TABLE membership.resources
+    DROP COLUMN IF EXISTS rfid_format;
+
+COMMIT;
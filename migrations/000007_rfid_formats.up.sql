@@ -0,0 +1,41 @@
+ALTER TABLE membership.resources
+    ADD COLUMN IF NOT EXISTS rfid_format text NOT NULL DEFAULT 'esp';
+
+ALTER TABLE membership.member_credentials
+    ADD COLUMN IF NOT EXISTS uid text;
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'membership' AND table_name = 'member_credentials' AND column_name = 'rfid'
+    ) THEN
+        ALTER TABLE membership.member_credentials RENAME COLUMN rfid TO legacy_rfid;
+    END IF;
+END $$;
+
+ALTER TABLE membership.member_credentials
+    ALTER COLUMN legacy_rfid DROP NOT NULL;
+
+-- tags were stored the way the esp reader prints them, which drops the leading zero of each byte.
+--   when every byte is two characters long the raw UID is the same hex, otherwise it can't be
+--   recovered and the tag is kept as is.  Those tags only work on esp resources until they are reissued.
+UPDATE membership.member_credentials
+    SET uid = upper(legacy_rfid),
+        legacy_rfid = NULL
+    WHERE uid IS NULL
+    AND (legacy_rfid ~ '^([1-9a-f][0-9a-f]){4}$' OR legacy_rfid ~ '^([1-9a-f][0-9a-f]){7}$');
+
+CREATE UNIQUE INDEX IF NOT EXISTS member_credentials_active_uid
+    ON membership.member_credentials (uid)
+    WHERE status = 'active';
+
+UPDATE membership.members m
+    SET rfid = (
+        SELECT COALESCE(c.uid, c.legacy_rfid)
+        FROM membership.member_credentials c
+        WHERE c.member_id = m.id AND c.status = 'active'
+        ORDER BY c.issued_at DESC, c.id DESC
+        LIMIT 1
+    );
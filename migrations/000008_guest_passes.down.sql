@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS membership.guest_resource;
+DROP TABLE IF EXISTS membership.guests;
+
+COMMIT;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS membership.guests
+(
+    id BIGSERIAL PRIMARY KEY,
+    name text NOT NULL,
+    email text NOT NULL DEFAULT '',
+    uid text NOT NULL,
+    host_member_id uuid REFERENCES membership.members(id),
+    issued_by text NOT NULL,
+    starts_at timestamp NOT NULL,
+    ends_at timestamp NOT NULL,
+    created_at timestamp NOT NULL DEFAULT NOW(),
+    pushed_at timestamp,
+    removed_at timestamp,
+    removed_reason text,
+    CONSTRAINT guest_pass_window CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS guests_ends_at
+    ON membership.guests (ends_at)
+    WHERE removed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS membership.guest_resource
+(
+    guest_id bigint NOT NULL REFERENCES membership.guests(id) ON DELETE CASCADE,
+    resource_id uuid NOT NULL REFERENCES membership.resources(id) ON DELETE CASCADE,
+    PRIMARY KEY (guest_id, resource_id)
+);
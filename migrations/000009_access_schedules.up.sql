@@ -0,0 +1,16 @@
+ALTER TABLE membership.resources
+    ADD COLUMN IF NOT EXISTS supports_schedules boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS membership.access_schedules
+(
+    id BIGSERIAL PRIMARY KEY,
+    resource_id uuid NOT NULL REFERENCES membership.resources(id) ON DELETE CASCADE,
+    member_tier_id integer REFERENCES membership.member_tiers(id),
+    name text NOT NULL DEFAULT '',
+    days integer[] NOT NULL,
+    start_time time NOT NULL,
+    end_time time NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS access_schedules_resource_id
+    ON membership.access_schedules (resource_id);
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS membership.member_certifications;
+DROP TABLE IF EXISTS membership.resource_certifications;
+DROP TABLE IF EXISTS membership.certifications;
+
+COMMIT;
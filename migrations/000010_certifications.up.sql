@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS membership.certifications
+(
+    id SERIAL PRIMARY KEY,
+    name text NOT NULL UNIQUE,
+    description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS membership.resource_certifications
+(
+    resource_id uuid NOT NULL REFERENCES membership.resources(id) ON DELETE CASCADE,
+    certification_id integer NOT NULL REFERENCES membership.certifications(id) ON DELETE CASCADE,
+    PRIMARY KEY (resource_id, certification_id)
+);
+
+CREATE TABLE IF NOT EXISTS membership.member_certifications
+(
+    id BIGSERIAL PRIMARY KEY,
+    member_id uuid NOT NULL REFERENCES membership.members(id),
+    certification_id integer NOT NULL REFERENCES membership.certifications(id) ON DELETE CASCADE,
+    trainer text NOT NULL,
+    recorded_by text NOT NULL,
+    certified_at timestamp NOT NULL DEFAULT NOW(),
+    expires_at timestamp,
+    revoked_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS member_certifications_member_id
+    ON membership.member_certifications (member_id, certification_id);
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS membership.resource_access_log;
+DROP TABLE IF EXISTS membership.resource_trainers;
+
+COMMIT;
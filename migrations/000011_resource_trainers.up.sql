@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS membership.resource_trainers
+(
+    resource_id uuid NOT NULL REFERENCES membership.resources(id) ON DELETE CASCADE,
+    member_id uuid NOT NULL REFERENCES membership.members(id) ON DELETE CASCADE,
+    added_by text NOT NULL,
+    added_at timestamp NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (resource_id, member_id)
+);
+
+CREATE TABLE IF NOT EXISTS membership.resource_access_log
+(
+    id BIGSERIAL PRIMARY KEY,
+    member_id uuid NOT NULL REFERENCES membership.members(id) ON DELETE CASCADE,
+    resource_id uuid NOT NULL REFERENCES membership.resources(id) ON DELETE CASCADE,
+    action text NOT NULL,
+    changed_by text NOT NULL,
+    changed_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS resource_access_log_resource_id
+    ON membership.resource_access_log (resource_id, changed_at);
@@ -0,0 +1,15 @@
+BEGIN;
+
+DELETE FROM membership.communication_log
+WHERE communication_id IN (
+    SELECT id
+    FROM membership.communication
+    WHERE name IN ('AccessRequestApproved', 'AccessRequestDenied')
+);
+
+DELETE FROM membership.communication
+WHERE name IN ('AccessRequestApproved', 'AccessRequestDenied');
+
+DROP TABLE IF EXISTS membership.access_requests;
+
+COMMIT;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS membership.access_requests
+(
+    id BIGSERIAL PRIMARY KEY,
+    member_id uuid NOT NULL REFERENCES membership.members(id) ON DELETE CASCADE,
+    resource_id uuid NOT NULL REFERENCES membership.resources(id) ON DELETE CASCADE,
+    note text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'pending',
+    requested_at timestamp NOT NULL DEFAULT NOW(),
+    decided_by text,
+    decided_at timestamp,
+    decision_note text NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS access_requests_pending
+    ON membership.access_requests (member_id, resource_id)
+    WHERE status = 'pending';
+
+INSERT INTO membership.communication
+    (name, subject, frequency_throttle, template)
+VALUES
+    ('AccessRequestApproved', 'Resource Access Approved', 0, 'access_request_approved.html.tmpl'),
+    ('AccessRequestDenied', 'Resource Access Request Denied', 0, 'access_request_denied.html.tmpl')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP INDEX IF EXISTS membership.member_tiers_description;
+DROP INDEX IF EXISTS membership.member_tiers_active_price;
+
+ALTER TABLE membership.member_tiers
+    ALTER COLUMN id DROP DEFAULT;
+
+ALTER TABLE membership.member_tiers
+    DROP COLUMN IF EXISTS sort_order,
+    DROP COLUMN IF EXISTS active,
+    DROP COLUMN IF EXISTS billing_period;
+
+COMMIT;
@@ -0,0 +1,21 @@
+ALTER TABLE membership.member_tiers
+    ADD COLUMN IF NOT EXISTS billing_period text NOT NULL DEFAULT 'month',
+    ADD COLUMN IF NOT EXISTS active boolean NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS sort_order integer NOT NULL DEFAULT 0;
+
+UPDATE membership.member_tiers
+SET sort_order = id
+WHERE sort_order = 0;
+
+ALTER TABLE membership.member_tiers
+    ALTER COLUMN id SET DEFAULT nextval('membership.member_tiers_id_seq');
+
+SELECT setval('membership.member_tiers_id_seq', (SELECT MAX(id) FROM membership.member_tiers));
+
+-- payments are matched to a tier by their amount so active tiers can't share a price
+CREATE UNIQUE INDEX IF NOT EXISTS member_tiers_active_price
+    ON membership.member_tiers (price)
+    WHERE active;
+
+CREATE UNIQUE INDEX IF NOT EXISTS member_tiers_description
+    ON membership.member_tiers (lower(description));
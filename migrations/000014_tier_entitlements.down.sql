@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE membership.member_resource
+    DROP COLUMN IF EXISTS granted_by_tier;
+
+DROP TABLE IF EXISTS membership.tier_resources;
+
+COMMIT;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS membership.tier_resources
+(
+    member_tier_id integer NOT NULL REFERENCES membership.member_tiers(id) ON DELETE CASCADE,
+    resource_id uuid NOT NULL REFERENCES membership.resources(id) ON DELETE CASCADE,
+    PRIMARY KEY (member_tier_id, resource_id)
+);
+
+-- rows added because of the member's tier.  Manually granted rows are never removed when the tier changes
+ALTER TABLE membership.member_resource
+    ADD COLUMN IF NOT EXISTS granted_by_tier boolean NOT NULL DEFAULT false;
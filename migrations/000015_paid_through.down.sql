@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS membership.paid_through_ledger;
+
+ALTER TABLE membership.members
+    DROP COLUMN IF EXISTS paid_through;
+
+COMMIT;
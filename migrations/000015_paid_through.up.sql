@@ -0,0 +1,16 @@
+ALTER TABLE membership.members
+    ADD COLUMN IF NOT EXISTS paid_through date;
+
+-- how far each payment extended the member's paid through date
+CREATE TABLE IF NOT EXISTS membership.paid_through_ledger
+(
+    payment_id uuid PRIMARY KEY REFERENCES membership.payments(id) ON DELETE CASCADE,
+    member_id uuid NOT NULL REFERENCES membership.members(id) ON DELETE CASCADE,
+    member_tier_id integer REFERENCES membership.member_tiers(id) ON DELETE SET NULL,
+    paid_from date NOT NULL,
+    paid_through date NOT NULL,
+    created_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS paid_through_ledger_member_id
+    ON membership.paid_through_ledger (member_id, paid_through);
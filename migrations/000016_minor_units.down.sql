@@ -0,0 +1,31 @@
+BEGIN;
+
+DROP INDEX IF EXISTS membership.member_tiers_active_price;
+
+ALTER TABLE membership.payments
+    DROP CONSTRAINT IF EXISTS unique_payments;
+
+ALTER TABLE membership.payments
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE membership.member_tiers
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE membership.payments
+    ALTER COLUMN amount_minor TYPE numeric USING amount_minor / 100.0;
+ALTER TABLE membership.payments
+    RENAME COLUMN amount_minor TO amount;
+
+ALTER TABLE membership.member_tiers
+    ALTER COLUMN price_minor TYPE integer USING price_minor / 100;
+ALTER TABLE membership.member_tiers
+    RENAME COLUMN price_minor TO price;
+
+ALTER TABLE membership.payments
+    ADD CONSTRAINT unique_payments PRIMARY KEY (date, amount, member_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS member_tiers_active_price
+    ON membership.member_tiers (price)
+    WHERE active;
+
+COMMIT;
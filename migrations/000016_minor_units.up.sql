@@ -0,0 +1,42 @@
+-- amounts were whole dollars, they are now kept in minor units (cents) along with their currency
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'membership' AND table_name = 'payments' AND column_name = 'amount'
+    ) THEN
+        ALTER TABLE membership.payments
+            RENAME COLUMN amount TO amount_minor;
+        ALTER TABLE membership.payments
+            ALTER COLUMN amount_minor TYPE bigint USING round(amount_minor * 100);
+    END IF;
+
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'membership' AND table_name = 'member_tiers' AND column_name = 'price'
+    ) THEN
+        ALTER TABLE membership.member_tiers
+            RENAME COLUMN price TO price_minor;
+        ALTER TABLE membership.member_tiers
+            ALTER COLUMN price_minor TYPE bigint USING price_minor * 100;
+    END IF;
+END $$;
+
+ALTER TABLE membership.payments
+    ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'USD';
+
+ALTER TABLE membership.member_tiers
+    ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'USD';
+
+-- the same amount in another currency is a different payment
+ALTER TABLE membership.payments
+    DROP CONSTRAINT IF EXISTS unique_payments;
+ALTER TABLE membership.payments
+    ADD CONSTRAINT unique_payments PRIMARY KEY (date, amount_minor, currency, member_id);
+
+DROP INDEX IF EXISTS membership.member_tiers_active_price;
+CREATE UNIQUE INDEX IF NOT EXISTS member_tiers_active_price
+    ON membership.member_tiers (price_minor, currency)
+    WHERE active;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE membership.payments
+    DROP COLUMN IF EXISTS provider;
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- the payments before this were all downloaded from PayPal
+ALTER TABLE membership.payments
+    ADD COLUMN IF NOT EXISTS provider integer NOT NULL DEFAULT 1;
+
+ALTER TABLE membership.payments
+    ALTER COLUMN provider DROP DEFAULT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS membership.members_stripe_customer_id;
+
+ALTER TABLE membership.members
+    DROP COLUMN IF EXISTS stripe_customer_id;
+
+ALTER TABLE membership.members
+    DROP COLUMN IF EXISTS subscription_cancelled_at;
+
+COMMIT;
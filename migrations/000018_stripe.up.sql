@@ -0,0 +1,11 @@
+-- the stripe customer a member pays with, so payments are matched even when the emails differ
+ALTER TABLE membership.members
+    ADD COLUMN IF NOT EXISTS stripe_customer_id text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS members_stripe_customer_id
+    ON membership.members (stripe_customer_id)
+    WHERE stripe_customer_id IS NOT NULL;
+
+-- when the member cancelled their subscription with a payment provider
+ALTER TABLE membership.members
+    ADD COLUMN IF NOT EXISTS subscription_cancelled_at timestamp;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS membership.payment_provider_tokens;
+
+COMMIT;
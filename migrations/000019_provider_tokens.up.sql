@@ -0,0 +1,7 @@
+-- refresh tokens of the payment providers that rotate them on every use, i.e. QuickBooks
+CREATE TABLE IF NOT EXISTS membership.payment_provider_tokens
+(
+    provider integer PRIMARY KEY,
+    refresh_token text NOT NULL,
+    updated_at timestamp NOT NULL DEFAULT NOW()
+);
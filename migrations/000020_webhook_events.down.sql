@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS membership.webhook_events;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- ids of the webhook events we have handled, providers send an event again when they aren't sure we got it
+CREATE TABLE IF NOT EXISTS membership.webhook_events
+(
+    provider integer NOT NULL,
+    event_id text NOT NULL,
+    received_at timestamp NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_events_pkey PRIMARY KEY (provider, event_id)
+);
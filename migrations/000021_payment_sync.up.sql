@@ -0,0 +1,7 @@
+-- how far the payments of each provider have been downloaded, each sync resumes from here
+CREATE TABLE IF NOT EXISTS membership.payment_sync
+(
+    provider integer PRIMARY KEY,
+    synced_through timestamp NOT NULL,
+    updated_at timestamp NOT NULL DEFAULT NOW()
+);
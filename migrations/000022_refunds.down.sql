@@ -0,0 +1,24 @@
+BEGIN;
+
+DELETE FROM membership.communication_log
+WHERE communication_id IN (
+    SELECT id
+    FROM membership.communication
+    WHERE name = 'PaymentReversedLeadership'
+);
+
+DELETE FROM membership.communication
+WHERE name = 'PaymentReversedLeadership';
+
+DELETE FROM membership.payments
+WHERE refund_of IS NOT NULL;
+
+DROP INDEX IF EXISTS membership.payments_refund_of;
+DROP INDEX IF EXISTS membership.payments_provider_transaction_id;
+
+ALTER TABLE membership.payments
+    DROP COLUMN IF EXISTS refund_of,
+    DROP COLUMN IF EXISTS kind,
+    DROP COLUMN IF EXISTS transaction_id;
+
+COMMIT;
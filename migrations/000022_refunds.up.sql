@@ -0,0 +1,22 @@
+-- the id the provider gave the transaction, refunds are linked to their payment with it
+ALTER TABLE membership.payments
+    ADD COLUMN IF NOT EXISTS transaction_id text;
+
+-- refunds and reversals are stored as negative entries of the payment they give money back for
+ALTER TABLE membership.payments
+    ADD COLUMN IF NOT EXISTS kind integer NOT NULL DEFAULT 0;
+
+ALTER TABLE membership.payments
+    ADD COLUMN IF NOT EXISTS refund_of uuid REFERENCES membership.payments(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS payments_provider_transaction_id
+    ON membership.payments (provider, transaction_id);
+
+CREATE INDEX IF NOT EXISTS payments_refund_of
+    ON membership.payments (refund_of);
+
+INSERT INTO membership.communication
+    (name, subject, frequency_throttle, template)
+VALUES
+    ('PaymentReversedLeadership', 'hackRVA Payment Reversed', 0, 'payment_reversed_leadership.html.tmpl')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,33 @@
+BEGIN;
+
+-- payments of the same amount by the same member on the same day can't be kept apart by the old key.
+--   They are real payments and deleting them would also delete their refunds, so the rollback
+--   refuses to run until they have been looked at by hand
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM membership.payments
+        GROUP BY date, amount_minor, currency, member_id
+        HAVING COUNT(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'members have more than one payment of the same amount on the same day, the old key can''t hold them';
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS membership.payments_provider_transaction_id;
+CREATE INDEX IF NOT EXISTS payments_provider_transaction_id
+    ON membership.payments (provider, transaction_id);
+
+ALTER TABLE membership.payments
+    DROP CONSTRAINT IF EXISTS payments_pkey;
+
+ALTER TABLE membership.payments
+    ADD CONSTRAINT unique_payments PRIMARY KEY (date, amount_minor, currency, member_id);
+
+ALTER TABLE membership.payments
+    ALTER COLUMN transaction_id DROP NOT NULL;
+ALTER TABLE membership.payments
+    ALTER COLUMN transaction_id DROP DEFAULT;
+
+COMMIT;
@@ -0,0 +1,42 @@
+-- payments recorded before the provider's ids were kept are given their own id
+UPDATE membership.payments
+SET transaction_id = id::text
+WHERE transaction_id IS NULL;
+
+-- a transaction that was somehow recorded twice keeps both entries, they were both counted so far
+UPDATE membership.payments p
+SET transaction_id = p.id::text
+WHERE EXISTS (
+    SELECT 1
+    FROM membership.payments o
+    WHERE o.provider = p.provider
+    AND o.transaction_id = p.transaction_id
+    AND o.id < p.id
+);
+
+-- payments recorded by an admin don't come with an id
+ALTER TABLE membership.payments
+    ALTER COLUMN transaction_id SET DEFAULT gen_random_uuid()::text;
+ALTER TABLE membership.payments
+    ALTER COLUMN transaction_id SET NOT NULL;
+
+-- the same amount paid by the same member on the same day can be two payments,
+--   the provider's id is what tells payments apart
+ALTER TABLE membership.payments
+    DROP CONSTRAINT IF EXISTS unique_payments;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'payments_pkey' AND conrelid = 'membership.payments'::regclass
+    ) THEN
+        ALTER TABLE membership.payments
+            ADD CONSTRAINT payments_pkey PRIMARY KEY (id);
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS membership.payments_provider_transaction_id;
+CREATE UNIQUE INDEX IF NOT EXISTS payments_provider_transaction_id
+    ON membership.payments (provider, transaction_id);
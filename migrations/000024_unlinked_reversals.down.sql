@@ -0,0 +1,13 @@
+BEGIN;
+
+DELETE FROM membership.communication_log
+WHERE communication_id IN (
+    SELECT id
+    FROM membership.communication
+    WHERE name = 'PaymentReversalUnlinked'
+);
+
+DELETE FROM membership.communication
+WHERE name = 'PaymentReversalUnlinked';
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- leadership is told about reversals of payments that weren't recorded
+INSERT INTO membership.communication
+    (name, subject, frequency_throttle, template)
+VALUES
+    ('PaymentReversalUnlinked', 'hackRVA Payment Reversal Not Recorded', 0, 'payment_reversal_unlinked.html.tmpl')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,27 @@
+BEGIN;
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'payments_provider_known' AND conrelid = 'membership.payments'::regclass
+    ) THEN
+        ALTER TABLE membership.payments
+            DROP CONSTRAINT payments_provider_known;
+
+        UPDATE membership.payments SET provider = -(provider - 1);
+        UPDATE membership.payments SET provider = -provider;
+
+        UPDATE membership.payment_provider_tokens SET provider = -(provider - 1);
+        UPDATE membership.payment_provider_tokens SET provider = -provider;
+
+        UPDATE membership.webhook_events SET provider = -(provider - 1);
+        UPDATE membership.webhook_events SET provider = -provider;
+
+        UPDATE membership.payment_sync SET provider = -(provider - 1);
+        UPDATE membership.payment_sync SET provider = -provider;
+    END IF;
+END $$;
+
+COMMIT;
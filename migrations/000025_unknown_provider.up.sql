@@ -0,0 +1,26 @@
+-- provider 0 is kept for a payment whose provider wasn't set, the providers move up by one.
+--   The check on the payments is how we know the providers were already moved
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'payments_provider_known' AND conrelid = 'membership.payments'::regclass
+    ) THEN
+        -- negated first so no provider collides with the one above it while they move
+        UPDATE membership.payments SET provider = -(provider + 1);
+        UPDATE membership.payments SET provider = -provider;
+
+        UPDATE membership.payment_provider_tokens SET provider = -(provider + 1);
+        UPDATE membership.payment_provider_tokens SET provider = -provider;
+
+        UPDATE membership.webhook_events SET provider = -(provider + 1);
+        UPDATE membership.webhook_events SET provider = -provider;
+
+        UPDATE membership.payment_sync SET provider = -(provider + 1);
+        UPDATE membership.payment_sync SET provider = -provider;
+
+        ALTER TABLE membership.payments
+            ADD CONSTRAINT payments_provider_known CHECK (provider > 0);
+    END IF;
+END $$;
@@ -1,7 +1,10 @@
 package payments
 
 import (
+	"fmt"
+	"memberserver/config"
 	"memberserver/database"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -12,42 +15,144 @@ type paypalAuth struct {
 	TokenType   string `json:"token_type"`
 }
 
+// syncOverlap - how far before the high-water mark each sync starts.
+//   Pending transactions complete days after they were made and providers can be slow to report them,
+//   payments that were already recorded aren't recorded again
+const syncOverlap = 3 * 24 * time.Hour
+
 // GetPayments reach out the payment providers and download
-// payments
+// payments.  The providers are enabled in the config
 func GetPayments() {
 	db, err := database.Setup()
 	if err != nil {
 		log.Errorf("error setting up db: %s", err)
+		return
 	}
 	defer db.Release()
 
-	err = getLastMonthsPayments()
+	err = syncPayments(db)
 	if err != nil {
 		log.Errorf("error getting payments: %s", err.Error())
 	}
 	log.Debug("done adding payments to db")
 }
 
-// getLastMonthsPayments fetches payments from paypal
-//  to see if members have paid their dues
-func getLastMonthsPayments() error {
-	startDate := time.Now().AddDate(0, 0, -15).Format(time.RFC3339) // subtract one month
-	endDate := time.Now().AddDate(0, 0, 1).Format(time.RFC3339)     // add a day
+// Backfill downloads the payments of each enabled provider between start and end,
+//  i.e. to rebuild the payment history after an outage.  It doesn't move the high-water marks
+func Backfill(start time.Time, end time.Time) error {
+	c, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("error with config: %v", err)
+	}
+
+	if end.After(time.Now()) {
+		end = time.Now()
+	}
+
+	if !start.Before(end) {
+		return fmt.Errorf("the backfill has to start before %s", end.Format(time.RFC3339))
+	}
+
+	payments, refunds, _, err := downloadPayments(enabledProviders(c), func(Provider) time.Time {
+		return start
+	}, end)
+
+	processErr := processPayments(payments)
+	if processErr != nil {
+		return processErr
+	}
+
+	processRefunds(refunds)
+
+	log.Infof("backfilled %d payments and %d refunds from %s to %s", len(payments), len(refunds), start.Format(time.RFC3339), end.Format(time.RFC3339))
 
-	p, err := getPaypalPayments(startDate, endDate)
+	return err
+}
+
+// syncPayments fetches the payments of each enabled provider
+//  since the last time they were fetched, to see if members have paid their dues.
+//  A provider that was never synced is fetched for the last month
+func syncPayments(db *database.Database) error {
+	c, err := config.Load()
 	if err != nil {
-		log.Errorf("error getting payments %s", err.Error())
-		return err
+		return fmt.Errorf("error with config: %v", err)
+	}
+
+	end := time.Now()
+
+	since := func(p Provider) time.Time {
+		syncedThrough, err := db.GetSyncedThrough(p.Kind())
+		if err != nil {
+			log.Error(err)
+		}
+
+		if syncedThrough.IsZero() {
+			return end.AddDate(0, -1, 0)
+		}
+
+		return syncedThrough.Add(-syncOverlap)
+	}
+
+	payments, refunds, synced, err := downloadPayments(enabledProviders(c), since, end)
+
+	// the providers aren't marked as synced, so the payments are downloaded again next time
+	processErr := processPayments(payments)
+	if processErr != nil {
+		return processErr
+	}
+
+	processRefunds(refunds)
+
+	// the next sync resumes where this one ended
+	for _, p := range synced {
+		markErr := db.SetSyncedThrough(p.Kind(), end)
+		if markErr != nil {
+			log.Error(markErr)
+		}
 	}
 
-	processPayments(p)
 	return err
 }
 
-func processPayments(payments []database.Payment) {
+// downloadPayments fetches the payments and refunds of each provider from its start until end.
+//  A provider that is down shouldn't keep the payments of the others from being counted,
+//  the providers that were downloaded are returned along with an error naming the ones that weren't
+func downloadPayments(providers []Provider, start func(p Provider) time.Time, end time.Time) ([]database.Payment, []database.Refund, []Provider, error) {
+	var payments []database.Payment
+	var refunds []database.Refund
+	var downloaded []Provider
+	var failed []string
+
+	for _, provider := range providers {
+		p, r, err := providerPayments(provider, start(provider), end)
+		if err != nil {
+			log.Errorf("error getting payments %s", err.Error())
+			failed = append(failed, provider.Kind().String())
+			continue
+		}
+
+		payments = append(payments, p...)
+		refunds = append(refunds, r...)
+		downloaded = append(downloaded, provider)
+	}
+
+	if len(failed) > 0 {
+		return payments, refunds, downloaded, fmt.Errorf("error getting payments from %s", strings.Join(failed, ", "))
+	}
+
+	return payments, refunds, downloaded, nil
+}
+
+// processPayments records payments against the members that made them, members are added for new payers.
+//  An error is returned when the payments couldn't be recorded, so they are tried again
+func processPayments(payments []database.Payment) error {
+	if len(payments) == 0 {
+		return nil
+	}
+
 	db, err := database.Setup()
 	if err != nil {
-		log.Errorf("error setting up db: %s", err)
+		return fmt.Errorf("error setting up db: %v", err)
 	}
 	defer db.Release()
 
@@ -68,7 +173,7 @@ func processPayments(payments []database.Payment) {
 
 	err = db.AddMembers(membersToAdd)
 	if err != nil {
-		log.Error(err)
+		return err
 	}
 
 	members := db.GetMembers()
@@ -79,6 +184,15 @@ func processPayments(payments []database.Payment) {
 		memberLookup[m.Email] = m
 	}
 
+	// the payments of archived members are recorded with the rest of their history, they stay archived
+	for _, m := range db.GetArchivedMembers() {
+		if _, ok := memberLookup[m.Email]; ok {
+			continue
+		}
+
+		memberLookup[m.Email] = m.Member
+	}
+
 	var paymentsWithMemberID []database.Payment
 	for _, p := range payments {
 		payment := p
@@ -86,5 +200,5 @@ func processPayments(payments []database.Payment) {
 		paymentsWithMemberID = append(paymentsWithMemberID, payment)
 	}
 
-	db.AddPayments(paymentsWithMemberID)
+	return db.AddPayments(paymentsWithMemberID)
 }
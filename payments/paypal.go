@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,15 +15,28 @@ import (
 
 	"github.com/Rhymond/go-money"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/errgo.v2/fmt/errors"
 )
 
+// paypalMaxWindow - the longest range of time PayPal reports on at once
+const paypalMaxWindow = 31 * 24 * time.Hour
+
+// paypalPageSize - the most transactions PayPal reports on a page
+const paypalPageSize = 500
+
+// paypalReversalCodes - the event codes of money PayPal took back from us, the other refunds were ours to give
+var paypalReversalCodes = map[string]bool{
+	"T1106": true, // payment reversal, initiated by PayPal
+	"T1201": true, // chargeback
+}
+
 type paypalAccessTokenResponse struct {
 	AccessToken string `json:"access_token"`
 }
 
 type paypalTransactions struct {
 	Transactions []paypalTransaction `json:"transaction_details"`
+	Page         int                 `json:"page"`
+	TotalPages   int                 `json:"total_pages"`
 }
 
 type paypalTransaction struct {
@@ -30,10 +45,15 @@ type paypalTransaction struct {
 }
 
 type transaction struct {
-	Status  string            `json:"transaction_status"`
-	Subject string            `json:"transaction_subject"`
-	Date    string            `json:"transaction_initiation_date"`
-	Amount  transactionAmount `json:"transaction_amount"`
+	ID     string `json:"transaction_id"`
+	Status string `json:"transaction_status"`
+	// EventCode - the kind of transaction, i.e. T1107 for a refund
+	EventCode string `json:"transaction_event_code"`
+	// ReferenceID - the transaction a refund or reversal is for
+	ReferenceID string            `json:"paypal_reference_id"`
+	Subject     string            `json:"transaction_subject"`
+	Date        string            `json:"transaction_initiation_date"`
+	Amount      transactionAmount `json:"transaction_amount"`
 }
 
 type payer struct {
@@ -48,115 +68,253 @@ type payerName struct {
 }
 
 type transactionAmount struct {
-	CurrencyCode string  `json:"currency_code"`
-	Value        float64 `json:"value,string"`
+	CurrencyCode string `json:"currency_code"`
+	// Value - the amount in major units, i.e. "34.99"
+	Value string `json:"value"`
 }
 
-var accessToken = ""
+// toMinorUnits - converts an amount like "34.99" to the minor units of its currency
+//   without going through a float, so no cents are lost
+func toMinorUnits(value string, currencyCode string) (int64, error) {
+	fraction := 2
+	if c := money.GetCurrency(strings.ToUpper(currencyCode)); c != nil {
+		fraction = c.Fraction
+	}
 
-func getPaypalPayments(startDate string, endDate string) ([]database.Payment, error) {
-	var payments []database.Payment
-	c, err := config.Load()
-	if err != nil {
-		fmt.Printf("error with config: %s", err)
-		return payments, err
+	value = strings.TrimSpace(value)
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	parts := strings.SplitN(value, ".", 2)
+
+	whole := parts[0]
+	decimals := ""
+	if len(parts) == 2 {
+		decimals = parts[1]
 	}
 
-	url := fmt.Sprintf("%s/v1/reporting/transactions?start_date=%s&end_date=%s&fields=transaction_info,payer_info", c.PaypalURL, startDate, endDate)
-	token, err := requestPaypalAccessToken()
+	if len(decimals) > fraction {
+		return 0, fmt.Errorf("%s has more decimal places than %s allows", value, currencyCode)
+	}
+
+	decimals += strings.Repeat("0", fraction-len(decimals))
+
+	amount, err := strconv.ParseInt(whole+decimals, 10, 64)
+	if err != nil || whole == "" {
+		return 0, fmt.Errorf("not a valid amount: %s", value)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+// paypalProvider - downloads the payments made through our PayPal subscriptions
+type paypalProvider struct {
+	url          string
+	clientID     string
+	clientSecret string
+}
+
+func newPaypalProvider(c config.Config) (Provider, error) {
+	if len(c.PaypalClientID) == 0 {
+		return nil, fmt.Errorf("not a proper value for paypalClientID in the config")
+	}
+	if len(c.PaypalClientSecret) == 0 {
+		return nil, fmt.Errorf("not a proper value for paypalClientSecret in the config")
+	}
+	if len(c.PaypalURL) == 0 {
+		return nil, fmt.Errorf("not a proper value for paypalURL in the config")
+	}
+
+	return paypalProvider{
+		url:          c.PaypalURL,
+		clientID:     c.PaypalClientID,
+		clientSecret: c.PaypalClientSecret,
+	}, nil
+}
+
+// Kind - payments are recorded as PayPal payments
+func (pp paypalProvider) Kind() database.PaymentProvider {
+	return database.Paypal
+}
+
+// Transactions - downloads the transactions made between start and end.
+//   PayPal only reports on 31 days at a time, longer ranges are downloaded a window at a time
+func (pp paypalProvider) Transactions(start time.Time, end time.Time) ([]Transaction, error) {
+	var transactions []Transaction
+
+	token, err := pp.requestAccessToken()
 	if err != nil {
 		log.Errorf("error getting paypal access token %s\n", err.Error())
-		return payments, err
+		return transactions, err
 	}
 
 	if len(token) == 0 {
-		return payments, errors.Newf("invalid token from paypal: %s", token)
+		return transactions, fmt.Errorf("invalid token from paypal: %s", token)
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
+	// a transaction on the edge of two windows is reported by both
+	seen := make(map[string]bool)
+
+	for _, w := range splitWindows(start, end, paypalMaxWindow) {
+		for page := 1; ; page++ {
+			paypalResponse, err := pp.transactionPage(token, w.start, w.end, page)
+			if err != nil {
+				return transactions, err
+			}
+
+			for _, t := range paypalResponse.Transactions {
+				if seen[t.Transaction.ID] {
+					continue
+				}
+				seen[t.Transaction.ID] = true
+
+				tr, err := paypalTransactionFrom(t)
+				if err != nil {
+					log.Errorf("error in transaction %s: %s\n", t.Transaction.ID, err.Error())
+					continue
+				}
+
+				transactions = append(transactions, tr)
+			}
+
+			if page >= paypalResponse.TotalPages {
+				break
+			}
+		}
+	}
+
+	return transactions, nil
+}
+
+// transactionPage - one page of the transactions made between start and end
+func (pp paypalProvider) transactionPage(token string, start time.Time, end time.Time, page int) (paypalTransactions, error) {
+	var paypalResponse paypalTransactions
+
+	q := url.Values{}
+	q.Set("start_date", start.Format(time.RFC3339))
+	q.Set("end_date", end.Format(time.RFC3339))
+	q.Set("fields", "transaction_info,payer_info")
+	q.Set("page_size", strconv.Itoa(paypalPageSize))
+	q.Set("page", strconv.Itoa(page))
+
+	req, err := http.NewRequest("GET", pp.url+"/v1/reporting/transactions?"+q.Encode(), nil)
 	if err != nil {
-		return payments, err
+		return paypalResponse, err
 	}
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	res, err := client.Do(req)
+	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		fmt.Println(err)
-		return payments, err
+		return paypalResponse, err
 	}
 	defer res.Body.Close()
 
-	var transactions paypalTransactions
-
-	err = json.NewDecoder(res.Body).Decode(&transactions)
-	if err != nil {
-		return payments, err
+	if res.StatusCode != http.StatusOK {
+		return paypalResponse, fmt.Errorf("paypal responded with %s for page %d of %s to %s", res.Status, page, start.Format(time.RFC3339), end.Format(time.RFC3339))
 	}
 
+	err = json.NewDecoder(res.Body).Decode(&paypalResponse)
+
+	return paypalResponse, err
+}
+
+func paypalTransactionFrom(t paypalTransaction) (Transaction, error) {
 	timeLayout := "2006-01-02T15:04:05-0700"
 
-	for _, t := range transactions.Transactions {
-		if t.Transaction.Status != "S" {
-			// found a transaction, but it didn't have a Status Code of 'S'
-			//
-			//   From PayPal:
-			// Status code	Description
-			// 	D	PayPal or merchant rules denied the transaction.
-			// 	F	The original recipient partially refunded the transaction.
-			// 	P	The transaction is pending. The transaction was created but waits for another payment process to complete, such as an ACH transaction, before the status changes to S.
-			// 	S	The transaction successfully completed without a denial and after any pending statuses.
-			// 	V	A successful transaction was reversed and funds were refunded to the original sender.
-			continue
-		}
+	date, err := time.Parse(timeLayout, t.Transaction.Date)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("error in date of a transaction: %v", err)
+	}
 
-		var p database.Payment
+	amount, err := toMinorUnits(t.Transaction.Amount.Value, t.Transaction.Amount.CurrencyCode)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("error in amount of a transaction: %v", err)
+	}
 
-		// i don't think this will handle pennies, but i don't think it matters
-		p.Amount = *money.New((int64)(t.Transaction.Amount.Value)*100, t.Transaction.Amount.CurrencyCode)
-		p.Email = t.Payer.Email
-		p.Name = t.Payer.Name.FullName
-		p.Date, err = time.Parse(timeLayout, t.Transaction.Date)
-		if err != nil {
-			log.Errorf("error in date of a transaction: %s\n", err.Error())
-		}
-		p.Provider = database.Paypal
+	tr := Transaction{
+		ID:      t.Transaction.ID,
+		Date:    date,
+		Amount:  *money.New(amount, strings.ToUpper(t.Transaction.Amount.CurrencyCode)),
+		Details: t,
+	}
 
-		payments = append(payments, p)
+	// refunds and reversals are transactions of their own that take money back out
+	if amount < 0 && t.Transaction.ReferenceID != "" {
+		tr.RefundOf = t.Transaction.ReferenceID
 	}
 
-	return payments, err
+	return tr, nil
 }
 
-// requestPaypalAccessToken - requests a BEARER access token to communicate with the api
-func requestPaypalAccessToken() (string, error) {
-	var token string
-	c, err := config.Load()
-
-	if err != nil {
-		return token, err
+// Payer - the name and email of the paypal account that paid
+func (pp paypalProvider) Payer(t Transaction) (string, string) {
+	details, ok := t.Details.(paypalTransaction)
+	if !ok {
+		return "", ""
 	}
 
-	if len(c.PaypalClientID) == 0 {
-		return token, fmt.Errorf("not a proper value for paypalClientID in the config")
-	}
-	if len(c.PaypalClientSecret) == 0 {
-		return token, fmt.Errorf("not a proper value for paypalClientSecret in the config")
+	return details.Payer.Name.FullName, details.Payer.Email
+}
+
+// Status - maps the status code of a paypal transaction
+//
+//   From PayPal:
+// Status code	Description
+// 	D	PayPal or merchant rules denied the transaction.
+// 	F	The original recipient partially refunded the transaction.
+// 	P	The transaction is pending. The transaction was created but waits for another payment process to complete, such as an ACH transaction, before the status changes to S.
+// 	S	The transaction successfully completed without a denial and after any pending statuses.
+// 	V	A successful transaction was reversed and funds were refunded to the original sender.
+//
+//   The money that was given back is a transaction of its own, a successful refund is reported as refunded
+//   and a successful reversal or chargeback as reversed.  A partially refunded payment was still paid.
+func (pp paypalProvider) Status(t Transaction) TransactionStatus {
+	details, ok := t.Details.(paypalTransaction)
+	if !ok {
+		return StatusUnknown
 	}
-	if len(c.PaypalURL) == 0 {
-		return token, fmt.Errorf("not a proper value for paypalURL in the config")
+
+	switch details.Transaction.Status {
+	case "S":
+		if t.RefundOf != "" && paypalReversalCodes[details.Transaction.EventCode] {
+			return StatusReversed
+		}
+		if t.RefundOf != "" {
+			return StatusRefunded
+		}
+		return StatusCompleted
+	case "P":
+		return StatusPending
+	case "D":
+		return StatusDenied
+	case "F":
+		return StatusCompleted
+	case "V":
+		return StatusReversed
 	}
 
+	return StatusUnknown
+}
+
+// requestAccessToken - requests a BEARER access token to communicate with the api
+func (pp paypalProvider) requestAccessToken() (string, error) {
+	var token string
+
 	payload := strings.NewReader("grant_type=client_credentials")
 
 	client := &http.Client{}
-	req, err := http.NewRequest("POST", c.PaypalURL+"/v1/oauth2/token", payload)
+	req, err := http.NewRequest("POST", pp.url+"/v1/oauth2/token", payload)
 
 	if err != nil {
 		return token, err
 	}
 
-	req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.PaypalClientID+":"+c.PaypalClientSecret)))
+	req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(pp.clientID+":"+pp.clientSecret)))
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
 	res, err := client.Do(req)
@@ -174,5 +332,5 @@ func requestPaypalAccessToken() (string, error) {
 
 	token = newAccessToken.AccessToken
 
-	return newAccessToken.AccessToken, err
+	return token, err
 }
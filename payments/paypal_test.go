@@ -0,0 +1,130 @@
+package payments
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"memberserver/database"
+)
+
+func TestToMinorUnits(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		expected int64
+	}{
+		{"34.99", "USD", 3499},
+		{"35", "USD", 3500},
+		{"35.5", "USD", 3550},
+		{"-35.00", "USD", -3500},
+		{"1000", "JPY", 1000},
+	}
+
+	for _, test := range tests {
+		amount, err := toMinorUnits(test.value, test.currency)
+		if err != nil || amount != test.expected {
+			t.Errorf("expected %s %s to be %d, got %d: %v", test.value, test.currency, test.expected, amount, err)
+		}
+	}
+
+	for _, value := range []string{"34.999", "", "abc", "3.4.5"} {
+		if _, err := toMinorUnits(value, "USD"); err == nil {
+			t.Errorf("expected %q to not be a valid amount", value)
+		}
+	}
+}
+
+func TestSplitWindows(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	windows := splitWindows(start, start.AddDate(0, 0, 75), paypalMaxWindow)
+	if len(windows) != 3 {
+		t.Fatalf("expected 75 days to take 3 windows, got %d", len(windows))
+	}
+
+	for i, w := range windows {
+		if w.end.Sub(w.start) > paypalMaxWindow {
+			t.Errorf("window %d is longer than paypal allows: %s to %s", i, w.start, w.end)
+		}
+		if i > 0 && !w.start.Equal(windows[i-1].end) {
+			t.Errorf("window %d doesn't start where the one before ended", i)
+		}
+	}
+
+	if !windows[0].start.Equal(start) || !windows[2].end.Equal(start.AddDate(0, 0, 75)) {
+		t.Errorf("windows don't cover the range: %+v", windows)
+	}
+
+	if windows := splitWindows(start, start.AddDate(0, 0, 15), paypalMaxWindow); len(windows) != 1 {
+		t.Errorf("expected a short range to be one window, got %d", len(windows))
+	}
+}
+
+func TestPaypalTransactionPages(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/oauth2/token" {
+			w.Write([]byte(`{"access_token": "A21AAtoken"}`))
+			return
+		}
+
+		requests++
+
+		q := req.URL.Query()
+		start, _ := time.Parse(time.RFC3339, q.Get("start_date"))
+		end, _ := time.Parse(time.RFC3339, q.Get("end_date"))
+		if end.Sub(start) > paypalMaxWindow {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case !start.Equal(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)):
+			w.Write([]byte(`{"transaction_details": [], "page": 1, "total_pages": 0}`))
+		case q.Get("page") == "2":
+			w.Write(paypalFixture(t, "transactions_page2.json"))
+		default:
+			w.Write(paypalFixture(t, "transactions_page1.json"))
+		}
+	}))
+	defer server.Close()
+
+	pp := paypalProvider{url: server.URL}
+
+	transactions, err := pp.Transactions(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 8, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two pages of the first window and one of each of the others
+	if requests != 4 {
+		t.Errorf("expected 4 requests, got %d", requests)
+	}
+
+	if len(transactions) != 4 {
+		t.Fatalf("expected the transactions of both pages, got %d", len(transactions))
+	}
+
+	// the pending transaction isn't a payment yet and the chargeback gives money back
+	payments := transactionPayments(pp, transactions)
+	if len(payments) != 2 || payments[1].Email != "grace@example.com" || payments[1].Amount.Amount() != 5000 {
+		t.Errorf("expected the completed transactions to be payments, got %+v", payments)
+	}
+
+	if payments[0].TransactionID != "5TY05013RG002845M" {
+		t.Errorf("expected the payment to keep its transaction id, got %s", payments[0].TransactionID)
+	}
+
+	refunds := transactionRefunds(pp, transactions)
+	if len(refunds) != 1 {
+		t.Fatalf("expected the chargeback to be a refund, got %d refunds", len(refunds))
+	}
+
+	r := refunds[0]
+	if r.Kind != database.PaymentReversal || r.PaymentTransactionID != "5TY05013RG002845M" || r.TransactionID != "3GT70925LF283522V" || r.Amount != 3500 || r.Currency != "USD" {
+		t.Errorf("chargeback wasn't mapped to a reversal of the payment: %+v", r)
+	}
+}
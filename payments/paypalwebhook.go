@@ -0,0 +1,538 @@
+package payments
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"memberserver/config"
+	"memberserver/database"
+
+	"github.com/Rhymond/go-money"
+	log "github.com/sirupsen/logrus"
+)
+
+// paypalCertDomain - PayPal serves the certificates it signs webhook events with from this domain
+const paypalCertDomain = "paypal.com"
+
+// paypalAuthAlgo - the only signing algorithm PayPal uses for webhook events
+const paypalAuthAlgo = "SHA256withRSA"
+
+// paypalTransmissionTolerance - how far the signed transmission time can be from ours, older events are replays
+const paypalTransmissionTolerance = 5 * time.Minute
+
+// maxPaypalCertBytes - the largest certificate chain we download
+const maxPaypalCertBytes = 1 << 16
+
+// ErrPaypalSignature - the webhook event wasn't signed by PayPal for our webhook
+var ErrPaypalSignature = errors.New("invalid paypal signature")
+
+// paypalCerts - the certificates that were already downloaded and verified, by their url
+var paypalCerts = struct {
+	sync.Mutex
+	byURL map[string]*x509.Certificate
+}{byURL: make(map[string]*x509.Certificate)}
+
+type paypalEvent struct {
+	ID         string          `json:"id"`
+	EventType  string          `json:"event_type"`
+	CreateTime string          `json:"create_time"`
+	Resource   json.RawMessage `json:"resource"`
+}
+
+type paypalSale struct {
+	ID     string `json:"id"`
+	State  string `json:"state"`
+	Amount struct {
+		Total    string `json:"total"`
+		Currency string `json:"currency"`
+	} `json:"amount"`
+	CreateTime         string `json:"create_time"`
+	BillingAgreementID string `json:"billing_agreement_id"`
+	SaleID             string `json:"sale_id"`
+}
+
+type paypalSubscription struct {
+	ID               string `json:"id"`
+	Status           string `json:"status"`
+	StatusUpdateTime string `json:"status_update_time"`
+	Subscriber       struct {
+		Email string `json:"email_address"`
+		Name  struct {
+			GivenName string `json:"given_name"`
+			Surname   string `json:"surname"`
+		} `json:"name"`
+	} `json:"subscriber"`
+}
+
+// HandlePaypalWebhook - verifies and applies an event that PayPal sent to our webhook.
+//   Completed sales are recorded as payments the same way downloaded payments are
+//   and the recorded payments are returned so their members can be updated.
+//   Refunds and reversals are recorded against the sale they give money back for.
+//   Cancelled subscriptions are flagged on the member.
+//   PayPal sends an event again until we acknowledge it, events are only applied once.
+func HandlePaypalWebhook(payload []byte, header http.Header) ([]database.Payment, error) {
+	c, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error with config: %v", err)
+	}
+
+	if len(c.PaypalWebhookID) == 0 {
+		return nil, fmt.Errorf("not a proper value for paypalWebhookID in the config")
+	}
+
+	verifier := paypalVerifier{
+		webhookID:  c.PaypalWebhookID,
+		certDomain: paypalCertDomain,
+		client:     http.DefaultClient,
+	}
+
+	err = verifier.verify(payload, header, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var event paypalEvent
+
+	err = json.Unmarshal(payload, &event)
+	if err != nil || event.ID == "" {
+		return nil, fmt.Errorf("error reading paypal event: %v", err)
+	}
+
+	db, err := database.Setup()
+	if err != nil {
+		return nil, fmt.Errorf("error setting up db: %v", err)
+	}
+	defer db.Release()
+
+	claimed, err := db.ClaimWebhookEvent(database.Paypal, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		log.Debugf("paypal event %s was already handled", event.ID)
+		return nil, nil
+	}
+
+	pp := paypalProvider{
+		url:          c.PaypalURL,
+		clientID:     c.PaypalClientID,
+		clientSecret: c.PaypalClientSecret,
+	}
+
+	payments, err := handlePaypalEvent(pp, event)
+	if err != nil {
+		// paypal sends the event again, it should be handled then
+		releaseErr := db.ReleaseWebhookEvent(database.Paypal, event.ID)
+		if releaseErr != nil {
+			log.Error(releaseErr)
+		}
+
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func handlePaypalEvent(pp paypalProvider, event paypalEvent) ([]database.Payment, error) {
+	switch event.EventType {
+	case "PAYMENT.SALE.COMPLETED":
+		var sale paypalSale
+
+		err := json.Unmarshal(event.Resource, &sale)
+		if err != nil {
+			return nil, fmt.Errorf("error reading paypal sale: %v", err)
+		}
+
+		// the sale doesn't say who paid, the subscription it was made for does
+		if sale.BillingAgreementID == "" {
+			log.Infof("paypal sale %s isn't for a subscription, it will be recorded when payments are downloaded", sale.ID)
+			return nil, nil
+		}
+
+		subscription, err := pp.subscription(sale.BillingAgreementID)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := saleTransaction(sale, subscription)
+		if err != nil {
+			return nil, err
+		}
+
+		payments := transactionPayments(pp, []Transaction{t})
+
+		err = processPayments(payments)
+		if err != nil {
+			return nil, err
+		}
+
+		// paying again means the member is subscribed again
+		setPaypalSubscriptionCancelled(subscription, nil)
+
+		return payments, nil
+	case "PAYMENT.SALE.REFUNDED", "PAYMENT.SALE.REVERSED":
+		var sale paypalSale
+
+		err := json.Unmarshal(event.Resource, &sale)
+		if err != nil {
+			return nil, fmt.Errorf("error reading paypal sale: %v", err)
+		}
+
+		if sale.SaleID == "" {
+			log.Infof("paypal %s %s doesn't say which sale it is for, it will be recorded when payments are downloaded", event.EventType, sale.ID)
+			return nil, nil
+		}
+
+		t, err := saleRefundTransaction(sale, event.EventType == "PAYMENT.SALE.REVERSED")
+		if err != nil {
+			return nil, err
+		}
+
+		return processRefunds(transactionRefunds(pp, []Transaction{t})), nil
+	case "BILLING.SUBSCRIPTION.CANCELLED", "BILLING.SUBSCRIPTION.SUSPENDED", "BILLING.SUBSCRIPTION.EXPIRED":
+		var subscription paypalSubscription
+
+		err := json.Unmarshal(event.Resource, &subscription)
+		if err != nil {
+			return nil, fmt.Errorf("error reading paypal subscription: %v", err)
+		}
+
+		cancelledAt, err := time.Parse(time.RFC3339, subscription.StatusUpdateTime)
+		if err != nil {
+			cancelledAt = time.Now()
+		}
+
+		setPaypalSubscriptionCancelled(subscription, &cancelledAt)
+
+		return nil, nil
+	case "BILLING.SUBSCRIPTION.ACTIVATED", "BILLING.SUBSCRIPTION.RE-ACTIVATED":
+		var subscription paypalSubscription
+
+		err := json.Unmarshal(event.Resource, &subscription)
+		if err != nil {
+			return nil, fmt.Errorf("error reading paypal subscription: %v", err)
+		}
+
+		setPaypalSubscriptionCancelled(subscription, nil)
+
+		return nil, nil
+	}
+
+	log.Debugf("ignoring paypal event %s of type %s", event.ID, event.EventType)
+
+	return nil, nil
+}
+
+// saleTransaction - a completed sale paid by the subscriber of the subscription.
+//   The details are the same as a downloaded transaction's so the sale is recorded the same way
+func saleTransaction(sale paypalSale, subscription paypalSubscription) (Transaction, error) {
+	date, err := time.Parse(time.RFC3339, sale.CreateTime)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("not a valid date for paypal sale %s: %s", sale.ID, sale.CreateTime)
+	}
+
+	currency := strings.ToUpper(sale.Amount.Currency)
+
+	amount, err := toMinorUnits(sale.Amount.Total, currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	status := "P"
+	if sale.State == "completed" {
+		status = "S"
+	}
+
+	name := strings.TrimSpace(subscription.Subscriber.Name.GivenName + " " + subscription.Subscriber.Name.Surname)
+
+	return Transaction{
+		ID:     sale.ID,
+		Date:   date,
+		Amount: *money.New(amount, currency),
+		Details: paypalTransaction{
+			Transaction: transaction{
+				ID:     sale.ID,
+				Status: status,
+				Date:   sale.CreateTime,
+				Amount: transactionAmount{CurrencyCode: currency, Value: sale.Amount.Total},
+			},
+			Payer: payer{
+				Email: subscription.Subscriber.Email,
+				Name: payerName{
+					GivenName: subscription.Subscriber.Name.GivenName,
+					Surname:   subscription.Subscriber.Name.Surname,
+					FullName:  name,
+				},
+			},
+		},
+	}, nil
+}
+
+// saleRefundTransaction - money that was given back for a sale.
+//   The details are the same as a downloaded refund's or reversal's so it is recorded the same way
+func saleRefundTransaction(refund paypalSale, reversed bool) (Transaction, error) {
+	date, err := time.Parse(time.RFC3339, refund.CreateTime)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("not a valid date for paypal refund %s: %s", refund.ID, refund.CreateTime)
+	}
+
+	currency := strings.ToUpper(refund.Amount.Currency)
+
+	amount, err := toMinorUnits(refund.Amount.Total, currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	eventCode := "T1107"
+	if reversed {
+		eventCode = "T1106"
+	}
+
+	return Transaction{
+		ID:       refund.ID,
+		Date:     date,
+		Amount:   *money.New(amount, currency),
+		RefundOf: refund.SaleID,
+		Details: paypalTransaction{
+			Transaction: transaction{
+				ID:          refund.ID,
+				Status:      "S",
+				EventCode:   eventCode,
+				ReferenceID: refund.SaleID,
+				Date:        refund.CreateTime,
+				Amount:      transactionAmount{CurrencyCode: currency, Value: refund.Amount.Total},
+			},
+		},
+	}, nil
+}
+
+// setPaypalSubscriptionCancelled - flags the subscription of the member that subscribed
+func setPaypalSubscriptionCancelled(subscription paypalSubscription, cancelledAt *time.Time) {
+	if subscription.Subscriber.Email == "" {
+		log.Infof("no subscriber on paypal subscription %s", subscription.ID)
+		return
+	}
+
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
+		return
+	}
+	defer db.Release()
+
+	member, err := db.GetMemberByEmail(subscription.Subscriber.Email)
+	if err != nil {
+		log.Infof("no member for paypal subscription %s: %s", subscription.ID, err)
+		return
+	}
+
+	err = db.SetSubscriptionCancelled(member.ID, cancelledAt)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// subscription - looks up a subscription to see who the subscriber is
+func (pp paypalProvider) subscription(id string) (paypalSubscription, error) {
+	var subscription paypalSubscription
+
+	token, err := pp.requestAccessToken()
+	if err != nil {
+		return subscription, fmt.Errorf("error getting paypal access token: %v", err)
+	}
+
+	req, err := http.NewRequest("GET", pp.url+"/v1/billing/subscriptions/"+url.PathEscape(id), nil)
+	if err != nil {
+		return subscription, err
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return subscription, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return subscription, fmt.Errorf("error getting paypal subscription %s: paypal responded with %s", id, res.Status)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&subscription)
+
+	return subscription, err
+}
+
+// paypalVerifier - checks that an event was signed by PayPal for our webhook.
+//   PayPal signs the transmission id, the transmission time, the webhook id and the crc32 of the event
+//   with the key of a certificate it links to in the headers.
+type paypalVerifier struct {
+	webhookID  string
+	certDomain string
+	client     *http.Client
+	// roots - the certificate authorities PayPal's certificate must chain to, the system's when nil
+	roots *x509.CertPool
+}
+
+func (v paypalVerifier) verify(payload []byte, header http.Header, now time.Time) error {
+	transmissionID := header.Get("PAYPAL-TRANSMISSION-ID")
+	transmissionTime := header.Get("PAYPAL-TRANSMISSION-TIME")
+	certURL := header.Get("PAYPAL-CERT-URL")
+
+	if transmissionID == "" || transmissionTime == "" || certURL == "" {
+		return ErrPaypalSignature
+	}
+
+	transmittedAt, err := time.Parse(time.RFC3339, transmissionTime)
+	if err != nil {
+		return ErrPaypalSignature
+	}
+
+	age := now.Sub(transmittedAt)
+	if age > paypalTransmissionTolerance || age < -paypalTransmissionTolerance {
+		return ErrPaypalSignature
+	}
+
+	if header.Get("PAYPAL-AUTH-ALGO") != paypalAuthAlgo {
+		return ErrPaypalSignature
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(header.Get("PAYPAL-TRANSMISSION-SIG"))
+	if err != nil || len(signature) == 0 {
+		return ErrPaypalSignature
+	}
+
+	cert, err := v.certificate(certURL, now)
+	if err != nil {
+		return err
+	}
+
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return ErrPaypalSignature
+	}
+
+	signed := fmt.Sprintf("%s|%s|%s|%d", transmissionID, transmissionTime, v.webhookID, crc32.ChecksumIEEE(payload))
+	digest := sha256.Sum256([]byte(signed))
+
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+		return ErrPaypalSignature
+	}
+
+	return nil
+}
+
+// certificate - downloads the certificate an event was signed with.
+//   Only certificates PayPal serves and that were issued to PayPal are trusted.
+func (v paypalVerifier) certificate(certURL string, now time.Time) (*x509.Certificate, error) {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !inDomain(u.Hostname(), v.certDomain) {
+		log.Errorf("paypal event linked to a certificate we don't trust: %s", certURL)
+		return nil, ErrPaypalSignature
+	}
+
+	paypalCerts.Lock()
+	cert, ok := paypalCerts.byURL[certURL]
+	paypalCerts.Unlock()
+
+	if ok && now.After(cert.NotBefore) && now.Before(cert.NotAfter) {
+		return cert, nil
+	}
+
+	res, err := v.client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting paypal certificate: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error getting paypal certificate: paypal responded with %s", res.Status)
+	}
+
+	chain, err := ioutil.ReadAll(io.LimitReader(res.Body, maxPaypalCertBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error getting paypal certificate: %v", err)
+	}
+
+	cert, err = verifyPaypalCertificate(chain, v.certDomain, v.roots, now)
+	if err != nil {
+		log.Errorf("error verifying paypal certificate %s: %s", certURL, err)
+		return nil, ErrPaypalSignature
+	}
+
+	paypalCerts.Lock()
+	paypalCerts.byURL[certURL] = cert
+	paypalCerts.Unlock()
+
+	return cert, nil
+}
+
+// verifyPaypalCertificate - the first certificate of the PEM chain, when it chains to the roots
+//   and was issued for a host in the domain
+func verifyPaypalCertificate(chain []byte, domain string, roots *x509.CertPool, now time.Time) (*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	for block, rest := pem.Decode(chain); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, c)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	leaf := certs[0]
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+	for _, name := range names {
+		if inDomain(name, domain) {
+			return leaf, nil
+		}
+	}
+
+	return nil, fmt.Errorf("certificate was issued to %s", leaf.Subject.CommonName)
+}
+
+// inDomain - the host is the domain or one of its subdomains
+func inDomain(host string, domain string) bool {
+	host = strings.ToLower(host)
+
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,215 @@
+package payments
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"memberserver/database"
+)
+
+func paypalFixture(t *testing.T, name string) []byte {
+	b, err := ioutil.ReadFile("testdata/paypal/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// testCertificate - a certificate for the common name signed by the parent, self signed when parent is nil
+func testCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func signPaypal(t *testing.T, key *rsa.PrivateKey, payload []byte, webhookID string, certURL string, sentAt time.Time) http.Header {
+	sent := sentAt.UTC().Format(time.RFC3339)
+
+	header := http.Header{}
+	header.Set("PAYPAL-TRANSMISSION-ID", "69cd13f0-d67a-11e5-baa3-778b53f4ae55")
+	header.Set("PAYPAL-TRANSMISSION-TIME", sent)
+	header.Set("PAYPAL-CERT-URL", certURL)
+	header.Set("PAYPAL-AUTH-ALGO", paypalAuthAlgo)
+
+	signed := fmt.Sprintf("69cd13f0-d67a-11e5-baa3-778b53f4ae55|%s|%s|%d", sent, webhookID, crc32.ChecksumIEEE(payload))
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Set("PAYPAL-TRANSMISSION-SIG", base64.StdEncoding.EncodeToString(signature))
+
+	return header
+}
+
+func TestVerifyPaypalSignature(t *testing.T) {
+	ca, caKey := testCertificate(t, "Test Root CA", nil, nil)
+	leaf, leafKey := testCertificate(t, "127.0.0.1", ca, caKey)
+	stranger, strangerKey := testCertificate(t, "127.0.0.1", nil, nil)
+
+	serve := func(certs ...*x509.Certificate) []byte {
+		var chain []byte
+		for _, c := range certs {
+			chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+		}
+		return chain
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/notifications/certs/CERT-trusted":
+			w.Write(serve(leaf, ca))
+		case "/v1/notifications/certs/CERT-stranger":
+			w.Write(serve(stranger))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	v := paypalVerifier{
+		webhookID:  "1JE4291016473214C",
+		certDomain: "127.0.0.1",
+		client:     server.Client(),
+		roots:      roots,
+	}
+
+	payload := paypalFixture(t, "sale_completed.json")
+	trusted := server.URL + "/v1/notifications/certs/CERT-trusted"
+	now := time.Now()
+
+	err := v.verify(payload, signPaypal(t, leafKey, payload, v.webhookID, trusted, now), now)
+	if err != nil {
+		t.Errorf("expected a signed event to be verified: %v", err)
+	}
+
+	noAlgo := signPaypal(t, leafKey, payload, v.webhookID, trusted, now)
+	noAlgo.Del("PAYPAL-AUTH-ALGO")
+
+	invalid := map[string]http.Header{
+		"another webhook":       signPaypal(t, leafKey, payload, "8PT597110X687430LKGECATA", trusted, now),
+		"changed payload":       signPaypal(t, leafKey, append([]byte(" "), payload...), v.webhookID, trusted, now),
+		"untrusted certificate": signPaypal(t, strangerKey, payload, v.webhookID, server.URL+"/v1/notifications/certs/CERT-stranger", now),
+		"another host":          signPaypal(t, leafKey, payload, v.webhookID, "https://example.com/v1/notifications/certs/CERT-trusted", now),
+		"not https":             signPaypal(t, leafKey, payload, v.webhookID, "http://127.0.0.1/v1/notifications/certs/CERT-trusted", now),
+		"no algorithm":          noAlgo,
+		"no headers":            {},
+		"replayed":              signPaypal(t, leafKey, payload, v.webhookID, trusted, now.Add(-time.Hour)),
+		"sent from the future":  signPaypal(t, leafKey, payload, v.webhookID, trusted, now.Add(time.Hour)),
+	}
+
+	for name, header := range invalid {
+		if err := v.verify(payload, header, now); err != ErrPaypalSignature {
+			t.Errorf("expected %s to be refused, got %v", name, err)
+		}
+	}
+}
+
+func TestPaypalSalePayment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/oauth2/token":
+			w.Write([]byte(`{"access_token": "A21AAtoken"}`))
+		case "/v1/billing/subscriptions/I-BW452GLLEP1G":
+			if req.Header.Get("Authorization") != "Bearer A21AAtoken" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(paypalFixture(t, "subscription.json"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var event paypalEvent
+
+	err := json.Unmarshal(paypalFixture(t, "sale_completed.json"), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sale paypalSale
+
+	err = json.Unmarshal(event.Resource, &sale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pp := paypalProvider{url: server.URL}
+
+	subscription, err := pp.subscription(sale.BillingAgreementID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := saleTransaction(sale, subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payments := transactionPayments(pp, []Transaction{tr})
+	if len(payments) != 1 {
+		t.Fatalf("expected the sale to be a payment, got %d payments", len(payments))
+	}
+
+	p := payments[0]
+	if p.Amount.Amount() != 3500 || p.Email != "ada@example.com" || p.Name != "Ada Lovelace" || p.Provider != database.Paypal {
+		t.Errorf("payment wasn't mapped from the sale: %+v", p)
+	}
+
+	if !p.Date.Equal(time.Date(2021, 11, 8, 18, 45, 0, 0, time.UTC)) {
+		t.Errorf("expected the payment to be dated when the sale was made, got %s", p.Date)
+	}
+
+	if _, err := pp.subscription("I-UNKNOWN"); err == nil {
+		t.Error("expected an error for a subscription paypal doesn't know")
+	}
+}
@@ -0,0 +1,202 @@
+package payments
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"memberserver/config"
+	"memberserver/database"
+
+	"github.com/Rhymond/go-money"
+	log "github.com/sirupsen/logrus"
+)
+
+// TransactionStatus - where a transaction stands with the provider
+type TransactionStatus int
+
+const (
+	// StatusCompleted - the money was received
+	StatusCompleted TransactionStatus = iota
+	// StatusPending - the transaction is waiting on something else, i.e. a bank transfer
+	StatusPending
+	// StatusDenied - the provider or merchant rules denied the transaction
+	StatusDenied
+	// StatusRefunded - some or all of the money was returned to the payer
+	StatusRefunded
+	// StatusReversed - the transaction was reversed and the money returned to the payer
+	StatusReversed
+	// StatusUnknown - the provider reported a status we don't handle
+	StatusUnknown
+)
+
+// Transaction - a payment as a provider reports it
+type Transaction struct {
+	// ID - the provider's id of the transaction
+	ID     string
+	Date   time.Time
+	Amount money.Money
+	// RefundOf - the provider's id of the payment a refund or reversal gives money back for.
+	//   Empty for payments
+	RefundOf string
+	// Details - what the provider needs to identify the payer and map the status
+	Details interface{}
+}
+
+// Provider - somewhere members pay their dues, i.e. PayPal
+type Provider interface {
+	// Kind - the provider that is recorded with each payment
+	Kind() database.PaymentProvider
+	// Transactions - the transactions made between start and end
+	Transactions(start time.Time, end time.Time) ([]Transaction, error)
+	// Payer - the name and email of whoever made the transaction
+	Payer(t Transaction) (string, string)
+	// Status - maps the provider's status of the transaction
+	Status(t Transaction) TransactionStatus
+}
+
+// providers - how to set up each provider that can be enabled in the config
+var providers = map[database.PaymentProvider]func(c config.Config) (Provider, error){
+	database.Paypal:     newPaypalProvider,
+	database.Stripe:     newStripeProvider,
+	database.QuickBooks: newQuickBooksProvider,
+}
+
+// enabledProviders - sets up the providers listed in the config.
+//   Providers that are unknown or can't be set up are logged and left out.
+func enabledProviders(c config.Config) []Provider {
+	var enabled []Provider
+
+	for _, name := range strings.Split(c.PaymentProviders, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		kind, ok := database.PaymentProviders[name]
+		if !ok {
+			log.Errorf("unknown payment provider: %s", name)
+			continue
+		}
+
+		newProvider, ok := providers[kind]
+		if !ok {
+			log.Errorf("payments can't be downloaded from %s", name)
+			continue
+		}
+
+		p, err := newProvider(c)
+		if err != nil {
+			log.Errorf("error setting up payment provider %s: %s", name, err)
+			continue
+		}
+
+		enabled = append(enabled, p)
+	}
+
+	return enabled
+}
+
+// window - a range of time transactions are downloaded for
+type window struct {
+	start time.Time
+	end   time.Time
+}
+
+// splitWindows - splits the range between start and end into windows no longer than max
+func splitWindows(start time.Time, end time.Time, max time.Duration) []window {
+	var windows []window
+
+	for !start.After(end) {
+		w := window{start: start, end: start.Add(max)}
+		if w.end.After(end) {
+			w.end = end
+		}
+
+		windows = append(windows, w)
+
+		if !w.end.Before(end) {
+			break
+		}
+
+		start = w.end
+	}
+
+	return windows
+}
+
+// providerPayments - the completed payments and the refunds of a provider between start and end
+func providerPayments(p Provider, start time.Time, end time.Time) ([]database.Payment, []database.Refund, error) {
+	transactions, err := p.Transactions(start, end)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting %s transactions: %v", p.Kind(), err)
+	}
+
+	return transactionPayments(p, transactions), transactionRefunds(p, transactions), nil
+}
+
+// transactionPayments - the payments of the completed transactions
+func transactionPayments(p Provider, transactions []Transaction) []database.Payment {
+	var payments []database.Payment
+
+	for _, t := range transactions {
+		if t.RefundOf != "" || p.Status(t) != StatusCompleted || !t.Amount.IsPositive() {
+			continue
+		}
+
+		name, email := p.Payer(t)
+
+		payments = append(payments, database.Payment{
+			Date:          t.Date,
+			Amount:        t.Amount,
+			Provider:      p.Kind(),
+			Email:         email,
+			Name:          name,
+			TransactionID: t.ID,
+		})
+	}
+
+	return payments
+}
+
+// transactionRefunds - the refunds and reversals of payments
+func transactionRefunds(p Provider, transactions []Transaction) []database.Refund {
+	var refunds []database.Refund
+
+	for _, t := range transactions {
+		if t.RefundOf == "" {
+			continue
+		}
+
+		kind := database.PaymentRefund
+
+		switch p.Status(t) {
+		case StatusRefunded:
+		case StatusReversed:
+			kind = database.PaymentReversal
+		default:
+			continue
+		}
+
+		// providers report what was given back as either a negative or a positive amount
+		amount := t.Amount.Amount()
+		if amount < 0 {
+			amount = -amount
+		}
+
+		_, email := p.Payer(t)
+
+		refunds = append(refunds, database.Refund{
+			Provider:             p.Kind(),
+			TransactionID:        t.ID,
+			PaymentTransactionID: t.RefundOf,
+			Email:                email,
+			Kind:                 kind,
+			Date:                 t.Date,
+			Amount:               amount,
+			Currency:             t.Amount.Currency().Code,
+		})
+	}
+
+	return refunds
+}
@@ -0,0 +1,110 @@
+package payments
+
+import (
+	"testing"
+	"time"
+
+	"memberserver/config"
+	"memberserver/database"
+
+	"github.com/Rhymond/go-money"
+)
+
+type fakeProvider struct {
+	transactions []Transaction
+}
+
+type fakeDetails struct {
+	name   string
+	email  string
+	status TransactionStatus
+}
+
+func (f fakeProvider) Kind() database.PaymentProvider {
+	return database.QuickBooks
+}
+
+func (f fakeProvider) Transactions(start time.Time, end time.Time) ([]Transaction, error) {
+	return f.transactions, nil
+}
+
+func (f fakeProvider) Payer(t Transaction) (string, string) {
+	d := t.Details.(fakeDetails)
+	return d.name, d.email
+}
+
+func (f fakeProvider) Status(t Transaction) TransactionStatus {
+	return t.Details.(fakeDetails).status
+}
+
+func TestProviderPayments(t *testing.T) {
+	provider := fakeProvider{transactions: []Transaction{
+		{ID: "1", Amount: *money.New(3500, "USD"), Details: fakeDetails{"Ada", "ada@example.com", StatusCompleted}},
+		{ID: "2", Amount: *money.New(3500, "USD"), Details: fakeDetails{"Bob", "bob@example.com", StatusPending}},
+		{ID: "3", Amount: *money.New(3500, "USD"), Details: fakeDetails{"Cy", "cy@example.com", StatusReversed}},
+	}}
+
+	payments, _, err := providerPayments(provider, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payments) != 1 {
+		t.Fatalf("expected only the completed transaction, got %d payments", len(payments))
+	}
+
+	p := payments[0]
+	if p.Email != "ada@example.com" || p.Name != "Ada" || p.Provider != database.QuickBooks || p.Amount.Amount() != 3500 {
+		t.Errorf("payment wasn't mapped from the transaction: %+v", p)
+	}
+}
+
+func TestTransactionRefunds(t *testing.T) {
+	provider := fakeProvider{}
+
+	transactions := []Transaction{
+		{ID: "1", Amount: *money.New(3500, "USD"), Details: fakeDetails{"Ada", "ada@example.com", StatusCompleted}},
+		{ID: "2", RefundOf: "1", Amount: *money.New(-1000, "USD"), Details: fakeDetails{status: StatusRefunded}},
+		{ID: "3", RefundOf: "1", Amount: *money.New(2500, "USD"), Details: fakeDetails{status: StatusReversed}},
+		{ID: "4", RefundOf: "1", Amount: *money.New(500, "USD"), Details: fakeDetails{status: StatusPending}},
+	}
+
+	// refunds aren't payments themselves
+	if payments := transactionPayments(provider, transactions); len(payments) != 1 || payments[0].TransactionID != "1" {
+		t.Errorf("expected only the payment, got %+v", payments)
+	}
+
+	refunds := transactionRefunds(provider, transactions)
+	if len(refunds) != 2 {
+		t.Fatalf("expected the refund and the reversal, got %d refunds", len(refunds))
+	}
+
+	if r := refunds[0]; r.Kind != database.PaymentRefund || r.Amount != 1000 || r.PaymentTransactionID != "1" || r.TransactionID != "2" {
+		t.Errorf("refund wasn't mapped: %+v", r)
+	}
+
+	if r := refunds[1]; r.Kind != database.PaymentReversal || r.Amount != 2500 || r.Provider != database.QuickBooks {
+		t.Errorf("reversal wasn't mapped: %+v", r)
+	}
+}
+
+func TestEnabledProviders(t *testing.T) {
+	c := config.Config{
+		PaymentProviders:   "PayPal, cash, nope",
+		PaypalClientID:     "id",
+		PaypalClientSecret: "secret",
+		PaypalURL:          "https://paypal.example.com",
+	}
+
+	enabled := enabledProviders(c)
+
+	// cash is recorded by admins and unknown providers are left out
+	if len(enabled) != 1 || enabled[0].Kind() != database.Paypal {
+		t.Errorf("expected only paypal to be enabled, got %v", enabled)
+	}
+
+	c.PaypalClientSecret = ""
+	if enabled := enabledProviders(c); len(enabled) != 0 {
+		t.Errorf("expected paypal without a secret to be left out, got %v", enabled)
+	}
+}
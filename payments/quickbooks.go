@@ -0,0 +1,402 @@
+package payments
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"memberserver/config"
+	"memberserver/database"
+
+	"github.com/Rhymond/go-money"
+	log "github.com/sirupsen/logrus"
+)
+
+// quickBooksPageSize - the most records QuickBooks returns for a query
+const quickBooksPageSize = 1000
+
+// quickBooksEntities - the transactions the treasurer records dues with.
+//   Sales receipts are paid on the spot, payments pay off an invoice.
+var quickBooksEntities = []string{"SalesReceipt", "Payment"}
+
+type quickBooksRef struct {
+	Value string `json:"value"`
+	Name  string `json:"name"`
+}
+
+type quickBooksEmail struct {
+	Address string `json:"Address"`
+}
+
+type quickBooksTransaction struct {
+	ID          string          `json:"Id"`
+	TxnDate     string          `json:"TxnDate"`
+	TotalAmt    json.Number     `json:"TotalAmt"`
+	CurrencyRef quickBooksRef   `json:"CurrencyRef"`
+	CustomerRef quickBooksRef   `json:"CustomerRef"`
+	BillEmail   quickBooksEmail `json:"BillEmail"`
+}
+
+type quickBooksCustomer struct {
+	ID               string          `json:"Id"`
+	DisplayName      string          `json:"DisplayName"`
+	PrimaryEmailAddr quickBooksEmail `json:"PrimaryEmailAddr"`
+}
+
+type quickBooksQueryResponse struct {
+	QueryResponse struct {
+		SalesReceipt []quickBooksTransaction `json:"SalesReceipt"`
+		Payment      []quickBooksTransaction `json:"Payment"`
+	} `json:"QueryResponse"`
+}
+
+type quickBooksCustomerResponse struct {
+	Customer quickBooksCustomer `json:"Customer"`
+}
+
+type quickBooksTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// quickBooksDetails - who a QuickBooks transaction was recorded for
+type quickBooksDetails struct {
+	entity string
+	name   string
+	email  string
+}
+
+// quickBooksTokens - where the refresh token is kept between runs.
+//   QuickBooks gives us a new refresh token each time we connect and the old one stops working.
+type quickBooksTokens interface {
+	refreshToken() (string, error)
+	saveRefreshToken(token string) error
+}
+
+// databaseTokens - keeps the refresh token in the database.
+//   The token in the config is only used until QuickBooks gives us a new one.
+type databaseTokens struct {
+	initial string
+}
+
+func (t databaseTokens) refreshToken() (string, error) {
+	db, err := database.Setup()
+	if err != nil {
+		return "", err
+	}
+	defer db.Release()
+
+	token, err := db.GetRefreshToken(database.QuickBooks)
+	if err != nil || token != "" {
+		return token, err
+	}
+
+	return t.initial, nil
+}
+
+func (t databaseTokens) saveRefreshToken(token string) error {
+	db, err := database.Setup()
+	if err != nil {
+		return err
+	}
+	defer db.Release()
+
+	return db.SetRefreshToken(database.QuickBooks, token)
+}
+
+// quickBooksProvider - the cash, check and bank transfer dues the treasurer records in QuickBooks Online
+type quickBooksProvider struct {
+	url          string
+	tokenURL     string
+	realmID      string
+	clientID     string
+	clientSecret string
+	tokens       quickBooksTokens
+	accessToken  string
+	customers    map[string]quickBooksCustomer
+}
+
+func newQuickBooksProvider(c config.Config) (Provider, error) {
+	if len(c.QuickBooksRealmID) == 0 {
+		return nil, fmt.Errorf("not a proper value for quickBooksRealmID in the config")
+	}
+	if len(c.QuickBooksClientID) == 0 {
+		return nil, fmt.Errorf("not a proper value for quickBooksClientID in the config")
+	}
+	if len(c.QuickBooksClientSecret) == 0 {
+		return nil, fmt.Errorf("not a proper value for quickBooksClientSecret in the config")
+	}
+	if len(c.QuickBooksURL) == 0 || len(c.QuickBooksTokenURL) == 0 {
+		return nil, fmt.Errorf("not a proper value for quickBooksURL in the config")
+	}
+
+	return &quickBooksProvider{
+		url:          c.QuickBooksURL,
+		tokenURL:     c.QuickBooksTokenURL,
+		realmID:      c.QuickBooksRealmID,
+		clientID:     c.QuickBooksClientID,
+		clientSecret: c.QuickBooksClientSecret,
+		tokens:       databaseTokens{initial: c.QuickBooksRefreshToken},
+		customers:    make(map[string]quickBooksCustomer),
+	}, nil
+}
+
+// Kind - payments are recorded as QuickBooks payments
+func (qb *quickBooksProvider) Kind() database.PaymentProvider {
+	return database.QuickBooks
+}
+
+// Transactions - the sales receipts and payments recorded between start and end
+func (qb *quickBooksProvider) Transactions(start time.Time, end time.Time) ([]Transaction, error) {
+	var transactions []Transaction
+
+	for _, entity := range quickBooksEntities {
+		records, err := qb.query(entity, start, end)
+		if err != nil {
+			return transactions, err
+		}
+
+		for _, r := range records {
+			t, err := qb.transaction(entity, r)
+			if err != nil {
+				log.Errorf("error reading QuickBooks %s %s: %s", entity, r.ID, err)
+				continue
+			}
+
+			transactions = append(transactions, t)
+		}
+	}
+
+	return transactions, nil
+}
+
+// Payer - the customer the transaction was recorded for
+func (qb *quickBooksProvider) Payer(t Transaction) (string, string) {
+	details, ok := t.Details.(quickBooksDetails)
+	if !ok {
+		return "", ""
+	}
+
+	return details.name, details.email
+}
+
+// Status - money that is recorded in QuickBooks has been received.
+//   Voided transactions are left with an amount of zero, they give back all of the payment that was recorded
+func (qb *quickBooksProvider) Status(t Transaction) TransactionStatus {
+	if _, ok := t.Details.(quickBooksDetails); !ok {
+		return StatusUnknown
+	}
+
+	if t.RefundOf != "" {
+		return StatusRefunded
+	}
+
+	return StatusCompleted
+}
+
+func (qb *quickBooksProvider) transaction(entity string, r quickBooksTransaction) (Transaction, error) {
+	date, err := time.Parse("2006-01-02", r.TxnDate)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("not a valid date: %s", r.TxnDate)
+	}
+
+	currency := strings.ToUpper(r.CurrencyRef.Value)
+	if currency == "" {
+		currency = "USD"
+	}
+
+	amount, err := toMinorUnits(r.TotalAmt.String(), currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	details := quickBooksDetails{
+		entity: entity,
+		name:   r.CustomerRef.Name,
+		email:  r.BillEmail.Address,
+	}
+
+	// payments don't have an email of their own, voided transactions don't need one
+	if details.email == "" && r.CustomerRef.Value != "" && amount > 0 {
+		customer, err := qb.customer(r.CustomerRef.Value)
+		if err != nil {
+			return Transaction{}, err
+		}
+
+		details.email = customer.PrimaryEmailAddr.Address
+		if details.name == "" {
+			details.name = customer.DisplayName
+		}
+	}
+
+	t := Transaction{
+		ID:      entity + ":" + r.ID,
+		Date:    date,
+		Amount:  *money.New(amount, currency),
+		Details: details,
+	}
+
+	if amount == 0 {
+		t.RefundOf = t.ID
+		t.ID += ":void"
+	}
+
+	return t, nil
+}
+
+// query - walks the pages of an entity's records between start and end
+func (qb *quickBooksProvider) query(entity string, start time.Time, end time.Time) ([]quickBooksTransaction, error) {
+	var records []quickBooksTransaction
+
+	for position := 1; ; position += quickBooksPageSize {
+		q := url.Values{}
+		q.Set("query", fmt.Sprintf("SELECT * FROM %s WHERE TxnDate >= '%s' AND TxnDate <= '%s' STARTPOSITION %d MAXRESULTS %d",
+			entity, start.Format("2006-01-02"), end.Format("2006-01-02"), position, quickBooksPageSize))
+		q.Set("minorversion", "65")
+
+		var response quickBooksQueryResponse
+
+		err := qb.get("/query?"+q.Encode(), &response)
+		if err != nil {
+			return records, fmt.Errorf("error querying QuickBooks %s: %v", entity, err)
+		}
+
+		page := response.QueryResponse.SalesReceipt
+		if entity == "Payment" {
+			page = response.QueryResponse.Payment
+		}
+
+		records = append(records, page...)
+
+		if len(page) < quickBooksPageSize {
+			return records, nil
+		}
+	}
+}
+
+// customer - looks up a QuickBooks customer, each customer is only looked up once
+func (qb *quickBooksProvider) customer(id string) (quickBooksCustomer, error) {
+	if c, ok := qb.customers[id]; ok {
+		return c, nil
+	}
+
+	var response quickBooksCustomerResponse
+
+	err := qb.get("/customer/"+url.PathEscape(id)+"?minorversion=65", &response)
+	if err != nil {
+		return response.Customer, fmt.Errorf("error getting QuickBooks customer %s: %v", id, err)
+	}
+
+	qb.customers[id] = response.Customer
+
+	return response.Customer, nil
+}
+
+// get - calls the accounting api of our company.
+//   The access token is refreshed when there isn't one yet or QuickBooks says it expired.
+func (qb *quickBooksProvider) get(path string, target interface{}) error {
+	if qb.accessToken == "" {
+		err := qb.refreshAccessToken()
+		if err != nil {
+			return err
+		}
+	}
+
+	res, err := qb.request(path)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		res.Body.Close()
+
+		err = qb.refreshAccessToken()
+		if err != nil {
+			return err
+		}
+
+		res, err = qb.request(path)
+		if err != nil {
+			return err
+		}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("QuickBooks responded with %s", res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(target)
+}
+
+func (qb *quickBooksProvider) request(path string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", qb.url+"/v3/company/"+qb.realmID+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+qb.accessToken)
+	req.Header.Add("Accept", "application/json")
+
+	return http.DefaultClient.Do(req)
+}
+
+// refreshAccessToken - trades the refresh token for an access token.
+//   The new refresh token QuickBooks sends back is saved, the old one won't work again.
+func (qb *quickBooksProvider) refreshAccessToken() error {
+	refreshToken, err := qb.tokens.refreshToken()
+	if err != nil {
+		return fmt.Errorf("error getting QuickBooks refresh token: %v", err)
+	}
+	if refreshToken == "" {
+		return fmt.Errorf("not a proper value for quickBooksRefreshToken in the config")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+
+	req, err := http.NewRequest("POST", qb.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(qb.clientID+":"+qb.clientSecret)))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("QuickBooks refused the refresh token: %s", res.Status)
+	}
+
+	var token quickBooksTokenResponse
+
+	err = json.NewDecoder(res.Body).Decode(&token)
+	if err != nil {
+		return err
+	}
+
+	if token.AccessToken == "" {
+		return fmt.Errorf("invalid token from QuickBooks")
+	}
+
+	if token.RefreshToken != "" && token.RefreshToken != refreshToken {
+		err = qb.tokens.saveRefreshToken(token.RefreshToken)
+		if err != nil {
+			return fmt.Errorf("error saving QuickBooks refresh token: %v", err)
+		}
+	}
+
+	qb.accessToken = token.AccessToken
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package payments
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"memberserver/database"
+)
+
+type memoryTokens struct {
+	token string
+	saved []string
+}
+
+func (m *memoryTokens) refreshToken() (string, error) {
+	return m.token, nil
+}
+
+func (m *memoryTokens) saveRefreshToken(token string) error {
+	m.token = token
+	m.saved = append(m.saved, token)
+	return nil
+}
+
+func quickBooksFixture(t *testing.T, name string) []byte {
+	b, err := ioutil.ReadFile("testdata/quickbooks/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// quickBooksServer - replays recorded QuickBooks responses.
+//   The first access token it gives out is treated as expired to test refreshing it again.
+func quickBooksServer(t *testing.T) *httptest.Server {
+	tokens := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/oauth2/v1/tokens/bearer":
+			req.ParseForm()
+			if req.Form.Get("grant_type") != "refresh_token" || req.Form.Get("refresh_token") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			tokens++
+			w.Write(quickBooksFixture(t, "token.json"))
+		case tokens < 2:
+			w.WriteHeader(http.StatusUnauthorized)
+		case req.URL.Path == "/v3/company/4620816365/query":
+			query := req.URL.Query().Get("query")
+			if strings.Contains(query, "FROM SalesReceipt") {
+				w.Write(quickBooksFixture(t, "salesreceipts.json"))
+				return
+			}
+			w.Write(quickBooksFixture(t, "payments.json"))
+		case req.URL.Path == "/v3/company/4620816365/customer/59":
+			w.Write(quickBooksFixture(t, "customer_59.json"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestQuickBooksTransactions(t *testing.T) {
+	server := quickBooksServer(t)
+	defer server.Close()
+
+	tokens := &memoryTokens{token: "AB11initialRefreshToken"}
+
+	qb := &quickBooksProvider{
+		url:          server.URL,
+		tokenURL:     server.URL + "/oauth2/v1/tokens/bearer",
+		realmID:      "4620816365",
+		clientID:     "client",
+		clientSecret: "secret",
+		tokens:       tokens,
+		customers:    make(map[string]quickBooksCustomer),
+	}
+
+	transactions, err := qb.Transactions(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 6, 30, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transactions) != 3 {
+		t.Fatalf("expected the sales receipts and payments, got %d transactions", len(transactions))
+	}
+
+	// the voided sales receipt isn't a payment
+	payments := transactionPayments(qb, transactions)
+	if len(payments) != 2 {
+		t.Fatalf("expected 2 payments, got %d", len(payments))
+	}
+
+	// it gives back all of the sales receipt that was recorded
+	refunds := transactionRefunds(qb, transactions)
+	if len(refunds) != 1 || refunds[0].PaymentTransactionID != "SalesReceipt:152" || refunds[0].Amount != 0 || refunds[0].Kind != database.PaymentRefund {
+		t.Errorf("expected the voided sales receipt to be a refund, got %+v", refunds)
+	}
+
+	receipt := payments[0]
+	if receipt.Email != "ada@example.com" || receipt.Amount.Amount() != 3500 || receipt.Provider != database.QuickBooks {
+		t.Errorf("sales receipt wasn't mapped: %+v", receipt)
+	}
+
+	// payments don't have an email so it comes from the customer
+	payment := payments[1]
+	if payment.Email != "grace@example.com" || payment.Name != "Grace Hopper" || payment.Amount.Amount() != 4999 {
+		t.Errorf("payment wasn't mapped: %+v", payment)
+	}
+
+	if len(tokens.saved) == 0 || tokens.token != "AB11654321rotatedRefreshToken" {
+		t.Errorf("expected the rotated refresh token to be saved, got %v", tokens.saved)
+	}
+}
+
+func TestQuickBooksRefusedRefreshToken(t *testing.T) {
+	server := quickBooksServer(t)
+	defer server.Close()
+
+	qb := &quickBooksProvider{
+		url:       server.URL,
+		tokenURL:  server.URL + "/oauth2/v1/tokens/bearer",
+		realmID:   "4620816365",
+		tokens:    &memoryTokens{},
+		customers: make(map[string]quickBooksCustomer),
+	}
+
+	if _, err := qb.Transactions(time.Now(), time.Now()); err == nil {
+		t.Error("expected an error without a refresh token")
+	}
+}
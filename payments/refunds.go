@@ -0,0 +1,212 @@
+package payments
+
+import (
+	"time"
+
+	"memberserver/config"
+	"memberserver/database"
+	"memberserver/mail"
+
+	"github.com/Rhymond/go-money"
+	log "github.com/sirupsen/logrus"
+)
+
+// reversalAlert - what leadership is told about a reversal
+type reversalAlert struct {
+	Name        string
+	Email       string
+	Provider    string
+	Amount      string
+	Tier        string
+	PaidThrough string
+}
+
+// unlinkedReversalAlert - what leadership is told about a reversal of a payment that wasn't found
+type unlinkedReversalAlert struct {
+	Email                string
+	Provider             string
+	Amount               string
+	Date                 string
+	TransactionID        string
+	PaymentTransactionID string
+}
+
+// processRefunds records refunds and reversals against the payments they give money back for
+//  and counts the payments of their members again, so the paid through dates are shortened.
+//  Leadership is told when a reversal leaves a member's payments short of their tier
+//  and when the payment a reversal is of couldn't be found.
+//  The refunds that were recorded are returned with the email of their member
+func processRefunds(refunds []database.Refund) []database.Payment {
+	if len(refunds) == 0 {
+		return nil
+	}
+
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
+		return nil
+	}
+	defer db.Release()
+
+	recorded, unlinked, err := db.AddRefunds(refunds)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+
+	for _, r := range unlinked {
+		if r.Kind == database.PaymentReversal {
+			alertUnlinkedReversal(db, r)
+		}
+	}
+
+	members := make(map[string]database.Member)
+	before := make(map[string]database.MembershipStatus)
+	var memberIDs []string
+
+	for i, r := range recorded {
+		m, ok := members[r.MemberID]
+		if !ok {
+			m, err = db.GetMemberByID(r.MemberID)
+			if err != nil {
+				log.Errorf("error getting member of refund %s: %s", r.TransactionID, err)
+				continue
+			}
+
+			status, err := db.GetMembershipStatus(m)
+			if err != nil {
+				log.Error(err)
+			}
+
+			members[m.ID] = m
+			before[m.ID] = status
+			memberIDs = append(memberIDs, m.ID)
+		}
+
+		recorded[i].Email = m.Email
+		recorded[i].Name = m.Name
+	}
+
+	err = db.RebuildPaidThrough(memberIDs)
+	if err != nil {
+		log.Errorf("error counting refunded payments again: %s", err)
+	}
+
+	db.UpdateMemberTiers()
+
+	alerted := make(map[string]bool)
+
+	for _, r := range recorded {
+		m, ok := members[r.MemberID]
+		if !ok || r.Kind != database.PaymentReversal || alerted[m.ID] {
+			continue
+		}
+
+		// the tier may have changed when the payments were counted again
+		updated, err := db.GetMemberByID(m.ID)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		after, err := db.GetMembershipStatus(updated)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		if !belowTier(before[m.ID], after, time.Now()) {
+			continue
+		}
+
+		alerted[m.ID] = true
+		alertReversal(db, m, r, before[m.ID], after)
+	}
+
+	return recorded
+}
+
+// belowTier - whether a member's payments stopped covering their tier,
+//   they were paid up before and aren't anymore or they were moved to another tier.
+//   Members that aren't paying for a tier, i.e. Credited, are never below it
+func belowTier(before database.MembershipStatus, after database.MembershipStatus, now time.Time) bool {
+	if before.Level.IsProtected() {
+		return false
+	}
+
+	if after.Level != before.Level {
+		return true
+	}
+
+	return paidUp(before, now) && !paidUp(after, now)
+}
+
+func paidUp(status database.MembershipStatus, now time.Time) bool {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	return status.PaidThrough != nil && !status.PaidThrough.Before(today)
+}
+
+// alertReversal - emails leadership that a reversal left a member short of their tier
+func alertReversal(db *database.Database, m database.Member, reversal database.Payment, before database.MembershipStatus, after database.MembershipStatus) {
+	c, err := config.Load()
+	if err != nil {
+		log.Errorf("error with config: %s", err)
+		return
+	}
+
+	mailApi, err := mail.Setup()
+	if err != nil {
+		log.Errorf("error setting up mail to alert leadership of a reversal: %s", err)
+		return
+	}
+
+	model := reversalAlert{
+		Name:     m.Name,
+		Email:    m.Email,
+		Provider: reversal.Provider.String(),
+		Amount:   money.New(-reversal.Amount.Amount(), reversal.Amount.Currency().Code).Display(),
+		Tier:     before.Tier,
+	}
+	if after.PaidThrough != nil {
+		model.PaidThrough = after.PaidThrough.Format("2006-01-02")
+	}
+
+	mailer := mail.NewMailer(db, mailApi, c)
+
+	_, err = mailer.SendCommunication(mail.PaymentReversedLeadership, c.AdminEmail, model)
+	if err != nil {
+		log.Errorf("error alerting leadership of a reversal: %s", err)
+	}
+}
+
+// alertUnlinkedReversal - emails leadership that a reversal couldn't be recorded because its payment wasn't found
+func alertUnlinkedReversal(db *database.Database, reversal database.Refund) {
+	c, err := config.Load()
+	if err != nil {
+		log.Errorf("error with config: %s", err)
+		return
+	}
+
+	mailApi, err := mail.Setup()
+	if err != nil {
+		log.Errorf("error setting up mail to alert leadership of a reversal: %s", err)
+		return
+	}
+
+	model := unlinkedReversalAlert{
+		Email:                reversal.Email,
+		Provider:             reversal.Provider.String(),
+		Amount:               money.New(reversal.Amount, reversal.Currency).Display(),
+		Date:                 reversal.Date.Format("2006-01-02"),
+		TransactionID:        reversal.TransactionID,
+		PaymentTransactionID: reversal.PaymentTransactionID,
+	}
+
+	mailer := mail.NewMailer(db, mailApi, c)
+
+	_, err = mailer.SendCommunication(mail.PaymentReversalUnlinked, c.AdminEmail, model)
+	if err != nil {
+		log.Errorf("error alerting leadership of a reversal that wasn't recorded: %s", err)
+	}
+}
@@ -0,0 +1,35 @@
+package payments
+
+import (
+	"testing"
+	"time"
+
+	"memberserver/database"
+)
+
+func TestBelowTier(t *testing.T) {
+	now := time.Date(2021, 7, 10, 15, 0, 0, 0, time.UTC)
+	nextMonth := time.Date(2021, 8, 5, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2021, 7, 10, 0, 0, 0, 0, time.UTC)
+	lastMonth := time.Date(2021, 6, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		before   database.MembershipStatus
+		after    database.MembershipStatus
+		expected bool
+	}{
+		{"no longer paid up", database.MembershipStatus{Level: database.Standard, PaidThrough: &nextMonth}, database.MembershipStatus{Level: database.Standard, PaidThrough: &lastMonth}, true},
+		{"never paid", database.MembershipStatus{Level: database.Standard, PaidThrough: &nextMonth}, database.MembershipStatus{Level: database.Standard}, true},
+		{"moved to another tier", database.MembershipStatus{Level: database.Premium, PaidThrough: &nextMonth}, database.MembershipStatus{Level: database.Standard, PaidThrough: &nextMonth}, true},
+		{"still paid up", database.MembershipStatus{Level: database.Standard, PaidThrough: &nextMonth}, database.MembershipStatus{Level: database.Standard, PaidThrough: &today}, false},
+		{"already past due", database.MembershipStatus{Level: database.Standard, PaidThrough: &lastMonth}, database.MembershipStatus{Level: database.Standard}, false},
+		{"credited", database.MembershipStatus{Level: database.Credited, PaidThrough: &nextMonth}, database.MembershipStatus{Level: database.Credited}, false},
+	}
+
+	for _, test := range tests {
+		if got := belowTier(test.before, test.after, now); got != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
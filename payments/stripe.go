@@ -0,0 +1,538 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"memberserver/config"
+	"memberserver/database"
+
+	"github.com/Rhymond/go-money"
+	log "github.com/sirupsen/logrus"
+)
+
+// stripeSignatureTolerance - how old a webhook event can be before it is treated as a replay
+const stripeSignatureTolerance = 5 * time.Minute
+
+// ErrStripeSignature - the webhook event wasn't signed with our webhook secret
+var ErrStripeSignature = errors.New("invalid stripe signature")
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// stripePaymentLookback - how long before it was paid an invoice can have been created.
+//   Stripe retries a failed card for up to two months, invoices that are collected by hand can take longer
+const stripePaymentLookback = 90 * 24 * time.Hour
+
+type stripeInvoice struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	CustomerEmail     string `json:"customer_email"`
+	CustomerName      string `json:"customer_name"`
+	AmountPaid        int64  `json:"amount_paid"`
+	Currency          string `json:"currency"`
+	Created           int64  `json:"created"`
+	StatusTransitions struct {
+		PaidAt int64 `json:"paid_at"`
+	} `json:"status_transitions"`
+}
+
+type stripeCharge struct {
+	ID             string `json:"id"`
+	Customer       string `json:"customer"`
+	Invoice        string `json:"invoice"`
+	AmountRefunded int64  `json:"amount_refunded"`
+	Currency       string `json:"currency"`
+	Created        int64  `json:"created"`
+	BillingDetails struct {
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	} `json:"billing_details"`
+	Refunds stripeRefundList `json:"refunds"`
+}
+
+type stripeRefund struct {
+	ID       string `json:"id"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Created  int64  `json:"created"`
+	Status   string `json:"status"`
+}
+
+type stripeRefundList struct {
+	Data []stripeRefund `json:"data"`
+}
+
+// stripeDispute - a chargeback the card holder's bank made
+type stripeDispute struct {
+	ID       string `json:"id"`
+	Charge   string `json:"charge"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Created  int64  `json:"created"`
+}
+
+type stripeSubscription struct {
+	ID         string `json:"id"`
+	Customer   string `json:"customer"`
+	CanceledAt int64  `json:"canceled_at"`
+}
+
+type stripeCustomer struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+type stripeInvoiceList struct {
+	Data    []stripeInvoice `json:"data"`
+	HasMore bool            `json:"has_more"`
+}
+
+// stripeDetails - who made a stripe transaction and where it stands
+type stripeDetails struct {
+	customer string
+	email    string
+	name     string
+	status   TransactionStatus
+}
+
+// stripeProvider - payments made by card through stripe.
+//   Stripe sends its events to our webhook as they happen,
+//   the invoices are also downloaded with the other providers in case an event was missed.
+type stripeProvider struct {
+	url       string
+	secretKey string
+}
+
+func newStripeProvider(c config.Config) (Provider, error) {
+	if len(c.StripeSecretKey) == 0 {
+		return nil, fmt.Errorf("not a proper value for stripeSecretKey in the config")
+	}
+	if len(c.StripeURL) == 0 {
+		return nil, fmt.Errorf("not a proper value for stripeURL in the config")
+	}
+
+	return stripeProvider{
+		url:       c.StripeURL,
+		secretKey: c.StripeSecretKey,
+	}, nil
+}
+
+// Kind - payments are recorded as stripe payments
+func (sp stripeProvider) Kind() database.PaymentProvider {
+	return database.Stripe
+}
+
+// Transactions - downloads the invoices that were paid between start and end
+func (sp stripeProvider) Transactions(start time.Time, end time.Time) ([]Transaction, error) {
+	transactions, err := sp.paidInvoices(start, end)
+	if err != nil {
+		return transactions, err
+	}
+
+	return resolveStripeCustomers(transactions), nil
+}
+
+// Payer - the name and email of the stripe customer
+func (sp stripeProvider) Payer(t Transaction) (string, string) {
+	details, ok := t.Details.(stripeDetails)
+	if !ok {
+		return "", ""
+	}
+
+	return details.name, details.email
+}
+
+// Status - the status the transaction was given when it was read from stripe
+func (sp stripeProvider) Status(t Transaction) TransactionStatus {
+	details, ok := t.Details.(stripeDetails)
+	if !ok {
+		return StatusUnknown
+	}
+
+	return details.status
+}
+
+func (sp stripeProvider) get(path string, target interface{}) error {
+	req, err := http.NewRequest("GET", sp.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", "Bearer "+sp.secretKey)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("stripe responded with %s", res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(target)
+}
+
+// paidInvoices - walks the pages of invoices that were paid between start and end.
+//   Stripe only filters invoices by when they were created, an invoice can be paid long after that
+//   when a failed card is retried or the invoice is collected by hand.  The invoices created up to
+//   stripePaymentLookback before start are downloaded and the ones that weren't paid between start and end are dropped
+func (sp stripeProvider) paidInvoices(start time.Time, end time.Time) ([]Transaction, error) {
+	var transactions []Transaction
+
+	startingAfter := ""
+	created := start.Add(-stripePaymentLookback)
+
+	for {
+		path := fmt.Sprintf("/v1/invoices?status=paid&limit=100&created[gte]=%d&created[lte]=%d", created.Unix(), end.Unix())
+		if startingAfter != "" {
+			path += "&starting_after=" + startingAfter
+		}
+
+		var invoices stripeInvoiceList
+
+		err := sp.get(path, &invoices)
+		if err != nil {
+			return transactions, err
+		}
+
+		for _, inv := range invoices.Data {
+			t := invoiceTransaction(inv)
+			if t.Date.Before(start) || t.Date.After(end) {
+				continue
+			}
+
+			transactions = append(transactions, t)
+		}
+
+		if !invoices.HasMore || len(invoices.Data) == 0 {
+			return transactions, nil
+		}
+
+		startingAfter = invoices.Data[len(invoices.Data)-1].ID
+	}
+}
+
+// customerEmail - looks up the email of a stripe customer
+func (sp stripeProvider) customerEmail(customerID string) (string, error) {
+	if sp.secretKey == "" {
+		return "", errors.New("no stripe secret key to look up customers with")
+	}
+
+	var customer stripeCustomer
+
+	err := sp.get("/v1/customers/"+customerID, &customer)
+	if err != nil {
+		return "", fmt.Errorf("error getting stripe customer %s: %v", customerID, err)
+	}
+
+	return customer.Email, nil
+}
+
+func invoiceTransaction(inv stripeInvoice) Transaction {
+	paidAt := inv.StatusTransitions.PaidAt
+	if paidAt == 0 {
+		paidAt = inv.Created
+	}
+
+	return Transaction{
+		ID:     inv.ID,
+		Date:   time.Unix(paidAt, 0),
+		Amount: *money.New(inv.AmountPaid, strings.ToUpper(inv.Currency)),
+		Details: stripeDetails{
+			customer: inv.Customer,
+			email:    inv.CustomerEmail,
+			name:     inv.CustomerName,
+			status:   StatusCompleted,
+		},
+	}
+}
+
+// chargeRefundTransactions - the refunds of a charge, each is given back for the invoice the charge paid
+func chargeRefundTransactions(charge stripeCharge) []Transaction {
+	var transactions []Transaction
+
+	for _, r := range charge.Refunds.Data {
+		status := StatusRefunded
+		if r.Status != "succeeded" {
+			status = StatusPending
+		}
+
+		transactions = append(transactions, Transaction{
+			ID:       r.ID,
+			Date:     time.Unix(r.Created, 0),
+			Amount:   *money.New(r.Amount, strings.ToUpper(r.Currency)),
+			RefundOf: charge.Invoice,
+			Details: stripeDetails{
+				customer: charge.Customer,
+				email:    charge.BillingDetails.Email,
+				name:     charge.BillingDetails.Name,
+				status:   status,
+			},
+		})
+	}
+
+	return transactions
+}
+
+// disputeTransaction - the money the bank took back for the invoice a disputed charge paid
+func disputeTransaction(dispute stripeDispute, charge stripeCharge) Transaction {
+	return Transaction{
+		ID:       dispute.ID,
+		Date:     time.Unix(dispute.Created, 0),
+		Amount:   *money.New(dispute.Amount, strings.ToUpper(dispute.Currency)),
+		RefundOf: charge.Invoice,
+		Details: stripeDetails{
+			customer: charge.Customer,
+			email:    charge.BillingDetails.Email,
+			name:     charge.BillingDetails.Name,
+			status:   StatusReversed,
+		},
+	}
+}
+
+// verifyStripeSignature - checks the Stripe-Signature header of a webhook event.
+//   The header has the time the event was signed and one or more signatures,
+//   i.e. t=1492774577,v1=5257a869...  Each signature is an HMAC-SHA256 of the time and the payload.
+func verifyStripeSignature(payload []byte, header string, secret string, now time.Time) error {
+	if secret == "" {
+		return errors.New("no stripe webhook secret is configured")
+	}
+
+	var timestamp string
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrStripeSignature
+	}
+
+	age := now.Sub(time.Unix(signedAt, 0))
+	if age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return ErrStripeSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, s := range signatures {
+		signature, err := hex.DecodeString(s)
+		if err == nil && hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+
+	return ErrStripeSignature
+}
+
+// resolveStripeCustomers - points the transactions of known stripe customers at their member,
+//   so a member's payments are matched even when their stripe email is different.
+//   Customers that aren't known yet are remembered for the member with the same email.
+func resolveStripeCustomers(transactions []Transaction) []Transaction {
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
+		return transactions
+	}
+	defer db.Release()
+
+	for i, t := range transactions {
+		details, ok := t.Details.(stripeDetails)
+		if !ok || details.customer == "" {
+			continue
+		}
+
+		member, err := db.GetMemberByStripeCustomer(details.customer)
+		if err == nil {
+			details.email = member.Email
+			transactions[i].Details = details
+			continue
+		}
+
+		if details.email == "" {
+			continue
+		}
+
+		member, err = db.GetMemberByEmail(details.email)
+		if err != nil {
+			continue
+		}
+
+		err = db.SetStripeCustomer(member.ID, details.customer)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	return transactions
+}
+
+// HandleStripeWebhook - verifies and applies an event that stripe sent to our webhook.
+//   Paid invoices are recorded as payments the same way downloaded payments are
+//   and the recorded payments are returned so their members can be updated.
+//   Refunds and disputes are recorded against the invoice the charge paid.
+//   Cancelled subscriptions are flagged on the member.
+func HandleStripeWebhook(payload []byte, signature string) ([]database.Payment, error) {
+	c, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error with config: %v", err)
+	}
+
+	err = verifyStripeSignature(payload, signature, c.StripeWebhookSecret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var event stripeEvent
+
+	err = json.Unmarshal(payload, &event)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stripe event: %v", err)
+	}
+
+	sp := stripeProvider{url: c.StripeURL, secretKey: c.StripeSecretKey}
+
+	switch event.Type {
+	case "invoice.paid":
+		var inv stripeInvoice
+
+		err = json.Unmarshal(event.Data.Object, &inv)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stripe invoice: %v", err)
+		}
+
+		transactions := resolveStripeCustomers([]Transaction{invoiceTransaction(inv)})
+		payments := transactionPayments(sp, transactions)
+
+		err = processPayments(payments)
+		if err != nil {
+			return nil, err
+		}
+
+		// paying again means the member is subscribed again
+		setStripeSubscriptionCancelled(sp, inv.Customer, nil)
+
+		return payments, nil
+	case "charge.refunded":
+		var charge stripeCharge
+
+		err = json.Unmarshal(event.Data.Object, &charge)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stripe charge: %v", err)
+		}
+
+		// newer versions of the api leave the refunds off the charge
+		if len(charge.Refunds.Data) == 0 {
+			err = sp.get("/v1/refunds?charge="+charge.ID, &charge.Refunds)
+			if err != nil {
+				return nil, fmt.Errorf("error getting refunds of stripe charge %s: %v", charge.ID, err)
+			}
+		}
+
+		return processRefunds(transactionRefunds(sp, chargeRefundTransactions(charge))), nil
+	case "charge.dispute.funds_withdrawn":
+		var dispute stripeDispute
+
+		err = json.Unmarshal(event.Data.Object, &dispute)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stripe dispute: %v", err)
+		}
+
+		var charge stripeCharge
+
+		err = sp.get("/v1/charges/"+dispute.Charge, &charge)
+		if err != nil {
+			return nil, fmt.Errorf("error getting disputed stripe charge %s: %v", dispute.Charge, err)
+		}
+
+		return processRefunds(transactionRefunds(sp, []Transaction{disputeTransaction(dispute, charge)})), nil
+	case "customer.subscription.deleted":
+		var subscription stripeSubscription
+
+		err = json.Unmarshal(event.Data.Object, &subscription)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stripe subscription: %v", err)
+		}
+
+		cancelledAt := time.Now()
+		if subscription.CanceledAt != 0 {
+			cancelledAt = time.Unix(subscription.CanceledAt, 0)
+		}
+
+		setStripeSubscriptionCancelled(sp, subscription.Customer, &cancelledAt)
+
+		return nil, nil
+	}
+
+	log.Debugf("ignoring stripe event %s of type %s", event.ID, event.Type)
+
+	return nil, nil
+}
+
+// setStripeSubscriptionCancelled - flags the subscription of the member that is the stripe customer.
+//   Customers we haven't seen a payment from are looked up by their email
+func setStripeSubscriptionCancelled(sp stripeProvider, customerID string, cancelledAt *time.Time) {
+	if customerID == "" {
+		return
+	}
+
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
+		return
+	}
+	defer db.Release()
+
+	member, err := db.GetMemberByStripeCustomer(customerID)
+	if err != nil && cancelledAt == nil {
+		// a customer we don't know about has nothing to clear
+		return
+	}
+	if err != nil {
+		email, err := sp.customerEmail(customerID)
+		if err != nil {
+			log.Errorf("error finding the member of stripe customer %s: %s", customerID, err)
+			return
+		}
+
+		member, err = db.GetMemberByEmail(email)
+		if err != nil {
+			log.Errorf("no member for stripe customer %s: %s", customerID, err)
+			return
+		}
+	}
+
+	err = db.SetSubscriptionCancelled(member.ID, cancelledAt)
+	if err != nil {
+		log.Error(err)
+	}
+}
@@ -0,0 +1,146 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"memberserver/database"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	b, err := ioutil.ReadFile("testdata/stripe/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func signStripe(payload []byte, secret string, at time.Time) string {
+	timestamp := fmt.Sprintf("%d", at.Unix())
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestVerifyStripeSignature(t *testing.T) {
+	payload := readFixture(t, "invoice_paid.json")
+	now := time.Now()
+
+	err := verifyStripeSignature(payload, signStripe(payload, "whsec_test", now), "whsec_test", now)
+	if err != nil {
+		t.Errorf("expected a signed event to be verified: %v", err)
+	}
+
+	invalid := map[string]string{
+		"another secret":   signStripe(payload, "whsec_other", now),
+		"replayed":         signStripe(payload, "whsec_test", now.Add(-time.Hour)),
+		"no signature":     fmt.Sprintf("t=%d", now.Unix()),
+		"empty header":     "",
+		"changed payload":  signStripe(append([]byte(" "), payload...), "whsec_test", now),
+		"not a hex digest": fmt.Sprintf("t=%d,v1=nothex", now.Unix()),
+	}
+
+	for name, header := range invalid {
+		if err := verifyStripeSignature(payload, header, "whsec_test", now); err != ErrStripeSignature {
+			t.Errorf("expected %s to be refused, got %v", name, err)
+		}
+	}
+}
+
+func TestStripeInvoicePayment(t *testing.T) {
+	var event stripeEvent
+
+	err := json.Unmarshal(readFixture(t, "invoice_paid.json"), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var inv stripeInvoice
+
+	err = json.Unmarshal(event.Data.Object, &inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payments := transactionPayments(stripeProvider{}, []Transaction{invoiceTransaction(inv)})
+	if len(payments) != 1 {
+		t.Fatalf("expected the invoice to be a payment, got %d payments", len(payments))
+	}
+
+	p := payments[0]
+	if p.Amount.Amount() != 3500 || p.Amount.Currency().Code != "USD" || p.Email != "ada@example.com" || p.Provider != database.Stripe {
+		t.Errorf("payment wasn't mapped from the invoice: %+v", p)
+	}
+
+	if !p.Date.Equal(time.Unix(1636397516, 0)) {
+		t.Errorf("expected the payment to be dated when the invoice was paid, got %s", p.Date)
+	}
+}
+
+func TestStripePaidInvoices(t *testing.T) {
+	start := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 11, 30, 0, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer sk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// invoices paid in the window can have been created well before it
+		created, _ := strconv.ParseInt(req.URL.Query().Get("created[gte]"), 10, 64)
+		if created > start.Add(-60*24*time.Hour).Unix() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		page := "invoices_page1.json"
+		if req.URL.Query().Get("starting_after") == "in_1JtFnQHaZcOtvLdQ7cVv1RnE" {
+			page = "invoices_page2.json"
+		}
+
+		w.Write(readFixture(t, page))
+	}))
+	defer server.Close()
+
+	sp := stripeProvider{url: server.URL, secretKey: "sk_test"}
+
+	transactions, err := sp.paidInvoices(start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the invoice that was paid before the window is left out
+	if len(transactions) != 4 {
+		t.Fatalf("expected the invoices paid in the window from both pages, got %d", len(transactions))
+	}
+
+	// the free trial invoice isn't a payment
+	payments := transactionPayments(sp, transactions)
+	if len(payments) != 3 || payments[1].Email != "grace@example.com" || payments[1].Amount.Amount() != 5000 {
+		t.Errorf("expected the three paid invoices to be payments, got %+v", payments)
+	}
+
+	// a failed card that was retried weeks after the invoice was created
+	if payments[2].Email != "linus@example.com" || !payments[2].Date.Equal(time.Unix(1636380000, 0)) {
+		t.Errorf("expected the retried invoice to be paid when it was retried, got %+v", payments[2])
+	}
+
+	sp.secretKey = "sk_wrong"
+	if _, err := sp.paidInvoices(start, end); err == nil {
+		t.Error("expected an error when stripe refuses the key")
+	}
+}
@@ -37,6 +37,8 @@ type AddMemberRequest struct {
 	RFID            string `json:"uid"`
 	AccessType      int    `json:"acctype"`
 	ValidUntil      int    `json:"validuntil"`
+	// Schedule - when the user can get in.  Only sent to resources that support schedules
+	Schedule []database.ScheduleWindow `json:"schedule,omitempty"`
 }
 
 type DeleteMemberRequest struct {
@@ -94,25 +96,52 @@ func UpdateResources() {
 	resources := db.GetResources()
 
 	for _, r := range resources {
-		members, _ := db.GetResourceACLWithMemberInfo(r)
-		for _, m := range members {
-			b, _ := json.Marshal(&AddMemberRequest{
-				ResourceAddress: r.Address,
-				Command:         "adduser",
-				UserName:        m.Name,
-				RFID:            m.RFID,
-				AccessType:      1,
-				ValidUntil:      -86400,
-			})
-			Publish(r.Name, string(b))
-
-			time.Sleep(2 * time.Second)
+		pushMembers(db, r)
+	}
+
+	db.Release()
+}
+
+// UpdateResourceSchedules - pushes a resource's schedules after they change.
+//   Resources that support schedules get each member with their schedule,
+//   the others get the members that can get in right now.
+func UpdateResourceSchedules(r database.Resource) {
+	if !r.SupportsSchedules {
+		err := UpdateResourceACL(r)
+		if err != nil {
+			log.Errorf("error updating %s after its schedules changed: %s", r.Name, err)
 		}
+		return
+	}
+
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
 	}
 
+	pushMembers(db, r)
+
 	db.Release()
 }
 
+func pushMembers(db *database.Database, r database.Resource) {
+	members, _ := db.GetResourceACLWithMemberInfo(r)
+	for _, m := range members {
+		b, _ := json.Marshal(&AddMemberRequest{
+			ResourceAddress: m.ResourceAddress,
+			Command:         "adduser",
+			UserName:        m.Name,
+			RFID:            m.RFID,
+			AccessType:      1,
+			ValidUntil:      -86400,
+			Schedule:        m.Schedule,
+		})
+		Publish(m.ResourceName, string(b))
+
+		time.Sleep(2 * time.Second)
+	}
+}
+
 // PushOne - update one user on the resources
 func PushOne(m database.Member) {
 	db, err := database.Setup()
@@ -129,6 +158,7 @@ func PushOne(m database.Member) {
 			RFID:            m.RFID,
 			AccessType:      1,
 			ValidUntil:      -86400,
+			Schedule:        m.Schedule,
 		})
 		Publish(m.ResourceName, string(b))
 	}
@@ -136,6 +166,101 @@ func PushOne(m database.Member) {
 	db.Release()
 }
 
+// ApplyTierEntitlements - grants and removes the resources that come with the members' tiers
+//   and pushes the changes to the resources
+func ApplyTierEntitlements() {
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
+	}
+
+	changes, err := db.SyncTierEntitlements()
+	if err != nil {
+		log.Errorf("error applying tier entitlements: %s", err)
+		db.Release()
+		return
+	}
+
+	granted := make(map[string]bool)
+	removed := make(map[string]bool)
+
+	for _, c := range changes {
+		if c.Action == database.ResourceAccessGranted {
+			granted[c.Email] = true
+			continue
+		}
+
+		removed[c.ResourceID] = true
+	}
+
+	for email := range granted {
+		PushOne(database.Member{Email: email})
+	}
+
+	for resourceID := range removed {
+		r, err := db.GetResourceByID(resourceID)
+		if err != nil {
+			log.Errorf("error getting resource to remove tier entitlements: %s", err)
+			continue
+		}
+
+		err = UpdateResourceACL(r)
+		if err != nil {
+			log.Errorf("error removing tier entitlements from %s: %s", r.Name, err)
+		}
+	}
+
+	db.Release()
+}
+
+// PushGuest - adds a guest to their resources until their pass ends
+func PushGuest(g database.Guest) {
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
+	}
+
+	guestAccess, _ := db.GetGuestAccess(g)
+	for _, a := range guestAccess {
+		b, _ := json.Marshal(&AddMemberRequest{
+			ResourceAddress: a.ResourceAddress,
+			Command:         "adduser",
+			UserName:        a.Name,
+			RFID:            a.RFID,
+			AccessType:      1,
+			ValidUntil:      int(a.ValidUntil.Unix()),
+			Schedule:        a.Schedule,
+		})
+		Publish(a.ResourceName, string(b))
+	}
+
+	db.Release()
+}
+
+// RemoveGuest - pushes the access list of each of the guest's resources
+//   so that the guest is taken off of them
+func RemoveGuest(g database.Guest) {
+	db, err := database.Setup()
+	if err != nil {
+		log.Errorf("error setting up db: %s", err)
+	}
+
+	for _, gr := range g.Resources {
+		r, err := db.GetResourceByID(gr.ResourceID)
+		if err != nil {
+			log.Errorf("error getting resource to remove guest: %s", err)
+			continue
+		}
+
+		err = UpdateResourceACL(r)
+		if err != nil {
+			log.Errorf("error removing guest from %s: %s", r.Name, err)
+		}
+	}
+
+	db.Release()
+}
+
 func DeleteResourceACL() {
 	db, err := database.Setup()
 	if err != nil {
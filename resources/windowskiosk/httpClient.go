@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type serviceAuthResponse struct {
@@ -16,19 +19,6 @@ type servieAuthRequest struct {
 	Password string `json:"password"`
 }
 
-type resource struct {
-	ID   string `json:"resourceID"`
-	Name string `json:"name"`
-}
-
-type member struct {
-	Name        string     `json:"name"`
-	Email       string     `json:"email"`
-	RFID        string     `json:"rfid"`
-	MemberLevel uint       `json:"memberLevel"`
-	Resources   []resource `json:"resources"`
-}
-
 func requestToken() (string, error) {
 	c := loadConfig()
 
@@ -64,21 +54,32 @@ func requestToken() (string, error) {
 	return newAccessToken.Token, err
 }
 
-func getMembers(token string) []member {
+// getACL gets the tags that have access to the kiosk.
+//   The server encodes them in the resource's rfid format, which should be decimalBytes
+//   since that is how the reader types them.
+func getACL(token string) []string {
 	c := loadConfig()
 
-	req, _ := http.NewRequest("GET", c.ServiceURL+"/api/member", nil)
+	query := url.Values{}
+	query.Set("resource", c.ResourceName)
+
+	req, _ := http.NewRequest("GET", c.ServiceURL+"/api/resource/acl?"+query.Encode(), nil)
 	client := &http.Client{}
 
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	resp, _ := client.Do(req)
+	var acl []string
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Errorf("couldn't get the access list %s", err)
+		return acl
+	}
 	defer resp.Body.Close()
 
 	body, _ := ioutil.ReadAll(resp.Body)
 
-	members := []member{}
-	json.Unmarshal(body, &members)
+	json.Unmarshal(body, &acl)
 
-	return members
+	return acl
 }
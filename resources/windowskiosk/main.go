@@ -13,23 +13,11 @@ func main() {
 	if err != nil {
 		log.Errorf("couldn't get token %s", err)
 	}
-	members := getMembers(token)
+	acl := getACL(token)
 
-	for _, m := range members {
-		if contains(m.Resources, resource{Name: c.ResourceName}) {
-			file += decodeID(m.RFID) + credStr
-		}
+	for _, tag := range acl {
+		file += tag + credStr
 	}
 
 	writeFile([]byte(file))
 }
-
-func contains(s []resource, r resource) bool {
-	for _, v := range s {
-		if v.Name == r.Name {
-			return true
-		}
-	}
-
-	return false
-}
@@ -0,0 +1,216 @@
+package rfid
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UID - the unique id of a tag.  The bytes are in the order the reader reads them off of the tag.
+//   This is what we store, each resource gets the tag encoded in the format it expects.
+type UID []byte
+
+// Format - how a resource expects rfid tags to be encoded
+type Format string
+
+const (
+	// FormatESP - each byte in hex without the leading zero, i.e. 4e61bc0.
+	//   This is how our esp32 rfid reader prints the UID.
+	FormatESP Format = "esp"
+	// FormatUIDHex - the raw UID in hex with every byte zero padded, i.e. 4E61BC00
+	FormatUIDHex Format = "uidHex"
+	// FormatDecimal - the number printed on the tag.  The UID is read as a little endian number
+	//   and padded to 10 digits for a 4 byte UID or 17 digits for a 7 byte UID, i.e. 0012345678
+	FormatDecimal Format = "decimal"
+	// FormatDecimalBytes - each byte in decimal separated by a space, i.e. 78 97 188 0.
+	//   This is how the windows kiosk reader types the UID.
+	FormatDecimalBytes Format = "decimalBytes"
+	// FormatWiegand26 - the facility code and card number of a wiegand 26 reader, i.e. 188:24910.
+	//   Wiegand 26 only carries 24 bits, the facility code is the third byte of the UID
+	//   and the card number is the first two bytes.
+	FormatWiegand26 Format = "wiegand26"
+)
+
+// DefaultFormat - the format of resources that haven't picked one
+const DefaultFormat = FormatESP
+
+// Formats lists the formats a resource can use
+var Formats = map[Format]bool{
+	FormatESP:          true,
+	FormatUIDHex:       true,
+	FormatDecimal:      true,
+	FormatDecimalBytes: true,
+	FormatWiegand26:    true,
+}
+
+const (
+	// single size UIDs are 4 bytes
+	singleSize = 4
+	// double size UIDs are 7 bytes
+	doubleSize = 7
+
+	singleSizeDecimalDigits = 10
+	doubleSizeDecimalDigits = 17
+)
+
+// ErrAmbiguous - the tag can't be decoded since more than one UID encodes to it
+var ErrAmbiguous = errors.New("the tag can be read more than one way")
+
+// ParseUID reads a UID that was stored with String
+func ParseUID(s string) (UID, error) {
+	return Decode(FormatUIDHex, s)
+}
+
+// String - the UID in the FormatUIDHex format.  This is how the UID is stored.
+func (u UID) String() string {
+	return strings.ToUpper(hex.EncodeToString(u))
+}
+
+func (u UID) validate() error {
+	if len(u) != singleSize && len(u) != doubleSize {
+		return fmt.Errorf("a UID must be %d or %d bytes, got %d", singleSize, doubleSize, len(u))
+	}
+
+	return nil
+}
+
+// number - the UID as a little endian number
+func (u UID) number() uint64 {
+	var n uint64
+	for i := len(u) - 1; i >= 0; i-- {
+		n = n<<8 | uint64(u[i])
+	}
+
+	return n
+}
+
+func fromNumber(n uint64, size int) UID {
+	u := make(UID, size)
+	for i := range u {
+		u[i] = byte(n)
+		n >>= 8
+	}
+
+	return u
+}
+
+// Encode - renders the UID in the format a resource expects.
+//   For every format except FormatESP and FormatWiegand26, Decode(f, Encode(f, u)) returns u.
+//   For every format, Encode(f, Decode(f, s)) returns s if s is encoded the same way Encode would.
+func Encode(f Format, u UID) (string, error) {
+	err := u.validate()
+	if err != nil {
+		return "", err
+	}
+
+	switch f {
+	case FormatESP:
+		var s string
+		for _, b := range u {
+			s += strconv.FormatUint(uint64(b), 16)
+		}
+		return s, nil
+	case FormatUIDHex:
+		return u.String(), nil
+	case FormatDecimal:
+		digits := singleSizeDecimalDigits
+		if len(u) == doubleSize {
+			digits = doubleSizeDecimalDigits
+		}
+		return fmt.Sprintf("%0*d", digits, u.number()), nil
+	case FormatDecimalBytes:
+		var bytes []string
+		for _, b := range u {
+			bytes = append(bytes, strconv.Itoa(int(b)))
+		}
+		return strings.Join(bytes, " "), nil
+	case FormatWiegand26:
+		return fmt.Sprintf("%03d:%05d", u[2], uint16(u[1])<<8|uint16(u[0])), nil
+	}
+
+	return "", fmt.Errorf("unknown rfid format: %s", f)
+}
+
+// Decode - reads a tag that was encoded in the format.
+//   FormatESP can only be decoded when every byte is two characters long since
+//   the reader drops the leading zero.  ErrAmbiguous is returned for tags that can't be that long.
+//   An 8 character FormatESP tag is always read as a single size UID, even though it is also how the reader prints
+//   a double size UID with six bytes below 0x10, i.e. 1234567a is 12 34 56 7a but could be 01 02 03 04 05 06 7a.
+//   Those double size UIDs can't be decoded from FormatESP.
+//   FormatWiegand26 decodes to a single size UID with the fourth byte set to zero.
+func Decode(f Format, s string) (UID, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("the tag is empty")
+	}
+
+	var u UID
+
+	switch f {
+	case FormatESP:
+		if len(s) != singleSize*2 && len(s) != doubleSize*2 {
+			return nil, ErrAmbiguous
+		}
+		for i := 0; i < len(s); i += 2 {
+			if s[i] == '0' {
+				return nil, ErrAmbiguous
+			}
+		}
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("not a valid %s tag: %s", f, s)
+		}
+		u = b
+	case FormatUIDHex:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("not a valid %s tag: %s", f, s)
+		}
+		u = b
+	case FormatDecimal:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a valid %s tag: %s", f, s)
+		}
+		if len(s) <= singleSizeDecimalDigits && n <= 0xFFFFFFFF {
+			u = fromNumber(n, singleSize)
+		} else if len(s) <= doubleSizeDecimalDigits && n <= 0xFFFFFFFFFFFFFF {
+			u = fromNumber(n, doubleSize)
+		} else {
+			return nil, fmt.Errorf("%s is too large for a UID", s)
+		}
+	case FormatDecimalBytes:
+		for _, field := range strings.Fields(s) {
+			b, err := strconv.ParseUint(field, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("not a valid %s tag: %s", f, s)
+			}
+			u = append(u, byte(b))
+		}
+	case FormatWiegand26:
+		parts := strings.Split(s, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("not a valid %s tag: %s", f, s)
+		}
+		facility, err := strconv.ParseUint(parts[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("not a valid %s facility code: %s", f, parts[0])
+		}
+		card, err := strconv.ParseUint(parts[1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("not a valid %s card number: %s", f, parts[1])
+		}
+		u = UID{byte(card), byte(card >> 8), byte(facility), 0}
+	default:
+		return nil, fmt.Errorf("unknown rfid format: %s", f)
+	}
+
+	err := u.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
@@ -0,0 +1,137 @@
+package rfid
+
+import (
+	"bytes"
+	"testing"
+)
+
+var uids = []UID{
+	{0x4E, 0x61, 0xBC, 0x00},
+	{0x01, 0x02, 0x03, 0x04},
+	{0xFF, 0xFF, 0xFF, 0xFF},
+	{0x04, 0x1A, 0x2B, 0x3C, 0x4D, 0x5E, 0x80},
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+}
+
+func TestEncode(t *testing.T) {
+	u := UID{0x4E, 0x61, 0xBC, 0x00}
+
+	tests := map[Format]string{
+		FormatESP:          "4e61bc0",
+		FormatUIDHex:       "4E61BC00",
+		FormatDecimal:      "0012345678",
+		FormatDecimalBytes: "78 97 188 0",
+		FormatWiegand26:    "188:24910",
+	}
+
+	for f, expected := range tests {
+		s, err := Encode(f, u)
+		if err != nil {
+			t.Fatalf("failed to encode %s: %v", f, err)
+		}
+		if s != expected {
+			t.Errorf("%s: expected %s, got %s", f, expected, s)
+		}
+	}
+}
+
+func TestLosslessRoundTrip(t *testing.T) {
+	for _, f := range []Format{FormatUIDHex, FormatDecimal, FormatDecimalBytes} {
+		for _, u := range uids {
+			s, err := Encode(f, u)
+			if err != nil {
+				t.Fatalf("failed to encode %s as %s: %v", u, f, err)
+			}
+
+			decoded, err := Decode(f, s)
+			if err != nil {
+				t.Fatalf("failed to decode %s as %s: %v", s, f, err)
+			}
+
+			if !bytes.Equal(decoded, u) {
+				t.Errorf("%s: %s round tripped to %s", f, u, decoded)
+			}
+		}
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	tests := map[Format][]string{
+		FormatESP:          {"4e61bc12", "f1efcdab", "41a2b3c4d5e6f7"},
+		FormatUIDHex:       {"4E61BC00", "041A2B3C4D5E80"},
+		FormatDecimal:      {"0012345678", "4294967295", "00000000000000001"},
+		FormatDecimalBytes: {"78 97 188 0", "4 26 43 60 77 94 128"},
+		FormatWiegand26:    {"188:24910", "000:00001", "255:65535"},
+	}
+
+	for f, tags := range tests {
+		for _, s := range tags {
+			u, err := Decode(f, s)
+			if err != nil {
+				t.Fatalf("failed to decode %s as %s: %v", s, f, err)
+			}
+
+			encoded, err := Encode(f, u)
+			if err != nil {
+				t.Fatalf("failed to encode %s as %s: %v", u, f, err)
+			}
+
+			if encoded != s {
+				t.Errorf("%s: %s round tripped to %s", f, s, encoded)
+			}
+		}
+	}
+}
+
+func TestDecodeESPAmbiguous(t *testing.T) {
+	// the reader drops the leading zero so 4e61bc0 could be more than one UID
+	for _, s := range []string{"4e61bc0", "4e610bc1", "1234567"} {
+		_, err := Decode(FormatESP, s)
+		if err != ErrAmbiguous {
+			t.Errorf("expected %s to be ambiguous, got %v", s, err)
+		}
+	}
+}
+
+func TestDecodeESPShortDoubleSize(t *testing.T) {
+	// a double size UID with six bytes below 0x10 prints as 8 characters
+	u := UID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x7a}
+
+	s, err := Encode(FormatESP, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s != "1234567a" {
+		t.Fatalf("expected %s to be encoded as 1234567a, got %s", u, s)
+	}
+
+	// it can't be told apart from a single size UID, which is what it's read as
+	decoded, err := Decode(FormatESP, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decoded, UID{0x12, 0x34, 0x56, 0x7a}) {
+		t.Errorf("expected %s to be read as a single size UID, got %s", s, decoded)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := map[Format][]string{
+		FormatUIDHex:       {"", "4E61BC", "zz61BC00", "4E61BC0011"},
+		FormatDecimal:      {"abc", "-1", "72057594037927936"},
+		FormatDecimalBytes: {"78 97 188", "78 97 188 256"},
+		FormatWiegand26:    {"188", "256:1", "1:65536"},
+	}
+
+	for f, tags := range tests {
+		for _, s := range tags {
+			_, err := Decode(f, s)
+			if err == nil {
+				t.Errorf("expected %s to be an invalid %s tag", s, f)
+			}
+		}
+	}
+}
@@ -29,6 +29,15 @@ const resourceUpdateInterval = 4
 // checkIPInterval - check the IP Address daily
 const checkIPInterval = 24
 
+// checkGuestPassesInterval - start and end guest passes every 5 minutes
+const checkGuestPassesInterval = 5
+
+// checkSchedulesInterval - refresh resources as their schedules open and close every minute
+const checkSchedulesInterval = 1
+
+// checkCertificationsInterval - take members off resources as their certifications expire every 5 minutes
+const checkCertificationsInterval = 5
+
 var c config.Config
 var mailApi mail.MailApi
 var db *database.Database
@@ -46,6 +55,9 @@ func Setup(d *database.Database) {
 	scheduleTask(resourceStatusCheckInterval*time.Hour, checkResourceInit, checkResourceTick)
 	scheduleTask(resourceUpdateInterval*time.Hour, resourcemanager.UpdateResources, resourcemanager.UpdateResources)
 	scheduleTask(checkIPInterval*time.Hour, checkIPAddressTick, checkIPAddressTick)
+	scheduleTask(checkGuestPassesInterval*time.Minute, checkGuestPasses, checkGuestPasses)
+	scheduleTask(checkSchedulesInterval*time.Minute, checkSchedulesInit, checkSchedules)
+	scheduleTask(checkCertificationsInterval*time.Minute, checkCertificationsInit, checkCertifications)
 }
 
 func scheduleTask(interval time.Duration, initFunc func(), tickFunc func()) {
@@ -68,11 +80,12 @@ func scheduleTask(interval time.Duration, initFunc func(), tickFunc func()) {
 }
 
 func checkMemberStatus() {
+	db.RevokeLapsedCredits()
 	db.ApplyMemberCredits()
 	db.UpdateMemberTiers()
 
-	const memberGracePeriod = 46
-	const membershipMonth = 31
+	// grant and remove the tier resources once the revocations below are done
+	defer resourcemanager.ApplyTierEntitlements()
 
 	mailer := mail.NewMailer(db, mailApi, c)
 
@@ -85,16 +98,14 @@ func checkMemberStatus() {
 
 	pastDueAccounts := db.GetPastDueAccounts()
 	for _, a := range pastDueAccounts {
-		if a.DaysSinceLastPayment > memberGracePeriod {
+		if database.PastGracePeriod(a.DaysPastDue) {
 			mailer.SendCommunication(mail.AccessRevokedLeadership, c.AdminEmail, a)
 			mailer.SendCommunication(mail.AccessRevokedMember, a.Email, a)
-			db.SetMemberLevel(a.MemberId, database.Inactive)
-		} else if a.DaysSinceLastPayment > membershipMonth {
-			if !mailer.IsThrottled(pendingRevokation, database.Member{ID: a.MemberId}) {
-				//TODO: [ML] Does it make sense to send this to leadership?  It might be like spam...
-				mailer.SendCommunication(mail.PendingRevokationLeadership, c.AdminEmail, a)
-				mailer.SendCommunication(mail.PendingRevokationMember, a.Email, a)
-			}
+			db.SetMemberLevel(a.MemberId, database.Inactive, database.TierChangeRevoked)
+		} else if !mailer.IsThrottled(pendingRevokation, database.Member{ID: a.MemberId}) {
+			//TODO: [ML] Does it make sense to send this to leadership?  It might be like spam...
+			mailer.SendCommunication(mail.PendingRevokationLeadership, c.AdminEmail, a)
+			mailer.SendCommunication(mail.PendingRevokationMember, a.Email, a)
 		}
 	}
 }
@@ -185,3 +196,116 @@ func checkIPAddressTick() {
 	mailer := mail.NewMailer(db, mailApi, c)
 	mailer.SendCommunication(mail.IpChanged, c.AdminEmail, ipModel)
 }
+
+// checkGuestPasses pushes guest passes that have started to the resources
+//   and takes off the ones that have ended
+func checkGuestPasses() {
+	guests, err := db.GetGuestsToPush()
+	if err != nil {
+		log.Errorf("error getting guest passes to push: %s", err)
+	}
+
+	for _, g := range guests {
+		resourcemanager.PushGuest(g)
+
+		err = db.MarkGuestPushed(g.ID)
+		if err != nil {
+			log.Errorf("error marking guest pass pushed: %s", err)
+			continue
+		}
+
+		log.Infof("guest pass %d for %s started, valid until %s", g.ID, g.Name, g.EndsAt.Format(time.RFC3339))
+	}
+
+	expired, err := db.GetExpiredGuests()
+	if err != nil {
+		log.Errorf("error getting expired guest passes: %s", err)
+	}
+
+	for _, g := range expired {
+		g, err = db.RemoveGuest(g.ID, database.GuestExpired)
+		if err != nil {
+			log.Errorf("error removing expired guest pass: %s", err)
+			continue
+		}
+
+		resourcemanager.RemoveGuest(g)
+
+		log.Infof("guest pass %d for %s expired and was removed from the resources", g.ID, g.Name)
+	}
+}
+
+// lastScheduleCheck - when the schedules were last checked
+var lastScheduleCheck time.Time
+
+func checkSchedulesInit() {
+	lastScheduleCheck = time.Now()
+}
+
+// checkSchedules pushes the access list of resources that don't support schedules
+//   when one of their schedules opened or closed since the last check
+func checkSchedules() {
+	now := time.Now()
+	loc := database.ScheduleLocation()
+
+	schedules, err := db.GetSchedules()
+	if err != nil {
+		log.Errorf("error getting schedules: %s", err)
+		return
+	}
+
+	for _, r := range db.GetResources() {
+		if r.SupportsSchedules {
+			continue
+		}
+
+		if !schedules.ForResource(r.ID).ChangedBetween(lastScheduleCheck.In(loc), now.In(loc)) {
+			continue
+		}
+
+		err = resourcemanager.UpdateResourceACL(r)
+		if err != nil {
+			log.Errorf("error updating %s for its schedule: %s", r.Name, err)
+			continue
+		}
+
+		log.Infof("a schedule for %s opened or closed, pushed its access list", r.Name)
+	}
+
+	lastScheduleCheck = now
+}
+
+// lastCertificationCheck - when the certifications were last checked
+var lastCertificationCheck time.Time
+
+// checkCertificationsInit pushes the access list of every resource that requires a certification that has expired,
+//   certifications may have expired while the server was down
+func checkCertificationsInit() {
+	lastCertificationCheck = time.Time{}
+	checkCertifications()
+}
+
+// checkCertifications pushes the access list of the resources that require a certification
+//   that expired since the last check.  Pushing a member only adds them,
+//   the whole access list is pushed to take off the members whose certification expired
+func checkCertifications() {
+	now := time.Now()
+
+	resources, err := db.GetResourcesWithExpiredCertifications(lastCertificationCheck, now)
+	if err != nil {
+		log.Errorf("error getting resources with expired certifications: %s", err)
+		return
+	}
+
+	for _, r := range resources {
+		err = resourcemanager.UpdateResourceACL(r)
+		if err != nil {
+			log.Errorf("error updating %s for expired certifications: %s", r.Name, err)
+			continue
+		}
+
+		log.Infof("a certification %s requires expired, pushed its access list", r.Name)
+	}
+
+	lastCertificationCheck = now
+}
@@ -59,13 +59,14 @@ func fakePaymentHistory(member database.Member, lastPayment time.Time, numberOfP
 	for i := 0; i < numberOfPayments; i++ {
 		paymentDate := lastPayment.AddDate(0, -i, 0)
 		payments = append(payments, database.Payment{
-			ID:       faker.Number().Number(8),
-			Date:     paymentDate,
-			Amount:   *money.New(tiers[member.Level]*100, "USD"),
-			Provider: 1,
-			MemberID: member.ID,
-			Email:    member.Email,
-			Name:     member.Name,
+			ID:            faker.Number().Number(8),
+			Date:          paymentDate,
+			Amount:        *money.New(tiers[member.Level]*100, "USD"),
+			Provider:      database.Paypal,
+			MemberID:      member.ID,
+			Email:         member.Email,
+			Name:          member.Name,
+			TransactionID: faker.Lorem().Characters(17),
 		})
 	}
 	return payments
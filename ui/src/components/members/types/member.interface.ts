@@ -14,6 +14,16 @@ export interface MemberResponse {
   resources: Array<MemberResource>;
 }
 
+export interface MemberListResponse {
+  members: Array<MemberResponse>;
+  total: number;
+  page: number;
+  limit: number;
+  sort: string;
+  order: string;
+  sortOptions: Array<string>;
+}
+
 export interface MemberResource {
   resourceID: string;
   name: string;
@@ -1,5 +1,6 @@
 // rxjs
 import { Observable } from "rxjs";
+import { map } from "rxjs/operators";
 
 // memberdashboard
 import { HTTPService } from "./http.service";
@@ -7,6 +8,7 @@ import { ENV } from "../env";
 import {
   AssignRFIDRequest,
   MemberResponse,
+  MemberListResponse,
   CreateMemberRequest,
 } from "../components/members/types";
 
@@ -14,7 +16,9 @@ export class MemberService extends HTTPService {
   private readonly memberUrlSegment: string = ENV.api + "/member";
 
   getMembers(): Observable<MemberResponse[]> {
-    return this.get<MemberResponse[]>(this.memberUrlSegment);
+    return this.get<MemberListResponse>(this.memberUrlSegment).pipe(
+      map((result: MemberListResponse) => result.members)
+    );
   }
 
   assignRFID(request: AssignRFIDRequest): Observable<void> {